@@ -5,15 +5,21 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"formera/internal/config"
 	"formera/internal/database"
+	"formera/internal/events"
+	"formera/internal/export"
 	"formera/internal/handlers"
+	"formera/internal/jobs"
 	"formera/internal/logger"
+	"formera/internal/mailer"
 	"formera/internal/middleware"
 	"formera/internal/storage"
+	"formera/internal/webhooks"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -69,6 +75,35 @@ func main() {
 	cleanupScheduler := startCleanupScheduler(cfg, store)
 	defer cleanupScheduler.Stop()
 
+	// Background job queue; handlers are registered before it starts
+	queue := jobs.New(database.DB, jobs.Config{
+		Workers:   cfg.Jobs.Workers,
+		Retention: time.Duration(cfg.Jobs.RetentionDays) * 24 * time.Hour,
+	})
+
+	// Start mailer for notification emails
+	mail := startMailer(cfg)
+	defer mail.Stop()
+	mail.UseQueue(queue)
+
+	// Background exports are stored until they expire
+	exportRunner := export.NewRunner(database.DB, store)
+	exportRunner.Register(queue)
+	exportRunner.UseMailer(mail)
+
+	// Webhook deliveries are retried through the queue
+	hookConfig := webhooks.DefaultConfig()
+	hookConfig.AllowPrivateNetworks = cfg.Webhooks.AllowPrivateNetworks
+	hookConfig.Retention = time.Duration(cfg.Webhooks.RetentionDays) * 24 * time.Hour
+	hooks := webhooks.NewDispatcher(database.DB, hookConfig)
+	hooks.Register(queue)
+
+	queue.Start()
+	defer queue.Stop()
+
+	// Broker for live submission streams
+	broker := events.NewBroker()
+
 	// Setup Gin router with custom middleware
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
@@ -100,15 +135,17 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{cfg.CorsOrigin},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "If-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag"},
 		AllowCredentials: true,
 	}))
 	// Serve uploaded files - works for both local and S3 storage
 	// For local storage: serves files directly from disk
 	// For S3 storage: redirects to presigned URLs
 	if cfg.Storage.GetStorageType() == "local" {
-		r.Static("/uploads", cfg.Storage.LocalPath)
+		// Only uploads are public; generated exports are downloaded through the API
+		r.Static("/uploads/images", filepath.Join(cfg.Storage.LocalPath, "images"))
+		r.Static("/uploads/files", filepath.Join(cfg.Storage.LocalPath, "files"))
 	} else {
 		// For S3, use the upload handler to generate presigned URLs
 		uploadHandlerForFiles := handlers.NewUploadHandler(store)
@@ -117,11 +154,24 @@ func main() {
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(cfg.JWTSecret)
-	formHandler := handlers.NewFormHandler()
-	submissionHandler := handlers.NewSubmissionHandler()
+	formHandler := handlers.NewFormHandler(hooks)
+	submissionHandler := handlers.NewSubmissionHandler(mail, hooks, broker)
 	setupHandler := handlers.NewSetupHandler(cfg.JWTSecret)
 	uploadHandler := handlers.NewUploadHandler(store)
-	userHandler := handlers.NewUserHandler()
+	userHandler := handlers.NewUserHandler(hooks)
+	dataSubjectHandler := handlers.NewDataSubjectHandler(store, hooks)
+	pdfHandler := handlers.NewPDFHandler(store)
+	exportHandler := handlers.NewExportHandler(exportRunner, store)
+	bundleHandler := handlers.NewBundleHandler(store)
+	templateHandler := handlers.NewTemplateHandler()
+	versionHandler := handlers.NewVersionHandler()
+	shareHandler := handlers.NewShareHandler()
+	auditHandler := handlers.NewAuditHandler()
+	webhookHandler := handlers.NewWebhookHandler(hooks)
+	jobHandler := handlers.NewJobHandler(queue)
+	streamHandler := handlers.NewStreamHandler(broker, cfg.JWTSecret)
+	analyticsHandler := handlers.NewAnalyticsHandler(cfg.BaseURL)
+	dashboardHandler := handlers.NewDashboardHandler()
 
 	// Public routes with global rate limit (100 req/min per IP)
 	api := r.Group("/api")
@@ -141,6 +191,7 @@ func main() {
 
 		// Form submission with moderate rate limit (30 req/min per IP)
 		api.POST("/public/forms/:id/submit", middleware.SubmissionRateLimiter(), submissionHandler.Submit)
+		api.POST("/public/forms/:id/start", middleware.APIRateLimiter(), analyticsHandler.RecordStart)
 
 		// Public file upload (for form submissions with file fields)
 		api.POST("/public/upload", uploadHandler.UploadFile)
@@ -149,6 +200,9 @@ func main() {
 		api.GET("/files/*path", uploadHandler.GetFile)
 	}
 
+	// Live submission stream; accepts a stream ticket as query parameter for EventSource
+	api.GET("/forms/:id/stream", middleware.StreamAuthMiddleware(cfg.JWTSecret), streamHandler.Stream)
+
 	// Protected routes
 	protected := api.Group("/")
 	protected.Use(middleware.AuthMiddleware(cfg.JWTSecret))
@@ -157,22 +211,69 @@ func main() {
 		protected.GET("/auth/me", authHandler.Me)
 
 		// Form routes
+		protected.GET("/dashboard", dashboardHandler.Summary)
 		protected.GET("/forms", formHandler.List)
 		protected.POST("/forms", formHandler.Create)
 		protected.GET("/forms/:id", formHandler.Get)
 		protected.PUT("/forms/:id", formHandler.Update)
 		protected.DELETE("/forms/:id", formHandler.Delete)
 		protected.POST("/forms/:id/duplicate", formHandler.Duplicate)
+		protected.GET("/forms/:id/bundle", bundleHandler.Export)
+		protected.POST("/forms/import", bundleHandler.Import)
+		protected.GET("/forms/:id/versions", versionHandler.List)
+		protected.GET("/forms/:id/versions/diff", versionHandler.Diff)
+		protected.GET("/forms/:id/versions/:version", versionHandler.Get)
+
+		// Sharing routes
+		protected.GET("/forms/:id/shares", shareHandler.List)
+		protected.POST("/forms/:id/shares", shareHandler.Create)
+		protected.PUT("/forms/:id/shares/:shareId", shareHandler.Update)
+		protected.DELETE("/forms/:id/shares/:shareId", shareHandler.Delete)
 		protected.GET("/forms/check-slug", formHandler.CheckSlugAvailability)
 
+		// Template routes
+		protected.GET("/templates", templateHandler.List)
+		protected.GET("/templates/:id", templateHandler.Get)
+		protected.POST("/templates/:id/use", templateHandler.Use)
+
 		// Submission routes
 		protected.GET("/forms/:id/submissions", submissionHandler.List)
+		protected.POST("/forms/:id/stream/ticket", streamHandler.Ticket)
+		protected.POST("/forms/:id/submissions/import", submissionHandler.Import)
 		protected.GET("/forms/:id/submissions/:submissionId", submissionHandler.Get)
 		protected.DELETE("/forms/:id/submissions/:submissionId", submissionHandler.Delete)
+		protected.GET("/forms/:id/submissions/:submissionId/pdf", pdfHandler.Submission)
 		protected.GET("/forms/:id/stats", submissionHandler.Stats)
+		protected.GET("/forms/:id/crosstab", submissionHandler.Crosstab)
 		protected.GET("/forms/:id/submissions/by-date", submissionHandler.SubmissionsByDate)
+		protected.GET("/forms/:id/analytics", analyticsHandler.Funnel)
+		protected.GET("/forms/:id/attribution", analyticsHandler.Attribution)
 		protected.GET("/forms/:id/export/csv", submissionHandler.ExportCSV)
 		protected.GET("/forms/:id/export/json", submissionHandler.ExportJSON)
+		protected.GET("/forms/:id/export/xlsx", submissionHandler.ExportXLSX)
+		protected.GET("/forms/:id/export/pdf", pdfHandler.Bulk)
+		protected.GET("/forms/:id/export/zip", exportHandler.Archive)
+		protected.GET("/forms/:id/exports", exportHandler.List)
+		protected.GET("/forms/:id/exports/:exportId", exportHandler.Get)
+		protected.GET("/forms/:id/exports/:exportId/download", exportHandler.Download)
+		protected.GET("/forms/:id/export-presets", exportHandler.ListPresets)
+		protected.POST("/forms/:id/export-presets", exportHandler.CreatePreset)
+		protected.PUT("/forms/:id/export-presets/:presetId", exportHandler.UpdatePreset)
+		protected.DELETE("/forms/:id/export-presets/:presetId", exportHandler.DeletePreset)
+		protected.GET("/forms/:id/export-schedules", exportHandler.ListSchedules)
+		protected.POST("/forms/:id/export-schedules", exportHandler.CreateSchedule)
+		protected.GET("/forms/:id/export-schedules/:scheduleId", exportHandler.GetSchedule)
+		protected.PUT("/forms/:id/export-schedules/:scheduleId", exportHandler.UpdateSchedule)
+		protected.DELETE("/forms/:id/export-schedules/:scheduleId", exportHandler.DeleteSchedule)
+		protected.GET("/forms/:id/export-schedules/:scheduleId/runs", exportHandler.ScheduleRuns)
+
+		// Webhook routes
+		protected.GET("/forms/:id/webhooks", webhookHandler.List)
+		protected.POST("/forms/:id/webhooks", webhookHandler.Create)
+		protected.PUT("/forms/:id/webhooks/:webhookId", webhookHandler.Update)
+		protected.DELETE("/forms/:id/webhooks/:webhookId", webhookHandler.Delete)
+		protected.GET("/forms/:id/webhooks/:webhookId/deliveries", webhookHandler.Deliveries)
+		protected.POST("/forms/:id/webhooks/:webhookId/deliveries/:deliveryId/redeliver", webhookHandler.Redeliver)
 
 		// Upload routes (authenticated)
 		protected.POST("/uploads/image", uploadHandler.UploadImage)
@@ -195,6 +296,23 @@ func main() {
 		admin.POST("/users", userHandler.Create)
 		admin.PUT("/users/:id", userHandler.Update)
 		admin.DELETE("/users/:id", userHandler.Delete)
+
+		// Data subject requests (GDPR access and erasure)
+		admin.GET("/data-subjects/search", dataSubjectHandler.Search)
+		admin.GET("/data-subjects/export", dataSubjectHandler.Export)
+		admin.POST("/data-subjects/erase", dataSubjectHandler.Erase)
+
+		// Form templates (admin only)
+		admin.PUT("/forms/:id/template", templateHandler.Mark)
+		admin.DELETE("/forms/:id/template", templateHandler.Unmark)
+
+		// Audit log
+		admin.GET("/audit-logs", auditHandler.List)
+
+		// Background job routes (admin only)
+		admin.GET("/jobs", jobHandler.List)
+		admin.GET("/jobs/:id", jobHandler.Get)
+		admin.POST("/jobs/:id/retry", jobHandler.Retry)
 	}
 
 	// Swagger documentation endpoint
@@ -250,11 +368,18 @@ func main() {
 	// Stop cleanup scheduler
 	cleanupScheduler.Stop()
 
+	// End live streams, otherwise Shutdown waits for them until the timeout
+	broker.Close()
+
 	// Shutdown HTTP server
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Error().Err(err).Msg("Server forced to shutdown")
 	}
 
+	// Stop background work after the server so in-flight requests can still queue jobs
+	queue.Stop()
+	mail.Stop()
+
 	logger.Info().Msg("Server exited")
 }
 
@@ -337,3 +462,21 @@ func startCleanupScheduler(cfg *config.Config, store storage.Storage) *storage.C
 
 	return scheduler
 }
+
+// startMailer initializes and starts the SMTP mailer
+func startMailer(cfg *config.Config) *mailer.Mailer {
+	mail := mailer.New(mailer.Config{
+		Host:       cfg.Mail.Host,
+		Port:       cfg.Mail.Port,
+		Username:   cfg.Mail.Username,
+		Password:   cfg.Mail.Password,
+		Encryption: cfg.Mail.Encryption,
+		From:       cfg.Mail.From,
+		FromName:   cfg.Mail.FromName,
+		BaseURL:    cfg.BaseURL,
+		MaxRetries: cfg.Mail.MaxRetries,
+	})
+	mail.Start()
+
+	return mail
+}
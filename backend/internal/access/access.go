@@ -0,0 +1,58 @@
+// Package access decides which forms a user may view and change. The creator
+// of a form is its owner; other users get a role through a FormShare.
+package access
+
+import (
+	"formera/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Role returns the role of a user on a form, or "" if the user has no access
+func Role(db *gorm.DB, form *models.Form, userID string) (models.FormRole, error) {
+	if userID == "" {
+		return "", nil
+	}
+	if form.UserID == userID {
+		return models.FormRoleOwner, nil
+	}
+	var share models.FormShare
+	if err := db.Where("form_id = ? AND user_id = ?", form.ID, userID).Limit(1).Find(&share).Error; err != nil {
+		return "", err
+	}
+	return share.Role, nil
+}
+
+// Roles returns the roles of a user on the given forms, which must all be
+// accessible to the user
+func Roles(db *gorm.DB, forms []models.Form, userID string) (map[string]models.FormRole, error) {
+	roles := make(map[string]models.FormRole, len(forms))
+	var shared []string
+	for _, f := range forms {
+		if f.UserID == userID {
+			roles[f.ID] = models.FormRoleOwner
+		} else {
+			shared = append(shared, f.ID)
+		}
+	}
+	if len(shared) == 0 {
+		return roles, nil
+	}
+
+	var shares []models.FormShare
+	if err := db.Where("user_id = ? AND form_id IN ?", userID, shared).Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	for _, s := range shares {
+		roles[s.FormID] = s.Role
+	}
+	return roles, nil
+}
+
+// Scope limits a query on the forms table to the forms a user owns or that
+// are shared with them
+func Scope(userID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(forms.user_id = ? OR forms.id IN (SELECT form_id FROM form_shares WHERE user_id = ?))", userID, userID)
+	}
+}
@@ -0,0 +1,64 @@
+package access
+
+import (
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+)
+
+func TestFormRole_Allows(t *testing.T) {
+	tests := []struct {
+		role, required models.FormRole
+		want           bool
+	}{
+		{models.FormRoleOwner, models.FormRoleOwner, true},
+		{models.FormRoleOwner, models.FormRoleViewer, true},
+		{models.FormRoleEditor, models.FormRoleEditor, true},
+		{models.FormRoleEditor, models.FormRoleOwner, false},
+		{models.FormRoleViewer, models.FormRoleViewer, true},
+		{models.FormRoleViewer, models.FormRoleEditor, false},
+		{"", models.FormRoleViewer, false},
+		{"admin", models.FormRoleViewer, false},
+	}
+	for _, tt := range tests {
+		if got := tt.role.Allows(tt.required); got != tt.want {
+			t.Errorf("%q.Allows(%q) = %v, want %v", tt.role, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestRoleAndScope(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	owned := &models.Form{UserID: "alice", Title: "Owned"}
+	shared := &models.Form{UserID: "bob", Title: "Shared"}
+	private := &models.Form{UserID: "bob", Title: "Private"}
+	db.Create(owned)
+	db.Create(shared)
+	db.Create(private)
+	db.Create(&models.FormShare{FormID: shared.ID, UserID: "alice", Role: models.FormRoleViewer})
+
+	for form, want := range map[*models.Form]models.FormRole{owned: models.FormRoleOwner, shared: models.FormRoleViewer, private: ""} {
+		if role, err := Role(db, form, "alice"); err != nil || role != want {
+			t.Errorf("%s: expected role %q, got %q (err %v)", form.Title, want, role, err)
+		}
+	}
+	if role, _ := Role(db, owned, ""); role != "" {
+		t.Errorf("expected no role without a user, got %q", role)
+	}
+
+	var forms []models.Form
+	db.Model(&models.Form{}).Scopes(Scope("alice")).Where("title <> ?", "Owned").Find(&forms)
+	if len(forms) != 1 || forms[0].ID != shared.ID {
+		t.Errorf("expected the scope to combine with other conditions, got %+v", forms)
+	}
+	db.Model(&models.Form{}).Scopes(Scope("alice")).Order("title").Find(&forms)
+	if len(forms) != 2 {
+		t.Fatalf("expected the owned and shared form, got %d", len(forms))
+	}
+
+	roles, err := Roles(db, forms, "alice")
+	if err != nil || roles[owned.ID] != models.FormRoleOwner || roles[shared.ID] != models.FormRoleViewer {
+		t.Errorf("unexpected roles %v (err %v)", roles, err)
+	}
+}
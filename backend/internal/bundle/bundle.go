@@ -0,0 +1,229 @@
+// Package bundle exports form definitions with their images as portable JSON
+// or ZIP bundles, so forms can be moved between instances
+package bundle
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"formera/internal/models"
+	"formera/internal/storage"
+)
+
+// Format identifies form bundles
+const Format = "formera-form"
+
+// Version is the bundle version written by this package
+const Version = 1
+
+// MaxAssets limits the number of images of a bundle
+const MaxAssets = 100
+
+// Definition is the portable part of a form. The slug, status and password
+// belong to an instance and are not exported.
+type Definition struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Fields      models.FormFields   `json:"fields"`
+	Settings    models.FormSettings `json:"settings"`
+}
+
+// Asset is an image referenced by the form definition
+type Asset struct {
+	Ref         string `json:"ref"` // Reference in the definition, a storage path or upload URL
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data,omitempty"` // Content, base64 encoded in JSON bundles
+	File        string `json:"file,omitempty"` // Name of the content in ZIP bundles
+}
+
+// Bundle is a form definition with its images
+type Bundle struct {
+	Format     string     `json:"format"`
+	Version    int        `json:"version"`
+	ExportedAt time.Time  `json:"exported_at"`
+	Form       Definition `json:"form"`
+	Assets     []Asset    `json:"assets"`
+}
+
+// imgSrc matches the source of images embedded in HTML content
+var imgSrc = regexp.MustCompile(`(<img\b[^>]*?\bsrc\s*=\s*["'])([^"']+)(["'])`)
+
+// Build bundles a form with the images of its background, image fields and
+// HTML content that are kept in the storage. Other images, such as external
+// URLs, are portable as they are. Images missing from the storage are skipped.
+func Build(form *models.Form, store storage.Storage) (*Bundle, error) {
+	b := &Bundle{
+		Format:     Format,
+		Version:    Version,
+		ExportedAt: time.Now().UTC(),
+		Form: Definition{
+			Title:       form.Title,
+			Description: form.Description,
+			Fields:      form.Fields,
+			Settings:    form.Settings,
+		},
+		Assets: []Asset{},
+	}
+
+	for _, ref := range References(&b.Form) {
+		p := StoragePath(ref)
+		if p == "" {
+			continue
+		}
+		content, err := store.GetFileByPath(p)
+		if errors.Is(err, storage.ErrFileNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", p, err)
+		}
+		data, err := io.ReadAll(io.LimitReader(content.Reader, storage.MaxImageSize+1))
+		content.Reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", p, err)
+		}
+		if len(data) > storage.MaxImageSize {
+			continue
+		}
+		b.Assets = append(b.Assets, Asset{
+			Ref:         ref,
+			Filename:    path.Base(p),
+			ContentType: content.ContentType,
+			Data:        data,
+		})
+	}
+	return b, nil
+}
+
+// Validate checks the format, version and assets of a bundle read from a file
+func (b *Bundle) Validate() error {
+	if b.Format != Format {
+		return errors.New("not a form bundle")
+	}
+	if b.Version < 1 || b.Version > Version {
+		return fmt.Errorf("unsupported bundle version %d", b.Version)
+	}
+	if len(b.Assets) > MaxAssets {
+		return fmt.Errorf("bundle has more than %d images", MaxAssets)
+	}
+	for _, a := range b.Assets {
+		if a.Ref == "" || len(a.Data) == 0 {
+			return fmt.Errorf("image %q has no content", a.Filename)
+		}
+	}
+	return nil
+}
+
+// Restore stores the images referenced by the definition with upload and
+// points the references to the new files. References that were storage paths
+// get the new path, upload URLs the new URL.
+func (b *Bundle) Restore(upload func(a *Asset) (*storage.UploadResult, error)) error {
+	used := make(map[string]bool)
+	for _, ref := range References(&b.Form) {
+		used[ref] = true
+	}
+
+	refs := make(map[string]string, len(b.Assets))
+	for i := range b.Assets {
+		a := &b.Assets[i]
+		if _, ok := refs[a.Ref]; ok || !used[a.Ref] {
+			continue
+		}
+		result, err := upload(a)
+		if err != nil {
+			return fmt.Errorf("image %q: %w", a.Filename, err)
+		}
+		if StoragePath(a.Ref) == a.Ref {
+			refs[a.Ref] = result.Path
+		} else {
+			refs[a.Ref] = result.URL
+		}
+	}
+
+	Rewrite(&b.Form, func(ref string) string {
+		if to, ok := refs[ref]; ok {
+			return to
+		}
+		return ref
+	})
+	return nil
+}
+
+// References lists the image references of a definition in order, once each
+func References(def *Definition) []string {
+	var refs []string
+	seen := make(map[string]bool)
+	Rewrite(def, func(ref string) string {
+		if !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+		return ref
+	})
+	return refs
+}
+
+// Rewrite replaces the image references of a definition: the background image,
+// image fields and images embedded in the HTML of the description, field
+// descriptions, paragraphs and rich text
+func Rewrite(def *Definition, fn func(ref string) string) {
+	html := func(s string) string {
+		return imgSrc.ReplaceAllStringFunc(s, func(m string) string {
+			parts := imgSrc.FindStringSubmatch(m)
+			return parts[1] + fn(parts[2]) + parts[3]
+		})
+	}
+
+	if d := def.Settings.Design; d != nil && d.BackgroundImage != "" {
+		design := *d
+		design.BackgroundImage = fn(d.BackgroundImage)
+		def.Settings.Design = &design
+	}
+	def.Description = html(def.Description)
+
+	if def.Fields == nil {
+		return
+	}
+	fields := make(models.FormFields, len(def.Fields))
+	for i, field := range def.Fields {
+		if field.ImageURL != "" {
+			field.ImageURL = fn(field.ImageURL)
+		}
+		field.Description = html(field.Description)
+		field.SectionDescription = html(field.SectionDescription)
+		field.Content = html(field.Content)
+		field.RichTextContent = html(field.RichTextContent)
+		fields[i] = field
+	}
+	def.Fields = fields
+}
+
+// StoragePath returns the storage path of an image reference: the reference
+// itself if it is a path like images/2025/01/x.png, or the path within an
+// upload URL. Returns "" for other references.
+func StoragePath(ref string) string {
+	if strings.HasPrefix(ref, "data:") {
+		return ""
+	}
+	if p := storage.SanitizePath(ref); p == ref {
+		return p
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	for _, dir := range []string{"/images/", "/files/"} {
+		if i := strings.Index(u.Path, dir); i >= 0 {
+			return storage.SanitizePath(u.Path[i+1:])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package bundle
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/storage"
+)
+
+func TestStoragePath(t *testing.T) {
+	tests := []struct{ ref, want string }{
+		{"images/2025/01/abc.png", "images/2025/01/abc.png"},
+		{"http://localhost:8080/uploads/images/2025/01/abc.png", "images/2025/01/abc.png"},
+		{"https://bucket.s3.amazonaws.com/images/2025/01/abc.png?X-Amz-Signature=1", "images/2025/01/abc.png"},
+		{"https://example.com/logo.png", ""},
+		{"data:image/png;base64,iVBORw0KGgo=", ""},
+		{"../etc/passwd", ""},
+	}
+	for _, tt := range tests {
+		if got := StoragePath(tt.ref); got != tt.want {
+			t.Errorf("StoragePath(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	def := &Definition{
+		Description: `<p>Intro <img src="images/a.png" alt="A"></p>`,
+		Fields: models.FormFields{
+			{ID: "img", Type: models.FieldTypeImage, ImageURL: "images/b.png"},
+			{ID: "p", Type: models.FieldTypeParagraph, Content: `<img alt='x' src='images/a.png'>`},
+		},
+		Settings: models.FormSettings{Design: &models.FormDesign{BackgroundImage: "images/c.png"}},
+	}
+	original := def.Fields
+
+	if got, want := References(def), []string{"images/c.png", "images/a.png", "images/b.png"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected references %v, got %v", want, got)
+	}
+
+	Rewrite(def, func(ref string) string { return "new/" + ref })
+	if def.Settings.Design.BackgroundImage != "new/images/c.png" || def.Fields[0].ImageURL != "new/images/b.png" {
+		t.Errorf("references not rewritten: %+v", def)
+	}
+	if !strings.Contains(def.Description, `src="new/images/a.png"`) || !strings.Contains(def.Fields[1].Content, `src='new/images/a.png'`) {
+		t.Errorf("embedded images not rewritten: %q, %q", def.Description, def.Fields[1].Content)
+	}
+	if original[0].ImageURL != "images/b.png" {
+		t.Errorf("expected the original fields to be unchanged")
+	}
+}
+
+func TestBundle_RoundTrip(t *testing.T) {
+	src, _ := storage.NewLocalStorage(t.TempDir(), "http://old.example.com/uploads")
+	png := []byte("\x89PNG\r\n\x1a\nimage data")
+	uploaded, err := src.Upload("background.png", "image/png", int64(len(png)), bytes.NewReader(png))
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+
+	form := &models.Form{
+		Title: "Event",
+		Fields: models.FormFields{
+			{ID: "banner", Type: models.FieldTypeImage, ImageURL: uploaded.URL},
+			{ID: "logo", Type: models.FieldTypeImage, ImageURL: "https://example.com/logo.png"},
+			{ID: "gone", Type: models.FieldTypeImage, ImageURL: "images/2020/01/missing.png"},
+		},
+		Settings: models.FormSettings{Design: &models.FormDesign{BackgroundImage: uploaded.Path}},
+	}
+	b, err := Build(form, src)
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if len(b.Assets) != 2 {
+		t.Fatalf("expected the stored image twice by path and URL, got %+v", b.Assets)
+	}
+
+	for _, format := range []string{"json", "zip"} {
+		var buf bytes.Buffer
+		var read *Bundle
+		if format == "zip" {
+			if err := WriteZip(&buf, b); err != nil {
+				t.Fatalf("zip failed: %v", err)
+			}
+			read, err = ReadZip(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		} else {
+			if err := WriteJSON(&buf, b); err != nil {
+				t.Fatalf("json failed: %v", err)
+			}
+			read, err = ReadJSON(&buf)
+		}
+		if err != nil {
+			t.Fatalf("%s: read failed: %v", format, err)
+		}
+		if !bytes.Equal(read.Assets[0].Data, png) {
+			t.Errorf("%s: image content not preserved", format)
+		}
+
+		dst, _ := storage.NewLocalStorage(t.TempDir(), "http://new.example.com/uploads")
+		if err := read.Restore(func(a *Asset) (*storage.UploadResult, error) {
+			return dst.Upload(a.Filename, a.ContentType, int64(len(a.Data)), bytes.NewReader(a.Data))
+		}); err != nil {
+			t.Fatalf("%s: restore failed: %v", format, err)
+		}
+		def := read.Form
+		if p := def.Settings.Design.BackgroundImage; p == uploaded.Path || StoragePath(p) != p {
+			t.Errorf("%s: expected a new storage path, got %q", format, p)
+		}
+		if u := def.Fields[0].ImageURL; !strings.HasPrefix(u, "http://new.example.com/uploads/images/") {
+			t.Errorf("%s: expected a new upload URL, got %q", format, u)
+		}
+		if def.Fields[1].ImageURL != "https://example.com/logo.png" || def.Fields[2].ImageURL != "images/2020/01/missing.png" {
+			t.Errorf("%s: expected other references to be kept, got %+v", format, def.Fields)
+		}
+	}
+
+	for _, input := range []string{`{}`, `{"format": "formera-form", "version": 99}`, `[1]`} {
+		if _, err := ReadJSON(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package bundle
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"formera/internal/storage"
+)
+
+// manifestName is the form definition within a ZIP bundle
+const manifestName = "form.json"
+
+// WriteJSON writes a bundle as a single JSON document with embedded images
+func WriteJSON(w io.Writer, b *Bundle) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+// WriteZip writes a bundle as a ZIP archive of form.json and the images in an
+// assets folder
+func WriteZip(w io.Writer, b *Bundle) error {
+	zw := zip.NewWriter(w)
+
+	manifest := *b
+	manifest.Assets = make([]Asset, len(b.Assets))
+	for i, a := range b.Assets {
+		a.File = fmt.Sprintf("assets/%d-%s", i+1, storage.SanitizeFilename(a.Filename))
+		fw, err := zw.Create(a.File)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(a.Data); err != nil {
+			return err
+		}
+		a.Data = nil
+		manifest.Assets[i] = a
+	}
+
+	fw, err := zw.Create(manifestName)
+	if err != nil {
+		return err
+	}
+	if err := WriteJSON(fw, &manifest); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadJSON reads and validates a JSON bundle
+func ReadJSON(r io.Reader) (*Bundle, error) {
+	var b Bundle
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, errors.New("not a form bundle")
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ReadZip reads and validates a ZIP bundle, loading its images
+func ReadZip(r io.ReaderAt, size int64) (*Bundle, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, errors.New("not a ZIP file")
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[path.Clean(f.Name)] = f
+	}
+
+	manifest, ok := files[manifestName]
+	if !ok {
+		return nil, errors.New("form.json not found")
+	}
+	rc, err := manifest.Open()
+	if err != nil {
+		return nil, err
+	}
+	var b Bundle
+	err = json.NewDecoder(rc).Decode(&b)
+	rc.Close()
+	if err != nil {
+		return nil, errors.New("not a form bundle")
+	}
+	if len(b.Assets) > MaxAssets {
+		return nil, fmt.Errorf("bundle has more than %d images", MaxAssets)
+	}
+
+	for i := range b.Assets {
+		a := &b.Assets[i]
+		f, ok := files[path.Clean(a.File)]
+		if a.File == "" || !ok {
+			return nil, fmt.Errorf("image %q not found in archive", a.Filename)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		// The declared size of an entry cannot be trusted
+		a.Data, err = io.ReadAll(io.LimitReader(rc, storage.MaxImageSize+1))
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(a.Data) > storage.MaxImageSize {
+			return nil, fmt.Errorf("image %q is too large", a.Filename)
+		}
+	}
+
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
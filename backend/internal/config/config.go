@@ -51,6 +51,49 @@ type Config struct {
 
 	// Cleanup configuration
 	Cleanup CleanupConfig
+
+	// Mail configuration
+	Mail MailConfig
+
+	// Background job configuration
+	Jobs JobsConfig
+
+	// Webhook delivery configuration
+	Webhooks WebhooksConfig
+}
+
+type JobsConfig struct {
+	// Workers is the number of background jobs processed concurrently
+	Workers int
+	// RetentionDays after which succeeded jobs are deleted
+	RetentionDays int
+}
+
+type WebhooksConfig struct {
+	// AllowPrivateNetworks permits receivers on loopback, private and link-local addresses
+	AllowPrivateNetworks bool
+	// RetentionDays after which finished deliveries are deleted
+	RetentionDays int
+}
+
+type MailConfig struct {
+	// SMTP server settings
+	Host     string
+	Port     int
+	Username string // Optional: SMTP auth is skipped if empty
+	Password string
+	// Encryption: "none", "starttls" or "tls" (implicit TLS, usually port 465)
+	Encryption string
+	// Sender address and display name
+	From     string
+	FromName string
+	// MaxRetries for failed deliveries
+	MaxRetries int
+}
+
+// IsConfigured returns true if an SMTP server and sender are configured
+func (m *MailConfig) IsConfigured() bool {
+	return m.Host != "" && m.From != ""
 }
 
 type CleanupConfig struct {
@@ -106,6 +149,11 @@ func Load() *Config {
 	presignMinutes, _ := strconv.Atoi(getEnv("S3_PRESIGN_MINUTES", "60"))
 	cleanupInterval, _ := strconv.Atoi(getEnv("CLEANUP_INTERVAL_HOURS", "24"))
 	cleanupMinAge, _ := strconv.Atoi(getEnv("CLEANUP_MIN_AGE_DAYS", "7"))
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	smtpMaxRetries, _ := strconv.Atoi(getEnv("SMTP_MAX_RETRIES", "5"))
+	jobWorkers, _ := strconv.Atoi(getEnv("JOB_WORKERS", "4"))
+	jobRetention, _ := strconv.Atoi(getEnv("JOB_RETENTION_DAYS", "7"))
+	webhookRetention, _ := strconv.Atoi(getEnv("WEBHOOK_RETENTION_DAYS", "30"))
 
 	port := getEnv("PORT", "8080")
 	baseURL := getEnv("BASE_URL", "http://localhost:3000")
@@ -155,6 +203,27 @@ func Load() *Config {
 			MinAgeDays:    cleanupMinAge,
 			DryRun:        getEnv("CLEANUP_DRY_RUN", "false") == "true",
 		},
+
+		Mail: MailConfig{
+			Host:       getEnv("SMTP_HOST", ""),
+			Port:       smtpPort,
+			Username:   getEnv("SMTP_USERNAME", ""),
+			Password:   getEnv("SMTP_PASSWORD", ""),
+			Encryption: getEnv("SMTP_ENCRYPTION", "starttls"),
+			From:       getEnv("SMTP_FROM", ""),
+			FromName:   getEnv("SMTP_FROM_NAME", "Formera"),
+			MaxRetries: smtpMaxRetries,
+		},
+
+		Jobs: JobsConfig{
+			Workers:       jobWorkers,
+			RetentionDays: jobRetention,
+		},
+
+		Webhooks: WebhooksConfig{
+			AllowPrivateNetworks: getEnv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "false") == "true",
+			RetentionDays:        webhookRetention,
+		},
 	}
 }
 
@@ -0,0 +1,68 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"@every 5m",
+		"x * * * *",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+	// Wednesday
+	start := time.Date(2025, 3, 5, 10, 30, 20, 0, time.UTC)
+
+	tests := []struct {
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{"* * * * *", start, time.Date(2025, 3, 5, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", start, time.Date(2025, 3, 5, 10, 45, 0, 0, time.UTC)},
+		{"0 8 * * 1", start, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)},
+		{"0 8 * * MON", start, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", start, time.Date(2025, 3, 6, 10, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * mon-fri", start, time.Date(2025, 3, 5, 13, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", start, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", start, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", start, time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", start, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either one matches
+		{"0 0 15 * fri", start, time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", start, time.Time{}},
+		// Evaluated in the location of the given time
+		{"0 8 * * 1", start.In(berlin), time.Date(2025, 3, 10, 8, 0, 0, 0, berlin)},
+		// 02:30 does not exist on the day daylight saving time starts
+		{"30 2 * * *", time.Date(2025, 3, 29, 12, 0, 0, 0, berlin), time.Date(2025, 3, 31, 2, 30, 0, 0, berlin)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.spec, err)
+		}
+		if got := s.Next(tt.after); !got.Equal(tt.want) {
+			t.Errorf("%q after %v: expected %v, got %v", tt.spec, tt.after, tt.want, got)
+		}
+	}
+}
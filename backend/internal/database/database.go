@@ -31,7 +31,7 @@ func Initialize(dbPath string) error {
 	}
 
 	// Auto-migrate the schema
-	err = DB.AutoMigrate(&models.User{}, &models.Form{}, &models.Submission{}, &models.Settings{}, &storage.FileRecord{})
+	err = DB.AutoMigrate(&models.User{}, &models.Form{}, &models.Submission{}, &models.Settings{}, &models.AuditLog{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.Job{}, &models.FormAnalytics{}, &models.AttributionView{}, &models.Export{}, &models.ExportPreset{}, &models.ExportSchedule{}, &models.FormVersion{}, &models.FormShare{}, &storage.FileRecord{})
 	if err != nil {
 		return err
 	}
@@ -40,8 +40,15 @@ func Initialize(dbPath string) error {
 	var settings models.Settings
 	if result := DB.First(&settings); result.Error != nil {
 		DB.Create(models.GetDefaultSettings())
+	} else if settings.IPHashSalt == "" {
+		// Backfill salt for installations created before IP hashing existed
+		DB.Model(&settings).Update("ip_hash_salt", models.NewIPHashSalt())
 	}
 
+	// Backfill the submission of webhook deliveries recorded before it was stored,
+	// so erasure requests find them
+	DB.Exec("UPDATE webhook_deliveries SET submission_id = json_extract(payload, '$.submission.id') WHERE (submission_id IS NULL OR submission_id = '') AND json_valid(payload)")
+
 	log.Println("Database initialized successfully")
 	return nil
 }
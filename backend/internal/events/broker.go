@@ -0,0 +1,142 @@
+// Package events provides an in-process publish/subscribe broker for live
+// form events such as new submissions.
+package events
+
+import (
+	"sync"
+)
+
+// Event types
+const (
+	TypeSubmission = "submission"
+	TypeStats      = "stats"
+)
+
+// DefaultBuffer is the number of events a subscriber may fall behind before it is dropped
+const DefaultBuffer = 32
+
+// Event is a message for all subscribers of a form
+type Event struct {
+	Type   string
+	FormID string
+	Data   interface{}
+}
+
+// Subscription receives the events of one form. C is closed when the
+// subscription ends: on Close, on broker shutdown, or when the subscriber
+// could not keep up.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	formID string
+	broker *Broker
+	once   sync.Once
+	lagged bool
+}
+
+// Lagged reports whether the subscription was dropped because its buffer was full
+func (s *Subscription) Lagged() bool {
+	s.broker.mu.RLock()
+	defer s.broker.mu.RUnlock()
+	return s.lagged
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+// Broker fans out events to subscribers. Publishing never blocks: a subscriber
+// whose buffer is full is disconnected instead of slowing down the publisher,
+// and is expected to reconnect and resync.
+type Broker struct {
+	mu     sync.RWMutex
+	subs   map[string]map[*Subscription]struct{}
+	closed bool
+}
+
+// NewBroker creates a new event broker
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[string]map[*Subscription]struct{})}
+}
+
+// Subscribe registers a subscriber for the events of a form. After the broker
+// is closed, the returned subscription is already closed.
+func (b *Broker) Subscribe(formID string, buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	ch := make(chan Event, buffer)
+	sub := &Subscription{C: ch, ch: ch, formID: formID, broker: b}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		sub.once.Do(func() { close(ch) })
+		return sub
+	}
+	if b.subs[formID] == nil {
+		b.subs[formID] = make(map[*Subscription]struct{})
+	}
+	b.subs[formID][sub] = struct{}{}
+	return sub
+}
+
+// HasSubscribers reports whether anyone listens to a form, so publishers can
+// skip building expensive events
+func (b *Broker) HasSubscribers(formID string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs[formID]) > 0
+}
+
+// Publish sends an event to all subscribers of its form without blocking
+func (b *Broker) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs[event.FormID] {
+		select {
+		case sub.ch <- event:
+		default:
+			sub.lagged = true
+			b.remove(sub)
+		}
+	}
+}
+
+// Close ends all subscriptions and rejects new ones. Used on server shutdown
+// so that open streams terminate.
+func (b *Broker) Close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, subs := range b.subs {
+		for sub := range subs {
+			b.remove(sub)
+		}
+	}
+}
+
+// remove unregisters and closes a subscription. Callers must hold the write lock.
+func (b *Broker) remove(sub *Subscription) {
+	if subs, ok := b.subs[sub.formID]; ok {
+		delete(subs, sub)
+		if len(subs) == 0 {
+			delete(b.subs, sub.formID)
+		}
+	}
+	sub.once.Do(func() { close(sub.ch) })
+}
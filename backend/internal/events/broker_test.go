@@ -0,0 +1,71 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestBroker_PublishToFormSubscribers(t *testing.T) {
+	b := NewBroker()
+	sub := b.Subscribe("form-1", 4)
+	other := b.Subscribe("form-2", 4)
+	defer sub.Close()
+	defer other.Close()
+
+	b.Publish(Event{Type: TypeSubmission, FormID: "form-1", Data: "hello"})
+
+	select {
+	case ev := <-sub.C:
+		if ev.Data != "hello" {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	default:
+		t.Fatal("subscriber did not receive event")
+	}
+
+	select {
+	case ev := <-other.C:
+		t.Errorf("subscriber of another form received %+v", ev)
+	default:
+	}
+}
+
+func TestBroker_DropsSlowSubscriber(t *testing.T) {
+	b := NewBroker()
+	slow := b.Subscribe("form-1", 1)
+
+	b.Publish(Event{FormID: "form-1"})
+	b.Publish(Event{FormID: "form-1"}) // Buffer full
+
+	<-slow.C
+	if _, ok := <-slow.C; ok {
+		t.Fatal("slow subscriber should be closed")
+	}
+	if !slow.Lagged() {
+		t.Error("expected subscription to be marked as lagged")
+	}
+	if b.HasSubscribers("form-1") {
+		t.Error("dropped subscriber should be unregistered")
+	}
+
+	slow.Close() // Must not panic on double close
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker()
+	sub := b.Subscribe("form-1", 1)
+
+	b.Close()
+
+	if _, ok := <-sub.C; ok {
+		t.Error("subscription should be closed on broker shutdown")
+	}
+	if sub.Lagged() {
+		t.Error("shutdown is not lagging")
+	}
+	if _, ok := <-b.Subscribe("form-1", 1).C; ok {
+		t.Error("subscribing to a closed broker should return a closed subscription")
+	}
+
+	var nilBroker *Broker
+	nilBroker.Publish(Event{FormID: "form-1"})
+}
@@ -0,0 +1,258 @@
+package export
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"formera/internal/models"
+	"formera/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+// Data file formats of an archive
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// missingFilesName lists uploads that could not be read from storage
+const missingFilesName = "missing-files.txt"
+
+// ArchiveOptions configure a ZIP export
+type ArchiveOptions struct {
+	CSVOptions
+	Format string `json:"format"` // Data file format, csv or json
+}
+
+// ArchiveStats summarizes a written archive
+type ArchiveStats struct {
+	Submissions  int
+	Files        int
+	MissingFiles int
+}
+
+// WriteArchive writes a ZIP archive with the submissions matched by query as
+// a CSV or JSON data file, followed by every referenced upload in one folder
+// per submission. With a column selection only uploads of the selected fields
+// are included. Uploads are stored under their original filename when known;
+// uploads missing from storage are listed in missing-files.txt.
+func WriteArchive(w io.Writer, form *models.Form, query *gorm.DB, files storage.Storage, opts ArchiveOptions) (ArchiveStats, error) {
+	var stats ArchiveStats
+	zw := zip.NewWriter(w)
+
+	switch opts.Format {
+	case FormatJSON:
+		dw, err := zw.Create("submissions.json")
+		if err != nil {
+			return stats, err
+		}
+		if err := WriteJSON(dw, form, query, opts.Options); err != nil {
+			return stats, err
+		}
+	default:
+		dw, err := zw.Create("submissions.csv")
+		if err != nil {
+			return stats, err
+		}
+		if err := WriteCSV(dw, form, query, opts.CSVOptions); err != nil {
+			return stats, err
+		}
+	}
+
+	selector := newUploadSelector(form, opts)
+	var missing []string
+	err := Each(query, func(sub *models.Submission) error {
+		stats.Submissions++
+		paths := selector.paths(sub)
+		if len(paths) == 0 {
+			return nil
+		}
+
+		var records []storage.FileRecord
+		if err := query.Session(&gorm.Session{NewDB: true}).Model(&storage.FileRecord{}).Where("path IN ?", paths).Find(&records).Error; err != nil {
+			return err
+		}
+		originals := make(map[string]string, len(records))
+		for _, r := range records {
+			originals[r.Path] = r.Filename
+		}
+
+		used := make(map[string]int)
+		for _, p := range paths {
+			name := path.Base(p)
+			if original, ok := originals[p]; ok {
+				if sanitized := storage.SanitizeFilename(original); sanitized != "" && sanitized != "." {
+					name = sanitized
+				}
+			}
+			name = uniqueName(used, name)
+
+			found, err := copyFile(zw, files, p, path.Join(sub.ID, name))
+			if err != nil {
+				return err
+			}
+			if !found {
+				missing = append(missing, sub.ID+": "+p)
+				continue
+			}
+			stats.Files++
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	if len(missing) > 0 {
+		stats.MissingFiles = len(missing)
+		mw, err := zw.Create(missingFilesName)
+		if err != nil {
+			return stats, err
+		}
+		if _, err := io.WriteString(mw, strings.Join(missing, "\n")+"\n"); err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, zw.Close()
+}
+
+// uploadSelector picks the uploads of a submission included in an archive.
+// With a column selection, only uploads of the selected fields are included.
+type uploadSelector struct {
+	filtered bool
+	fieldIDs []string
+}
+
+func newUploadSelector(form *models.Form, opts ArchiveOptions) uploadSelector {
+	selector := uploadSelector{filtered: len(opts.Columns) > 0}
+	if selector.filtered {
+		for _, col := range Columns(form, opts.Options) {
+			if col.Kind == ColumnField {
+				selector.fieldIDs = append(selector.fieldIDs, col.Key)
+			}
+		}
+	}
+	return selector
+}
+
+func (s uploadSelector) paths(sub *models.Submission) []string {
+	data := sub.Data
+	if s.filtered {
+		data = make(models.SubmissionData, len(s.fieldIDs))
+		for _, id := range s.fieldIDs {
+			if v, ok := sub.Data[id]; ok {
+				data[id] = v
+			}
+		}
+	}
+	return ReferencedFiles(data)
+}
+
+// EstimateUploadSize sums the sizes of the uploads an archive of the
+// submissions matched by query would include. Uploads without a file record
+// count with the maximum upload size.
+func EstimateUploadSize(form *models.Form, query *gorm.DB, opts ArchiveOptions) (int64, error) {
+	selector := newUploadSelector(form, opts)
+	seen := make(map[string]bool)
+	var paths []string
+	err := Each(query, func(sub *models.Submission) error {
+		for _, p := range selector.paths(sub) {
+			if !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for start := 0; start < len(paths); start += BatchSize {
+		batch := paths[start:min(start+BatchSize, len(paths))]
+		var records []storage.FileRecord
+		if err := query.Session(&gorm.Session{NewDB: true}).Model(&storage.FileRecord{}).
+			Select("path", "size").Where("path IN ?", batch).Find(&records).Error; err != nil {
+			return 0, err
+		}
+		known := make(map[string]int64, len(records))
+		for _, r := range records {
+			known[r.Path] = r.Size
+		}
+		for _, p := range batch {
+			if size, ok := known[p]; ok {
+				total += size
+			} else {
+				total += storage.MaxFileSize
+			}
+		}
+	}
+	return total, nil
+}
+
+// copyFile streams an upload into the archive. Reports false if the upload
+// cannot be read from storage; errors are only returned for the archive itself.
+func copyFile(zw *zip.Writer, files storage.Storage, filePath, name string) (bool, error) {
+	if files == nil {
+		return false, nil
+	}
+	content, err := files.GetFileByPath(filePath)
+	if err != nil {
+		return false, nil
+	}
+	defer content.Reader.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return false, err
+	}
+	_, err = io.Copy(w, content.Reader)
+	return true, err
+}
+
+// uniqueName numbers repeated filenames within a folder: "cv.pdf", "cv (2).pdf"
+func uniqueName(used map[string]int, name string) string {
+	used[name]++
+	if n := used[name]; n > 1 {
+		ext := path.Ext(name)
+		return uniqueName(used, fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext))
+	}
+	return name
+}
+
+// ReferencedFiles returns the storage paths of uploaded files in submission
+// data, ordered by field ID and without duplicates
+func ReferencedFiles(data models.SubmissionData) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	var collect func(val interface{})
+	collect = func(val interface{}) {
+		switch v := val.(type) {
+		case string:
+			if p := storage.SanitizePath(v); p != "" && !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		case []interface{}:
+			for _, item := range v {
+				collect(item)
+			}
+		}
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		collect(data[key])
+	}
+	return paths
+}
@@ -0,0 +1,187 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"formera/internal/jobs"
+	"formera/internal/models"
+	"formera/internal/storage"
+	"formera/internal/testutil"
+
+	"gorm.io/gorm"
+)
+
+func TestReferencedFiles(t *testing.T) {
+	data := models.SubmissionData{
+		"b":    []interface{}{"files/2025/01/x.pdf", "images/2025/01/y.png"},
+		"a":    "files/2025/01/x.pdf",
+		"name": "Ada",
+		"evil": "../secret",
+	}
+	got := ReferencedFiles(data)
+	want := []string{"files/2025/01/x.pdf", "images/2025/01/y.png"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+// archiveFixture creates a form with two submissions, one referencing two
+// uploads with the same original name and a file missing from storage
+func archiveFixture(t *testing.T, db *gorm.DB) (*models.Form, storage.Storage, []*models.Submission) {
+	t.Helper()
+	store, _ := storage.NewLocalStorage(t.TempDir(), "http://localhost/uploads")
+
+	form := &models.Form{
+		Title:  "Applications",
+		Fields: models.FormFields{{ID: "name", Type: models.FieldTypeText, Label: "Name"}, {ID: "cv", Type: models.FieldTypeFile, Label: "CV"}},
+	}
+	db.Create(form)
+
+	var paths []interface{}
+	for _, content := range []string{"first", "second"} {
+		upload, err := store.Upload("cv.pdf", "application/pdf", int64(len(content)), strings.NewReader(content))
+		if err != nil {
+			t.Fatalf("failed to upload fixture: %v", err)
+		}
+		db.Create(&storage.FileRecord{ID: upload.ID, Path: upload.Path, Filename: "cv.pdf"})
+		paths = append(paths, upload.Path)
+	}
+	paths = append(paths, "files/2020/01/gone.pdf")
+
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	subs := []*models.Submission{
+		{FormID: form.ID, Data: models.SubmissionData{"name": "Ada", "cv": paths}, CreatedAt: start},
+		{FormID: form.ID, Data: models.SubmissionData{"name": "Grace"}, CreatedAt: start.Add(time.Hour)},
+	}
+	for _, sub := range subs {
+		db.Create(sub)
+	}
+	return form, store, subs
+}
+
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("invalid ZIP: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestWriteArchive(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	form, store, subs := archiveFixture(t, db)
+	query := db.Model(&models.Submission{}).Where("form_id = ?", form.ID)
+
+	var buf bytes.Buffer
+	stats, err := WriteArchive(&buf, form, query, store, ArchiveOptions{Format: FormatCSV})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if stats != (ArchiveStats{Submissions: 2, Files: 2, MissingFiles: 1}) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	files := readZip(t, buf.Bytes())
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	want := []string{subs[0].ID + "/cv (2).pdf", subs[0].ID + "/cv.pdf", missingFilesName, "submissions.csv"}
+	sort.Strings(want)
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected entries %v, got %v", want, names)
+	}
+	if files[subs[0].ID+"/cv.pdf"] != "first" || files[subs[0].ID+"/cv (2).pdf"] != "second" {
+		t.Error("uploads were not copied in order")
+	}
+	if !strings.HasPrefix(files["submissions.csv"], "ID,Submitted At,Name,CV\n") {
+		t.Errorf("unexpected data file:\n%s", files["submissions.csv"])
+	}
+	if files[missingFilesName] != subs[0].ID+": files/2020/01/gone.pdf\n" {
+		t.Errorf("unexpected missing files %q", files[missingFilesName])
+	}
+
+	buf.Reset()
+	if _, err := WriteArchive(&buf, form, query, store, ArchiveOptions{Format: FormatJSON}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal([]byte(readZip(t, buf.Bytes())["submissions.json"]), &records); err != nil {
+		t.Fatalf("invalid JSON data file: %v", err)
+	}
+	if len(records) != 2 || records[1]["Name"] != "Grace" {
+		t.Errorf("unexpected records %v", records)
+	}
+}
+
+func TestRunner(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	form, store, _ := archiveFixture(t, db)
+
+	queue := jobs.New(db, jobs.Config{})
+	runner := NewRunner(db, store)
+	runner.Register(queue)
+
+	exp, err := runner.Enqueue(form, "user-1", ArchiveOptions{Format: FormatCSV}, Filter{})
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	if exp.Status != models.ExportStatusPending {
+		t.Errorf("expected pending export, got %s", exp.Status)
+	}
+	queue.RunDue()
+
+	db.First(exp, "id = ?", exp.ID)
+	if exp.Status != models.ExportStatusSucceeded || exp.Submissions != 2 || exp.Size == 0 || exp.ExpiresAt == nil {
+		t.Fatalf("unexpected export %+v", exp)
+	}
+	// Exports are kept out of the publicly served upload directories
+	if !strings.HasPrefix(exp.Path, storage.ExportsDir+"/") || storage.SanitizePath(exp.Path) != "" {
+		t.Errorf("expected export in the non-public exports directory, got %q", exp.Path)
+	}
+	content, err := store.GetFileByPath(exp.Path)
+	if err != nil {
+		t.Fatalf("export file not stored: %v", err)
+	}
+	data, _ := io.ReadAll(content.Reader)
+	content.Reader.Close()
+	if _, ok := readZip(t, data)["submissions.csv"]; !ok {
+		t.Error("expected data file in stored export")
+	}
+
+	// The file is deleted once the retention period is over
+	var cleanup models.Job
+	if err := db.Where("type = ?", CleanupJobType).First(&cleanup).Error; err != nil {
+		t.Fatalf("cleanup not scheduled: %v", err)
+	}
+	if cleanup.RunAt.Before(time.Now().Add(Retention - time.Hour)) {
+		t.Errorf("cleanup scheduled too early: %v", cleanup.RunAt)
+	}
+	db.Model(&cleanup).Update("run_at", time.Now())
+	queue.RunDue()
+
+	db.First(exp, "id = ?", exp.ID)
+	if exp.Status != models.ExportStatusExpired {
+		t.Errorf("expected expired export, got %s", exp.Status)
+	}
+	if _, err := store.GetFileByPath(exp.Path); err == nil {
+		t.Error("expected export file to be deleted")
+	}
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"formera/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// utf8BOM lets Excel detect UTF-8 encoded CSV files
+const utf8BOM = "\uFEFF"
+
+// CSVOptions configure a CSV export
+type CSVOptions struct {
+	Options
+	Delimiter rune `json:"delimiter,omitempty"`
+	BOM       bool `json:"bom,omitempty"`
+}
+
+// WriteCSV streams the submissions matched by query as CSV, flushing after every batch
+func WriteCSV(w io.Writer, form *models.Form, query *gorm.DB, opts CSVOptions) error {
+	if opts.BOM {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	if opts.Delimiter != 0 {
+		writer.Comma = opts.Delimiter
+	}
+	sep := opts.MultiValueSeparator
+	if sep == "" {
+		sep = DefaultMultiValueSeparator
+	}
+
+	columns := Columns(form, opts.Options)
+	record := make([]string, len(columns))
+	for i, col := range columns {
+		record[i] = col.Header
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+
+	written := 0
+	err := Each(query, func(sub *models.Submission) error {
+		for i, col := range columns {
+			record[i] = FormatValue(col.Value(sub), sep)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		if written++; written%BatchSize == 0 {
+			return flush(writer, w)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return flush(writer, w)
+}
+
+// flush writes buffered records and pushes them to the client if w is an HTTP response
+func flush(writer *csv.Writer, w io.Writer) error {
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	if f, ok := w.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+// Package export writes the submissions of a form to downloadable files
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"formera/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// BatchSize is the number of submissions loaded and written at a time
+const BatchSize = 500
+
+// DefaultMultiValueSeparator joins the options of multi-value answers
+const DefaultMultiValueSeparator = "; "
+
+// HeaderMode selects how columns are named
+type HeaderMode string
+
+const (
+	HeaderLabels HeaderMode = "labels"
+	HeaderIDs    HeaderMode = "ids"
+)
+
+// Options configure the columns and formatting of an export
+type Options struct {
+	Headers             HeaderMode `json:"headers,omitempty"`
+	MultiValueSeparator string     `json:"separator,omitempty"`
+	IncludeMetadata     bool       `json:"metadata,omitempty"` // IP, user agent, referrer and completion time
+	IncludeUTM          bool       `json:"utm,omitempty"`      // UTM parameters
+	// Columns selects columns by key (id, submitted_at, field IDs, metadata and
+	// UTM keys) in the given order. Empty exports all columns.
+	Columns []string `json:"columns,omitempty"`
+}
+
+// ColumnKind tells where the value of a column comes from
+type ColumnKind int
+
+const (
+	ColumnID ColumnKind = iota
+	ColumnSubmittedAt
+	ColumnField
+	ColumnMetadata
+)
+
+// Column is a single column of an export
+type Column struct {
+	Kind   ColumnKind
+	Key    string // Field ID or metadata key
+	Header string
+	Field  *models.FormField // Only for ColumnField
+}
+
+// metadataColumns lists the metadata keys with their labels
+var metadataColumns = []struct{ key, label string }{
+	{"ip", "IP"},
+	{"user_agent", "User Agent"},
+	{"referrer", "Referrer"},
+	{"completion_seconds", "Completion Seconds"},
+}
+
+var utmColumns = []struct{ key, label string }{
+	{"utm_source", "UTM Source"},
+	{"utm_medium", "UTM Medium"},
+	{"utm_campaign", "UTM Campaign"},
+	{"utm_term", "UTM Term"},
+	{"utm_content", "UTM Content"},
+}
+
+// Columns returns the columns of an export in order: ID, submission time, the
+// form's input fields and the optional metadata and UTM columns, or the selected
+// columns in their given order. Layout fields are skipped, and duplicate labels
+// are numbered to keep headers unique.
+func Columns(form *models.Form, opts Options) []Column {
+	columns := allColumns(form, opts.Headers, opts.IncludeMetadata || len(opts.Columns) > 0, opts.IncludeUTM || len(opts.Columns) > 0)
+	if len(opts.Columns) > 0 {
+		byKey := make(map[string]Column, len(columns))
+		for _, col := range columns {
+			if _, ok := byKey[col.Key]; !ok {
+				byKey[col.Key] = col
+			}
+		}
+		selected := make([]Column, 0, len(opts.Columns))
+		for _, key := range opts.Columns {
+			if col, ok := byKey[key]; ok {
+				selected = append(selected, col)
+				delete(byKey, key) // Each column once
+			}
+		}
+		columns = selected
+	}
+
+	seen := make(map[string]int, len(columns))
+	for i := range columns {
+		h := columns[i].Header
+		seen[h]++
+		if n := seen[h]; n > 1 {
+			columns[i].Header = fmt.Sprintf("%s (%d)", h, n)
+		}
+	}
+	return columns
+}
+
+// UnknownColumns returns the selected column keys that are not columns of the form
+func UnknownColumns(form *models.Form, keys []string) []string {
+	known := make(map[string]bool)
+	for _, col := range allColumns(form, HeaderIDs, true, true) {
+		known[col.Key] = true
+	}
+	var unknown []string
+	for _, key := range keys {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	return unknown
+}
+
+func allColumns(form *models.Form, headers HeaderMode, metadata, utm bool) []Column {
+	ids := headers == HeaderIDs
+	header := func(id, label string) string {
+		if ids {
+			return id
+		}
+		return label
+	}
+
+	columns := []Column{
+		{Kind: ColumnID, Key: "id", Header: header("id", "ID")},
+		{Kind: ColumnSubmittedAt, Key: "submitted_at", Header: header("submitted_at", "Submitted At")},
+	}
+	for i := range form.Fields {
+		field := &form.Fields[i]
+		if field.Type.IsLayout() {
+			continue
+		}
+		label := field.Label
+		if label == "" {
+			label = field.ID
+		}
+		columns = append(columns, Column{Kind: ColumnField, Key: field.ID, Header: header(field.ID, label), Field: field})
+	}
+	if metadata {
+		for _, m := range metadataColumns {
+			columns = append(columns, Column{Kind: ColumnMetadata, Key: m.key, Header: header(m.key, m.label)})
+		}
+	}
+	if utm {
+		for _, m := range utmColumns {
+			columns = append(columns, Column{Kind: ColumnMetadata, Key: m.key, Header: header(m.key, m.label)})
+		}
+	}
+	return columns
+}
+
+// Value returns the raw value of a column for a submission
+func (c Column) Value(sub *models.Submission) interface{} {
+	switch c.Kind {
+	case ColumnID:
+		return sub.ID
+	case ColumnSubmittedAt:
+		return sub.CreatedAt
+	case ColumnField:
+		return sub.Data[c.Key]
+	}
+
+	m := sub.Metadata
+	switch c.Key {
+	case "ip":
+		return m.IP
+	case "user_agent":
+		return m.UserAgent
+	case "referrer":
+		return m.Referrer
+	case "completion_seconds":
+		if sub.CompletionSeconds == nil {
+			return nil
+		}
+		return *sub.CompletionSeconds
+	case "utm_source":
+		return m.UTMSource
+	case "utm_medium":
+		return m.UTMMedium
+	case "utm_campaign":
+		return m.UTMCampaign
+	case "utm_term":
+		return m.UTMTerm
+	case "utm_content":
+		return m.UTMContent
+	}
+	return nil
+}
+
+// FormatValue renders a value as text. Multi-value answers are joined with sep,
+// objects are written as JSON and times as RFC 3339.
+func FormatValue(v interface{}, sep string) string {
+	switch typed := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(typed)
+	case bool:
+		return strconv.FormatBool(typed)
+	case time.Time:
+		return typed.Format(time.RFC3339)
+	case []interface{}:
+		items := make([]string, len(typed))
+		for i, item := range typed {
+			items[i] = FormatValue(item, sep)
+		}
+		return strings.Join(items, sep)
+	default:
+		b, err := json.Marshal(typed)
+		if err != nil {
+			return fmt.Sprint(typed)
+		}
+		return string(b)
+	}
+}
+
+// Each calls fn for every submission matched by query, oldest first, loading
+// BatchSize submissions at a time. Batches are paged by (created_at, id), so
+// submissions arriving during an export do not shift later batches.
+func Each(query *gorm.DB, fn func(*models.Submission) error) error {
+	var after *models.Submission
+	for {
+		q := query.Session(&gorm.Session{}).Order("created_at ASC, id ASC").Limit(BatchSize)
+		if after != nil {
+			q = q.Where("created_at > ? OR (created_at = ? AND id > ?)", after.CreatedAt, after.CreatedAt, after.ID)
+		}
+
+		var batch []models.Submission
+		if err := q.Find(&batch).Error; err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := fn(&batch[i]); err != nil {
+				return err
+			}
+		}
+		if len(batch) < BatchSize {
+			return nil
+		}
+		after = &batch[len(batch)-1]
+	}
+}
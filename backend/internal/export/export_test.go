@@ -0,0 +1,191 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+)
+
+func testForm() *models.Form {
+	return &models.Form{
+		Title: "Survey",
+		Fields: models.FormFields{
+			{ID: "h1", Type: models.FieldTypeHeading, Label: "About you"},
+			{ID: "name", Type: models.FieldTypeText, Label: "Name"},
+			{ID: "divider", Type: models.FieldTypeDivider},
+			{ID: "tools", Type: models.FieldTypeCheckbox, Label: "Tools"},
+			{ID: "other", Type: models.FieldTypeText, Label: "Name"},
+		},
+	}
+}
+
+func headers(columns []Column) []string {
+	result := make([]string, len(columns))
+	for i, col := range columns {
+		result[i] = col.Header
+	}
+	return result
+}
+
+func TestColumns(t *testing.T) {
+	form := testForm()
+
+	got := headers(Columns(form, Options{}))
+	want := []string{"ID", "Submitted At", "Name", "Tools", "Name (2)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	got = headers(Columns(form, Options{Headers: HeaderIDs, IncludeMetadata: true, IncludeUTM: true}))
+	want = []string{
+		"id", "submitted_at", "name", "tools", "other",
+		"ip", "user_agent", "referrer", "completion_seconds",
+		"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestColumns_Selection(t *testing.T) {
+	form := testForm()
+
+	got := headers(Columns(form, Options{Columns: []string{"other", "name", "utm_source"}}))
+	want := []string{"Name", "Name (2)", "UTM Source"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	unknown := UnknownColumns(form, []string{"name", "h1", "missing", "ip"})
+	if !reflect.DeepEqual(unknown, []string{"h1", "missing"}) {
+		t.Errorf("expected layout and missing fields to be unknown, got %v", unknown)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{nil, ""},
+		{"text", "text"},
+		{float64(3), "3"},
+		{2.5, "2.5"},
+		{true, "true"},
+		{[]interface{}{"a", "b", float64(1)}, "a | b | 1"},
+		{map[string]interface{}{"url": "/f.pdf"}, `{"url":"/f.pdf"}`},
+		{time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), "2025-03-01T12:00:00Z"},
+	}
+	for _, tt := range tests {
+		if got := FormatValue(tt.value, " | "); got != tt.want {
+			t.Errorf("FormatValue(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	form := testForm()
+	db.Create(form)
+
+	seconds := 42
+	db.Create(&models.Submission{
+		FormID:            form.ID,
+		Data:              models.SubmissionData{"name": "Ada", "tools": []interface{}{"Mail", "Chat"}, "h1": "ignored"},
+		Metadata:          models.SubmissionMetadata{IP: "127.0.0.1", UTMSource: "newsletter"},
+		CompletionSeconds: &seconds,
+		CreatedAt:         time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	})
+
+	var buf bytes.Buffer
+	opts := CSVOptions{
+		Options:   Options{Headers: HeaderIDs, MultiValueSeparator: "|", IncludeMetadata: true, IncludeUTM: true},
+		Delimiter: ';',
+		BOM:       true,
+	}
+	query := db.Model(&models.Submission{}).Where("form_id = ?", form.ID)
+	if err := WriteCSV(&buf, form, query, opts); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, utf8BOM) {
+		t.Error("expected UTF-8 BOM")
+	}
+	reader := csv.NewReader(strings.NewReader(strings.TrimPrefix(out, utf8BOM)))
+	reader.Comma = ';'
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header and one row, got %v", records)
+	}
+
+	row := make(map[string]string)
+	for i, h := range records[0] {
+		row[h] = records[1][i]
+	}
+	want := map[string]string{
+		"submitted_at":       "2025-03-01T12:00:00Z",
+		"name":               "Ada",
+		"tools":              "Mail|Chat",
+		"ip":                 "127.0.0.1",
+		"completion_seconds": "42",
+		"utm_source":         "newsletter",
+		"utm_medium":         "",
+	}
+	for k, v := range want {
+		if row[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, row[k])
+		}
+	}
+	if _, ok := row["h1"]; ok {
+		t.Error("layout fields must not be exported")
+	}
+}
+
+func TestEach_Batches(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	form := testForm()
+	db.Create(form)
+
+	// More than two batches, with identical timestamps across a batch boundary
+	total := 2*BatchSize + 7
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	submissions := make([]models.Submission, total)
+	for i := range submissions {
+		submissions[i] = models.Submission{
+			FormID:    form.ID,
+			Data:      models.SubmissionData{},
+			CreatedAt: start.Add(time.Duration(i/3) * time.Second),
+		}
+	}
+	db.CreateInBatches(submissions, 200)
+
+	seen := make(map[string]bool, total)
+	var last time.Time
+	err := Each(db.Model(&models.Submission{}).Where("form_id = ?", form.ID), func(sub *models.Submission) error {
+		if seen[sub.ID] {
+			t.Fatalf("submission %s visited twice", sub.ID)
+		}
+		if sub.CreatedAt.Before(last) {
+			t.Fatalf("submissions out of order")
+		}
+		seen[sub.ID] = true
+		last = sub.CreatedAt
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if len(seen) != total {
+		t.Errorf("expected %d submissions, got %d", total, len(seen))
+	}
+}
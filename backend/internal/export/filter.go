@@ -0,0 +1,57 @@
+package export
+
+import (
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Filter selects submissions by submission time and answers. Background exports
+// store it, so it only holds serializable values.
+type Filter struct {
+	From *time.Time `json:"from,omitempty"` // Inclusive
+	To   *time.Time `json:"to,omitempty"`   // Exclusive
+	// Answers maps field IDs to the value the answer must equal or, for
+	// multi-value answers such as checkboxes, contain
+	Answers map[string]string `json:"answers,omitempty"`
+}
+
+// SQL returns the condition and arguments selecting the matching rows of the submissions table
+func (f Filter) SQL() (string, []interface{}) {
+	cond := "1 = 1"
+	var args []interface{}
+	if f.From != nil {
+		cond += " AND CAST(strftime('%s', submissions.created_at) AS INTEGER) >= ?"
+		args = append(args, f.From.Unix())
+	}
+	if f.To != nil {
+		cond += " AND CAST(strftime('%s', submissions.created_at) AS INTEGER) < ?"
+		args = append(args, f.To.Unix())
+	}
+
+	fieldIDs := make([]string, 0, len(f.Answers))
+	for fieldID := range f.Answers {
+		fieldIDs = append(fieldIDs, fieldID)
+	}
+	sort.Strings(fieldIDs)
+
+	// json_each yields the value itself for scalars and the items for arrays
+	for _, fieldID := range fieldIDs {
+		cond += " AND EXISTS (SELECT 1 FROM json_each(submissions.data, ?) AS answer WHERE CAST(answer.value AS TEXT) = ?)"
+		args = append(args, AnswerPath(fieldID), f.Answers[fieldID])
+	}
+	return cond, args
+}
+
+// Apply adds the filter to a query on the submissions table
+func (f Filter) Apply(query *gorm.DB) *gorm.DB {
+	cond, args := f.SQL()
+	return query.Where(cond, args...)
+}
+
+// AnswerPath returns the JSON path of an answer in the submission data.
+// Field IDs must not contain double quotes.
+func AnswerPath(fieldID string) string {
+	return `$."` + fieldID + `"`
+}
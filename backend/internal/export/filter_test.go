@@ -0,0 +1,42 @@
+package export
+
+import (
+	"testing"
+	"time"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+)
+
+func TestFilter_Apply(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	form := testForm()
+	db.Create(form)
+
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 12, 0, 0, 0, time.UTC) }
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"name": "Ada", "tools": []interface{}{"Mail", "Chat"}}, CreatedAt: day(1)})
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"name": "Bob", "tools": []interface{}{"Chat"}}, CreatedAt: day(2)})
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"name": "Eve"}, CreatedAt: day(3)})
+
+	from, to := day(2), day(3)
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int64
+	}{
+		{"none", Filter{}, 3},
+		{"from", Filter{From: &from}, 2},
+		{"to is exclusive", Filter{To: &to}, 2},
+		{"range", Filter{From: &from, To: &to}, 1},
+		{"scalar answer", Filter{Answers: map[string]string{"name": "Eve"}}, 1},
+		{"array contains", Filter{Answers: map[string]string{"tools": "Chat"}}, 2},
+		{"answers and range", Filter{From: &from, Answers: map[string]string{"tools": "Mail"}}, 0},
+	}
+	for _, tt := range tests {
+		var count int64
+		tt.filter.Apply(db.Model(&models.Submission{}).Where("form_id = ?", form.ID)).Count(&count)
+		if count != tt.want {
+			t.Errorf("%s: expected %d submissions, got %d", tt.name, tt.want, count)
+		}
+	}
+}
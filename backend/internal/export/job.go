@@ -0,0 +1,220 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"formera/internal/jobs"
+	"formera/internal/logger"
+	"formera/internal/mailer"
+	"formera/internal/models"
+	"formera/internal/storage"
+	"formera/internal/versions"
+
+	"gorm.io/gorm"
+)
+
+// Job types of background exports
+const (
+	JobType         = "export.archive"
+	CleanupJobType  = "export.cleanup"  // Deletes the file of an expired export
+	ScheduleJobType = "export.schedule" // Runs an export schedule
+)
+
+// Retention is how long a finished background export can be downloaded
+const Retention = 7 * 24 * time.Hour
+
+// Runner generates exports in the background and keeps the files in storage
+type Runner struct {
+	db      *gorm.DB
+	storage storage.Storage
+	queue   *jobs.Queue
+	mailer  *mailer.Mailer
+}
+
+// NewRunner creates a new background export runner
+func NewRunner(db *gorm.DB, store storage.Storage) *Runner {
+	return &Runner{db: db, storage: store}
+}
+
+// archiveRequest is stored as the options of a background ZIP export
+type archiveRequest struct {
+	Options ArchiveOptions `json:"options"`
+	Filter  Filter         `json:"filter"`
+}
+
+type archivePayload struct {
+	ExportID string `json:"export_id"`
+}
+
+type cleanupPayload struct {
+	ExportID string `json:"export_id"`
+	FileID   string `json:"file_id"`
+}
+
+// Register adds the export job handlers to the queue. Must be called before the queue is started.
+func (r *Runner) Register(q *jobs.Queue) {
+	r.queue = q
+	q.Register(JobType, r.runArchive)
+	q.Register(CleanupJobType, r.runCleanup)
+	q.Register(ScheduleJobType, r.runSchedule)
+}
+
+// Enqueue stores a pending ZIP export of the form's submissions matched by filter and queues it
+func (r *Runner) Enqueue(form *models.Form, userID string, opts ArchiveOptions, filter Filter) (*models.Export, error) {
+	if r.queue == nil {
+		return nil, errors.New("background exports are not available")
+	}
+	encoded, err := json.Marshal(archiveRequest{Options: opts, Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+	exp := &models.Export{
+		FormID:  form.ID,
+		UserID:  userID,
+		Format:  "zip",
+		Options: string(encoded),
+		Status:  models.ExportStatusPending,
+	}
+	if err := r.db.Create(exp).Error; err != nil {
+		return nil, err
+	}
+	if _, err := r.queue.Enqueue(JobType, archivePayload{ExportID: exp.ID}); err != nil {
+		r.db.Delete(exp)
+		return nil, err
+	}
+	return exp, nil
+}
+
+func (r *Runner) runArchive(ctx context.Context, job *models.Job) error {
+	var payload archivePayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+
+	var exp models.Export
+	if err := r.db.First(&exp, "id = ?", payload.ExportID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // Deleted together with its form
+		}
+		return err
+	}
+	var form models.Form
+	if err := r.db.First(&form, "id = ?", exp.FormID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := versions.WithHistory(r.db, &form); err != nil {
+		return err
+	}
+
+	r.db.Model(&exp).Update("status", models.ExportStatusRunning)
+	if err := r.writeArchive(ctx, &exp, &form); err != nil {
+		status := models.ExportStatusPending // Retried by the queue
+		if job.Attempts >= job.MaxAttempts {
+			status = models.ExportStatusFailed
+		}
+		r.db.Model(&exp).Updates(map[string]interface{}{"status": status, "error": err.Error()})
+		return err
+	}
+	return nil
+}
+
+// writeArchive renders the archive to a temporary file, uploads it and
+// schedules its deletion once the retention period is over
+func (r *Runner) writeArchive(ctx context.Context, exp *models.Export, form *models.Form) error {
+	var req archiveRequest
+	if err := json.Unmarshal([]byte(exp.Options), &req); err != nil {
+		return fmt.Errorf("invalid export options: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "formera-export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	query := req.Filter.Apply(r.db.WithContext(ctx).Model(&models.Submission{}).Where("form_id = ?", form.ID))
+	stats, err := WriteArchive(tmp, form, query, r.storage, req.Options)
+	if err != nil {
+		return err
+	}
+	if err := r.store(exp, tmp, fmt.Sprintf("%s-submissions.zip", form.ID), "application/zip", stats.Submissions); err != nil {
+		return err
+	}
+
+	logger.Info().
+		Str("export_id", exp.ID).
+		Int("submissions", stats.Submissions).
+		Int("files", stats.Files).
+		Int64("size", exp.Size).
+		Msg("Export finished")
+	return nil
+}
+
+// store uploads a finished export file, marks the export succeeded and
+// schedules the deletion of the file once the retention period is over
+func (r *Runner) store(exp *models.Export, file *os.File, filename, contentType string, submissions int) error {
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	result, err := r.storage.UploadExport(filename, contentType, size, file)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	expires := now.Add(Retention)
+	if err := r.db.Model(exp).Updates(map[string]interface{}{
+		"status":      models.ExportStatusSucceeded,
+		"error":       "",
+		"submissions": submissions,
+		"size":        size,
+		"filename":    filename,
+		"file_id":     result.ID,
+		"path":        result.Path,
+		"finished_at": now,
+		"expires_at":  expires,
+	}).Error; err != nil {
+		r.storage.Delete(result.ID)
+		return err
+	}
+	exp.Status = models.ExportStatusSucceeded
+	exp.Submissions = submissions
+	exp.Size = size
+	exp.Filename = filename
+	exp.FileID = result.ID
+	exp.Path = result.Path
+	exp.FinishedAt = &now
+	exp.ExpiresAt = &expires
+
+	if _, err := r.queue.Enqueue(CleanupJobType, cleanupPayload{ExportID: exp.ID, FileID: result.ID}, jobs.RunAt(expires)); err != nil {
+		logger.Error().Err(err).Str("export_id", exp.ID).Msg("Failed to schedule export cleanup")
+	}
+	return nil
+}
+
+func (r *Runner) runCleanup(ctx context.Context, job *models.Job) error {
+	var payload cleanupPayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+	if err := r.storage.Delete(payload.FileID); err != nil && !errors.Is(err, storage.ErrFileNotFound) {
+		return err
+	}
+	return r.db.Model(&models.Export{}).Where("id = ?", payload.ExportID).
+		Update("status", models.ExportStatusExpired).Error
+}
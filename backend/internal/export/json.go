@@ -0,0 +1,57 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"formera/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// WriteJSON streams the submissions matched by query as a JSON array. Each
+// record holds the id, the submission time and the answers keyed by field label
+// or ID, followed by the optional metadata. Answers keep their JSON types.
+func WriteJSON(w io.Writer, form *models.Form, query *gorm.DB, opts Options) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	columns := Columns(form, opts)
+	written := 0
+	err := Each(query, func(sub *models.Submission) error {
+		record := make(map[string]interface{}, len(columns))
+		for _, col := range columns {
+			key := col.Key
+			if col.Kind == ColumnField {
+				key = col.Header
+			}
+			record[key] = col.Value(sub)
+		}
+		b, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		if written > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		written++
+		if written%BatchSize == 0 {
+			if f, ok := w.(interface{ Flush() }); ok {
+				f.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]")
+	return err
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+)
+
+func TestWriteJSON(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	form := testForm()
+	db.Create(form)
+	db.Create(&models.Submission{
+		FormID:    form.ID,
+		Data:      models.SubmissionData{"name": "Ada", "tools": []interface{}{"Mail"}, "other": "Lovelace"},
+		Metadata:  models.SubmissionMetadata{UTMSource: "ads"},
+		CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	})
+	query := db.Model(&models.Submission{}).Where("form_id = ?", form.ID)
+
+	decode := func(opts Options) []map[string]interface{} {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, form, query, opts); err != nil {
+			t.Fatalf("export failed: %v", err)
+		}
+		var records []map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("expected one record, got %d", len(records))
+		}
+		return records
+	}
+
+	record := decode(Options{})[0]
+	if record["Name"] != "Ada" || record["Name (2)"] != "Lovelace" || !reflect.DeepEqual(record["Tools"], []interface{}{"Mail"}) {
+		t.Errorf("unexpected record %v", record)
+	}
+	if _, ok := record["utm_source"]; ok {
+		t.Error("UTM parameters must be opt-in")
+	}
+
+	record = decode(Options{Headers: HeaderIDs, Columns: []string{"tools", "utm_source"}})[0]
+	want := map[string]interface{}{"tools": []interface{}{"Mail"}, "utm_source": "ads"}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("expected %v, got %v", want, record)
+	}
+}
@@ -0,0 +1,289 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"  // Register decoder for uploaded images
+	_ "image/jpeg" // Register decoder for uploaded images
+	"image/png"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"formera/internal/models"
+	"formera/internal/storage"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// maxPDFImageSize limits the size of a single image embedded in a PDF
+const maxPDFImageSize = 10 << 20
+
+// maxPDFImagePixels limits the dimensions of embedded images, which are
+// decoded into memory at 4 bytes per pixel (a 4000×4000 pixel image uses 64 MB)
+const maxPDFImagePixels = 16_000_000
+
+// pdfFont is the font family of submission PDFs. The Go fonts cover Latin,
+// Greek and Cyrillic scripts; characters of other scripts such as Chinese or
+// Arabic are not included and are rendered as missing glyphs.
+const pdfFont = "Go"
+
+// Page layout of submission PDFs in millimetres
+const (
+	pdfMargin         = 20.0
+	pdfMaxImageWidth  = 80.0
+	pdfMaxImageHeight = 50.0
+)
+
+// PDFOptions configure a PDF export
+type PDFOptions struct {
+	// Fields limits the answers to the given field IDs and leaves out layout
+	// fields. Empty renders the whole form.
+	Fields []string
+}
+
+// WritePDF renders a submission as a printable PDF: the form title followed by
+// headings, sections and the labelled answers in field order. Signatures and
+// uploaded images are embedded; other uploads are listed by filename. files may
+// be nil, in which case uploads are only listed.
+func WritePDF(w io.Writer, form *models.Form, sub *models.Submission, files storage.Storage, opts PDFOptions) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.AliasNbPages("")
+	pdf.SetTitle(form.Title, true)
+	// fpdf keeps parsed UTF-8 fonts per document and subsets them on output, so
+	// they cannot be shared between the PDFs of a bulk export
+	pdf.AddUTF8FontFromBytes(pdfFont, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", gobold.TTF)
+
+	r := &pdfRenderer{pdf: pdf, files: files}
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 5)
+		pdf.SetFont(pdfFont, "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%s · %d/{nb}", sub.ID, pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pdf.SetFont(pdfFont, "B", 18)
+	r.text(8, form.Title)
+	pdf.SetFont(pdfFont, "", 9)
+	pdf.SetTextColor(100, 100, 100)
+	r.text(5, "Submitted "+sub.CreatedAt.UTC().Format("2006-01-02 15:04 UTC"))
+	pdf.SetTextColor(0, 0, 0)
+	pdf.Ln(4)
+
+	selected := make(map[string]bool, len(opts.Fields))
+	for _, id := range opts.Fields {
+		selected[id] = true
+	}
+	for _, field := range orderedFields(form.Fields) {
+		if len(selected) > 0 && !selected[field.ID] {
+			continue
+		}
+		r.field(field, sub.Data[field.ID])
+	}
+
+	return pdf.Output(w)
+}
+
+// orderedFields returns the fields sorted by their Order, keeping the stored order for ties
+func orderedFields(fields models.FormFields) models.FormFields {
+	sorted := make(models.FormFields, len(fields))
+	copy(sorted, fields)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Order < sorted[j].Order })
+	return sorted
+}
+
+type pdfRenderer struct {
+	pdf    *fpdf.Fpdf
+	files  storage.Storage
+	images int
+}
+
+// text writes a left-aligned paragraph in the current font
+func (r *pdfRenderer) text(lineHeight float64, s string) {
+	r.pdf.MultiCell(0, lineHeight, s, "", "L", false)
+}
+
+func (r *pdfRenderer) field(field models.FormField, value interface{}) {
+	pdf := r.pdf
+	switch field.Type {
+	case models.FieldTypeSection:
+		pdf.Ln(3)
+		pdf.SetFont(pdfFont, "B", 14)
+		r.text(7, field.Label)
+		if field.SectionDescription != "" {
+			pdf.SetFont(pdfFont, "", 10)
+			r.text(5, field.SectionDescription)
+		}
+		x, y := pdf.GetXY()
+		pdf.Line(x, y+1, x+r.width(), y+1)
+		pdf.Ln(4)
+	case models.FieldTypeHeading:
+		sizes := map[int]float64{1: 16, 2: 14, 3: 12, 4: 11}
+		size, ok := sizes[field.HeadingLevel]
+		if !ok {
+			size = sizes[2]
+		}
+		pdf.Ln(2)
+		pdf.SetFont(pdfFont, "B", size)
+		r.text(size/2, field.Label)
+		pdf.Ln(1)
+	case models.FieldTypeParagraph:
+		pdf.SetFont(pdfFont, "", 10)
+		r.text(5, field.Content)
+		pdf.Ln(2)
+	case models.FieldTypeDivider:
+		x, y := pdf.GetXY()
+		pdf.Line(x, y+2, x+r.width(), y+2)
+		pdf.Ln(5)
+	case models.FieldTypePagebreak, models.FieldTypeImage:
+		// Presentation only
+	default:
+		pdf.SetFont(pdfFont, "B", 10)
+		label := field.Label
+		if label == "" {
+			label = field.ID
+		}
+		r.text(5, label)
+		pdf.SetFont(pdfFont, "", 10)
+		r.answer(field, value)
+		pdf.Ln(3)
+	}
+}
+
+func (r *pdfRenderer) answer(field models.FormField, value interface{}) {
+	switch field.Type {
+	case models.FieldTypeSignature:
+		if s, ok := value.(string); ok && s != "" {
+			if data, ok := decodeDataURL(s); ok {
+				if !r.image(data) {
+					r.text(5, "[signature]") // Not worth printing the encoded image
+				}
+				return
+			}
+			if r.upload(s) {
+				return
+			}
+		}
+	case models.FieldTypeFile:
+		var paths []string
+		switch v := value.(type) {
+		case string:
+			paths = []string{v}
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					paths = append(paths, s)
+				}
+			}
+		}
+		for _, p := range paths {
+			if !r.upload(p) {
+				r.text(5, path.Base(p))
+			}
+		}
+		if len(paths) > 0 {
+			return
+		}
+	}
+
+	text := FormatValue(value, ", ")
+	if text == "" {
+		text = "-"
+	}
+	r.text(5, text)
+}
+
+// upload embeds the uploaded image at a storage path. Reports whether an image was embedded.
+func (r *pdfRenderer) upload(p string) bool {
+	p = storage.SanitizePath(strings.TrimPrefix(p, "/uploads/"))
+	if p == "" || r.files == nil {
+		return false
+	}
+	content, err := r.files.GetFileByPath(p)
+	if err != nil {
+		return false
+	}
+	defer content.Reader.Close()
+	if content.ContentType != "" && !strings.HasPrefix(content.ContentType, "image/") {
+		return false
+	}
+	data, err := io.ReadAll(io.LimitReader(content.Reader, maxPDFImageSize+1))
+	if err != nil || len(data) > maxPDFImageSize {
+		return false
+	}
+	return r.image(data)
+}
+
+// image embeds a PNG, JPEG or GIF image scaled to fit the maximum image size.
+// Images are re-encoded as 8-bit PNG, which covers every variant the PDF writer can read.
+// Images with more than maxPDFImagePixels are skipped without decoding them.
+func (r *pdfRenderer) image(data []byte) bool {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPDFImagePixels {
+		return false
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return false
+	}
+	rgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, rgba); err != nil {
+		return false
+	}
+
+	r.images++
+	name := fmt.Sprintf("image%d", r.images)
+	opts := fpdf.ImageOptions{ImageType: "PNG"}
+	info := r.pdf.RegisterImageOptionsReader(name, opts, &buf)
+	if r.pdf.Err() {
+		r.pdf.ClearError()
+		return false
+	}
+
+	width, height := info.Width(), info.Height()
+	scale := 1.0
+	if width > pdfMaxImageWidth {
+		scale = pdfMaxImageWidth / width
+	}
+	if height*scale > pdfMaxImageHeight {
+		scale = pdfMaxImageHeight / height
+	}
+	r.pdf.ImageOptions(name, r.pdf.GetX(), r.pdf.GetY(), width*scale, height*scale, true, opts, 0, "")
+	return true
+}
+
+// width returns the printable page width
+func (r *pdfRenderer) width() float64 {
+	pageWidth, _ := r.pdf.GetPageSize()
+	left, _, right, _ := r.pdf.GetMargins()
+	return pageWidth - left - right
+}
+
+// decodeDataURL returns the content of a base64 data URL such as a drawn signature
+func decodeDataURL(s string) ([]byte, bool) {
+	if !strings.HasPrefix(s, "data:") {
+		return nil, false
+	}
+	header, payload, ok := strings.Cut(s, ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return nil, false
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	return data, err == nil
+}
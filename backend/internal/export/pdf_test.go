@@ -0,0 +1,131 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"formera/internal/models"
+	"formera/internal/storage"
+
+	"github.com/go-pdf/fpdf"
+)
+
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	img.Set(5, 5, color.NRGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestOrderedFields(t *testing.T) {
+	fields := models.FormFields{
+		{ID: "c", Order: 2},
+		{ID: "a", Order: 0},
+		{ID: "b", Order: 2},
+	}
+	got := orderedFields(fields)
+	if got[0].ID != "a" || got[1].ID != "c" || got[2].ID != "b" {
+		t.Errorf("unexpected order %v", got)
+	}
+	if fields[0].ID != "c" {
+		t.Error("input fields must not be reordered")
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	store, _ := storage.NewLocalStorage(t.TempDir(), "http://localhost/uploads")
+	pngData := testPNG(t)
+	photo, err := store.Upload("photo.png", "image/png", int64(len(pngData)), bytes.NewReader(pngData))
+	if err != nil {
+		t.Fatalf("failed to upload fixture: %v", err)
+	}
+	doc, err := store.Upload("cv.pdf", "application/pdf", 4, strings.NewReader("%PDF"))
+	if err != nil {
+		t.Fatalf("failed to upload fixture: %v", err)
+	}
+
+	form := &models.Form{
+		Title: "Incident report",
+		Fields: models.FormFields{
+			{ID: "sign", Type: models.FieldTypeSignature, Label: "Signature", Order: 4},
+			{ID: "section", Type: models.FieldTypeSection, Label: "Details", SectionDescription: "What happened", Order: 0},
+			{ID: "what", Type: models.FieldTypeTextarea, Label: "Description", Order: 1},
+			{ID: "files", Type: models.FieldTypeFile, Label: "Attachments", Order: 2},
+			{ID: "tags", Type: models.FieldTypeCheckbox, Label: "Tags", Order: 3},
+		},
+	}
+	sub := &models.Submission{
+		ID: "sub-1",
+		Data: models.SubmissionData{
+			"what":  "Spilled coffee – äöü, пролитый кофе",
+			"files": []interface{}{photo.Path, doc.Path},
+			"tags":  []interface{}{"kitchen", "minor"},
+			"sign":  "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData),
+		},
+		CreatedAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, form, sub, store, PDFOptions{}); err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-") {
+		t.Fatal("expected a PDF document")
+	}
+	if !strings.Contains(out, "/FontFile2") {
+		t.Error("expected an embedded TrueType font for non-Latin text")
+	}
+	// The uploaded photo and the signature, but not the PDF attachment
+	if n := strings.Count(out, "/Subtype /Image"); n != 2 {
+		t.Errorf("expected 2 embedded images, got %d", n)
+	}
+
+	// Without storage, uploads are listed and broken signatures fall back to text
+	sub.Data["sign"] = "data:image/png;base64,broken"
+	buf.Reset()
+	if err := WritePDF(&buf, form, sub, nil, PDFOptions{}); err != nil {
+		t.Fatalf("render without storage failed: %v", err)
+	}
+	if n := strings.Count(buf.String(), "/Subtype /Image"); n != 0 {
+		t.Errorf("expected no embedded images, got %d", n)
+	}
+}
+
+func TestPDFRenderer_Image_TooLarge(t *testing.T) {
+	// A PNG header announcing 20000×20000 pixels, which must not be decoded
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], 20000)
+	binary.BigEndian.PutUint32(ihdr[8:], 20000)
+	ihdr[12], ihdr[13] = 8, 6 // 8-bit RGBA
+	var data bytes.Buffer
+	data.WriteString("\x89PNG\r\n\x1a\n")
+	binary.Write(&data, binary.BigEndian, uint32(13))
+	data.Write(ihdr)
+	binary.Write(&data, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data.Bytes())); err != nil {
+		t.Fatalf("fixture is not a valid PNG header: %v", err)
+	}
+
+	r := &pdfRenderer{pdf: fpdf.New("P", "mm", "A4", "")}
+	if r.image(data.Bytes()) {
+		t.Error("expected oversized image to be skipped")
+	}
+	if !r.image(testPNG(t)) {
+		t.Error("expected small image to be embedded")
+	}
+}
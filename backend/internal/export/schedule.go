@@ -0,0 +1,260 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"formera/internal/cron"
+	"formera/internal/jobs"
+	"formera/internal/logger"
+	"formera/internal/mailer"
+	"formera/internal/models"
+	"formera/internal/versions"
+
+	"gorm.io/gorm"
+)
+
+// ScheduleFormats are the file formats of scheduled exports
+var ScheduleFormats = map[string]bool{"csv": true, "json": true, "xlsx": true, "zip": true}
+
+// ScheduleOptions are the export options of a schedule, parsed from its
+// parameters when it is saved. Only the options of Format are used.
+type ScheduleOptions struct {
+	Format  string         `json:"format"`
+	CSV     CSVOptions     `json:"csv"`
+	JSON    Options        `json:"json"`
+	XLSX    XLSXOptions    `json:"xlsx"`
+	Archive ArchiveOptions `json:"zip"`
+	Filter  Filter         `json:"filter"`
+}
+
+type schedulePayload struct {
+	ScheduleID string    `json:"schedule_id"`
+	Revision   int       `json:"revision"`
+	RunAt      time.Time `json:"run_at"`
+}
+
+// NextRun returns the first time after t at which a cron spec matches in the
+// given IANA time zone
+func NextRun(spec, timezone string, t time.Time) (time.Time, error) {
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown time zone %q", timezone)
+	}
+	next := schedule.Next(t.In(loc))
+	if next.IsZero() {
+		return time.Time{}, errors.New("schedule never runs")
+	}
+	return next, nil
+}
+
+// UseMailer enables the download links emailed after scheduled runs
+func (r *Runner) UseMailer(m *mailer.Mailer) {
+	r.mailer = m
+}
+
+// Schedule starts a new revision of a schedule after it was created or
+// changed and queues its next run. Runs queued for earlier revisions are
+// skipped once they are due. Inactive schedules are unscheduled.
+func (r *Runner) Schedule(s *models.ExportSchedule) error {
+	s.Revision++
+	if !s.Active {
+		s.NextRunAt = nil
+		return r.db.Model(s).Updates(map[string]interface{}{"next_run_at": nil, "revision": s.Revision}).Error
+	}
+	if r.queue == nil {
+		return errors.New("background exports are not available")
+	}
+	if err := r.db.Model(s).Update("revision", s.Revision).Error; err != nil {
+		return err
+	}
+	return r.queueNext(s)
+}
+
+// queueNext stores the next run of the current revision of a schedule and queues it
+func (r *Runner) queueNext(s *models.ExportSchedule) error {
+	next, err := NextRun(s.Spec, s.Timezone, time.Now())
+	if err != nil {
+		return err
+	}
+	next = next.UTC()
+	if err := r.db.Model(s).Update("next_run_at", next).Error; err != nil {
+		return err
+	}
+	s.NextRunAt = &next
+
+	// A failed run is recorded and the next one scheduled instead of retrying
+	payload := schedulePayload{ScheduleID: s.ID, Revision: s.Revision, RunAt: next}
+	_, err = r.queue.Enqueue(ScheduleJobType, payload, jobs.RunAt(next), jobs.MaxAttempts(1))
+	return err
+}
+
+func (r *Runner) runSchedule(ctx context.Context, job *models.Job) error {
+	var payload schedulePayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+
+	var s models.ExportSchedule
+	if err := r.db.First(&s, "id = ?", payload.ScheduleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // Deleted together with its form
+		}
+		return err
+	}
+	// The schedule was paused or changed after this run was queued
+	if !s.Active || s.Revision != payload.Revision {
+		return nil
+	}
+	var form models.Form
+	if err := r.db.First(&form, "id = ?", s.FormID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := versions.WithHistory(r.db, &form); err != nil {
+		return err
+	}
+
+	until := time.Now().UTC().Truncate(time.Second)
+	// Queue the next run first, so a failing run does not stop the schedule.
+	// A run requeued after a shutdown has already queued it.
+	if s.NextRunAt != nil && s.NextRunAt.Equal(payload.RunAt) {
+		if err := r.queueNext(&s); err != nil {
+			logger.Error().Err(err).Str("schedule_id", s.ID).Msg("Failed to schedule next export run")
+		}
+	}
+
+	exp, err := r.runScheduled(ctx, &s, &form, until)
+	if err != nil {
+		if exp != nil {
+			r.db.Model(exp).Updates(map[string]interface{}{"status": models.ExportStatusFailed, "error": err.Error(), "finished_at": time.Now()})
+		}
+		return err
+	}
+	return nil
+}
+
+// runScheduled writes one run of a schedule, recorded as an export. Incremental
+// runs cover the submissions from the end of the last successful run until
+// until. Returns the export once it is created, also on failure.
+func (r *Runner) runScheduled(ctx context.Context, s *models.ExportSchedule, form *models.Form, until time.Time) (*models.Export, error) {
+	var opts ScheduleOptions
+	if err := json.Unmarshal([]byte(s.Options), &opts); err != nil {
+		return nil, fmt.Errorf("invalid export options: %w", err)
+	}
+
+	exp := &models.Export{
+		FormID:     form.ID,
+		UserID:     s.UserID,
+		ScheduleID: s.ID,
+		Format:     opts.Format,
+		Options:    s.Options,
+		Status:     models.ExportStatusRunning,
+	}
+	filter := opts.Filter
+	if s.Incremental {
+		filter.From = s.LastRunAt
+		filter.To = &until
+		exp.Since = s.LastRunAt
+		exp.Until = &until
+	}
+	if err := r.db.Create(exp).Error; err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "formera-export-*."+opts.Format)
+	if err != nil {
+		return exp, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	query := filter.Apply(r.db.WithContext(ctx).Model(&models.Submission{}).Where("form_id = ?", form.ID))
+	submissions, contentType, err := r.writeScheduled(tmp, form, query, opts)
+	if err != nil {
+		return exp, err
+	}
+	filename := fmt.Sprintf("%s-submissions-%s.%s", form.ID, until.Format("20060102-150405"), opts.Format)
+	if err := r.store(exp, tmp, filename, contentType, submissions); err != nil {
+		return exp, err
+	}
+
+	if s.Incremental {
+		if err := r.db.Model(s).Update("last_run_at", until).Error; err != nil {
+			return exp, err
+		}
+		s.LastRunAt = &until
+	}
+	logger.Info().
+		Str("export_id", exp.ID).
+		Str("schedule_id", s.ID).
+		Int("submissions", submissions).
+		Int64("size", exp.Size).
+		Msg("Scheduled export finished")
+
+	r.notify(s, form, exp)
+	return exp, nil
+}
+
+// writeScheduled writes the export file of a schedule and returns the number
+// of submissions and the content type
+func (r *Runner) writeScheduled(w io.Writer, form *models.Form, query *gorm.DB, opts ScheduleOptions) (int, string, error) {
+	if opts.Format == "zip" {
+		stats, err := WriteArchive(w, form, query, r.storage, opts.Archive)
+		return stats.Submissions, "application/zip", err
+	}
+
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return 0, "", err
+	}
+	var err error
+	contentType := ""
+	switch opts.Format {
+	case "csv":
+		contentType = "text/csv"
+		err = WriteCSV(w, form, query, opts.CSV)
+	case "json":
+		contentType = "application/json"
+		err = WriteJSON(w, form, query, opts.JSON)
+	case "xlsx":
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		err = WriteXLSX(w, form, query, opts.XLSX)
+	default:
+		err = fmt.Errorf("unsupported format %q", opts.Format)
+	}
+	return int(count), contentType, err
+}
+
+// notify emails the link to a finished run to the schedule's recipients. The
+// link opens the run in the frontend, as the file is only served to signed-in users.
+func (r *Runner) notify(s *models.ExportSchedule, form *models.Form, exp *models.Export) {
+	var to []string
+	for _, addr := range strings.Split(s.Email, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	if len(to) == 0 || !r.mailer.Enabled() {
+		return
+	}
+
+	msg, err := mailer.NewExportReady(form, s, exp, to, r.mailer.BaseURL())
+	if err != nil {
+		logger.Error().Err(err).Str("export_id", exp.ID).Msg("Failed to build export email")
+		return
+	}
+	r.mailer.SendAsync(msg)
+}
@@ -0,0 +1,158 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"formera/internal/jobs"
+	"formera/internal/models"
+	"formera/internal/storage"
+	"formera/internal/testutil"
+)
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2025, 3, 5, 10, 30, 0, 0, time.UTC)
+	next, err := NextRun("0 7 * * 1", "Europe/Berlin", now)
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	if want := time.Date(2025, 3, 10, 6, 0, 0, 0, time.UTC); !next.Equal(want) {
+		t.Errorf("expected %v, got %v", want, next)
+	}
+
+	for _, tt := range []struct{ spec, tz string }{
+		{"0 7 * *", "UTC"},
+		{"0 7 * * 1", "Mars/Olympus"},
+		{"0 0 31 2 *", "UTC"},
+	} {
+		if _, err := NextRun(tt.spec, tt.tz, now); err == nil {
+			t.Errorf("%q in %s: expected an error", tt.spec, tt.tz)
+		}
+	}
+}
+
+func TestRunner_Schedule(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store, _ := storage.NewLocalStorage(t.TempDir(), "http://localhost/uploads")
+	form := testForm()
+	db.Create(form)
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"name": "Ada"}, CreatedAt: time.Now().Add(-time.Hour)})
+
+	queue := jobs.New(db, jobs.Config{})
+	runner := NewRunner(db, store)
+	runner.Register(queue)
+
+	options, _ := json.Marshal(ScheduleOptions{Format: "csv", CSV: CSVOptions{Options: Options{Headers: HeaderIDs, Columns: []string{"name"}}, Delimiter: ','}})
+	schedule := &models.ExportSchedule{
+		FormID:      form.ID,
+		UserID:      "user-1",
+		Name:        "Weekly",
+		Spec:        "0 7 * * 1",
+		Timezone:    "UTC",
+		Format:      "csv",
+		Options:     string(options),
+		Incremental: true,
+		Active:      true,
+	}
+	db.Create(schedule)
+	if err := runner.Schedule(schedule); err != nil {
+		t.Fatalf("schedule failed: %v", err)
+	}
+	if schedule.NextRunAt == nil || schedule.NextRunAt.Weekday() != time.Monday || schedule.NextRunAt.Hour() != 7 {
+		t.Fatalf("unexpected next run %v", schedule.NextRunAt)
+	}
+
+	// runDue runs the queued run of the schedule as if it were due
+	runDue := func() {
+		t.Helper()
+		db.Model(&models.Job{}).Where("type = ? AND status = ?", ScheduleJobType, models.JobStatusPending).Update("run_at", time.Now())
+		queue.RunDue()
+	}
+	readRun := func(exp *models.Export) string {
+		t.Helper()
+		content, err := store.GetFileByPath(exp.Path)
+		if err != nil {
+			t.Fatalf("export file not stored: %v", err)
+		}
+		defer content.Reader.Close()
+		data, _ := io.ReadAll(content.Reader)
+		return strings.TrimSpace(string(data))
+	}
+
+	runDue()
+	var runs []models.Export
+	db.Where("schedule_id = ?", schedule.ID).Order("created_at").Find(&runs)
+	if len(runs) != 1 || runs[0].Status != models.ExportStatusSucceeded || runs[0].Submissions != 1 || runs[0].Since != nil || runs[0].Until == nil {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+	if got := readRun(&runs[0]); got != "name\nAda" {
+		t.Errorf("unexpected first run:\n%s", got)
+	}
+	db.First(schedule, "id = ?", schedule.ID)
+	if schedule.LastRunAt == nil || !schedule.LastRunAt.Equal(*runs[0].Until) {
+		t.Errorf("expected progress to be recorded, got %v", schedule.LastRunAt)
+	}
+	var pending int64
+	db.Model(&models.Job{}).Where("type = ? AND status = ?", ScheduleJobType, models.JobStatusPending).Count(&pending)
+	if pending != 1 {
+		t.Errorf("expected the next run to be queued, got %d", pending)
+	}
+
+	// Incremental runs only contain new submissions
+	db.Model(schedule).Update("last_run_at", schedule.LastRunAt.Add(-time.Minute))
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"name": "Bob"}, CreatedAt: time.Now().Add(-30 * time.Second)})
+	runDue()
+	runs = nil
+	db.Where("schedule_id = ?", schedule.ID).Order("created_at").Find(&runs)
+	if len(runs) != 2 || runs[1].Since == nil {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+	if got := readRun(&runs[1]); got != "name\nBob" {
+		t.Errorf("unexpected incremental run:\n%s", got)
+	}
+
+	// Runs interrupted by a shutdown are requeued and still run, without
+	// queueing the next run twice
+	var done models.Job
+	db.Where("type = ? AND status = ?", ScheduleJobType, models.JobStatusSucceeded).Order("created_at DESC").First(&done)
+	var payload schedulePayload
+	json.Unmarshal([]byte(done.Payload), &payload)
+	// The next run was queued a week later before the shutdown
+	payload.RunAt = payload.RunAt.Add(-7 * 24 * time.Hour)
+	encoded, _ := json.Marshal(payload)
+	db.Model(&done).Updates(map[string]interface{}{"status": models.JobStatusPending, "attempts": 0, "run_at": time.Now(), "payload": string(encoded)})
+	queue.RunDue()
+	runs = nil
+	db.Where("schedule_id = ?", schedule.ID).Order("created_at").Find(&runs)
+	if len(runs) != 3 || runs[2].Status != models.ExportStatusSucceeded {
+		t.Fatalf("expected the requeued run to export, got %+v", runs)
+	}
+	db.Model(&models.Job{}).Where("type = ? AND status = ?", ScheduleJobType, models.JobStatusPending).Count(&pending)
+	if pending != 1 {
+		t.Errorf("expected a single queued next run, got %d", pending)
+	}
+
+	// Runs queued before the schedule was changed are skipped
+	db.First(schedule, "id = ?", schedule.ID)
+	if err := runner.Schedule(schedule); err != nil {
+		t.Fatalf("reschedule failed: %v", err)
+	}
+	db.Model(&models.Job{}).Where("type = ? AND status = ?", ScheduleJobType, models.JobStatusPending).Count(&pending)
+	runDue()
+	var count int64
+	db.Model(&models.Export{}).Where("schedule_id = ?", schedule.ID).Count(&count)
+	if pending != 2 || count != 4 {
+		t.Errorf("expected only the run of the new revision, got %d queued and %d runs", pending, count)
+	}
+
+	// Runs queued before the schedule was paused are skipped
+	db.Model(schedule).Update("active", false)
+	runDue()
+	db.Model(&models.Export{}).Where("schedule_id = ?", schedule.ID).Count(&count)
+	if count != 4 {
+		t.Errorf("expected no run of a paused schedule, got %d runs", count)
+	}
+}
@@ -0,0 +1,345 @@
+package export
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"formera/internal/models"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// Sheet names of XLSX exports
+const (
+	SheetSubmissions = "Submissions"
+	SheetMetadata    = "Metadata"
+	SheetSummary     = "Summary"
+)
+
+// XLSXOptions configure an XLSX export
+type XLSXOptions struct {
+	Options
+	MetadataSheet bool `json:"metadata_sheet,omitempty"` // Metadata and UTM parameters per submission on a separate sheet
+	SummarySheet  bool `json:"summary_sheet,omitempty"`  // Answer counts and numeric statistics per field
+}
+
+// xlsxStyles holds the style IDs used by an export
+type xlsxStyles struct {
+	header, date, dateTime, percent int
+}
+
+// WriteXLSX writes the submissions matched by query as a spreadsheet. Numbers,
+// dates and submission times are typed cells, and header rows are frozen.
+func WriteXLSX(w io.Writer, form *models.Form, query *gorm.DB, opts XLSXOptions) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	styles, err := newXLSXStyles(f)
+	if err != nil {
+		return err
+	}
+	sep := opts.MultiValueSeparator
+	if sep == "" {
+		sep = DefaultMultiValueSeparator
+	}
+
+	if err := f.SetSheetName(f.GetSheetName(0), SheetSubmissions); err != nil {
+		return err
+	}
+	columns := Columns(form, opts.Options)
+	summary := newSummary(columns)
+	err = writeSheet(f, SheetSubmissions, columns, styles, query, func(col Column, sub *models.Submission) interface{} {
+		v := col.Value(sub)
+		if opts.SummarySheet && col.Kind == ColumnField {
+			summary.add(col.Field, v)
+		}
+		return cellValue(col, v, sep, styles)
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.MetadataSheet {
+		if _, err := f.NewSheet(SheetMetadata); err != nil {
+			return err
+		}
+		metaColumns := Columns(&models.Form{}, Options{Headers: opts.Headers, IncludeMetadata: true, IncludeUTM: true})
+		err := writeSheet(f, SheetMetadata, metaColumns, styles, query, func(col Column, sub *models.Submission) interface{} {
+			return cellValue(col, col.Value(sub), sep, styles)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.SummarySheet {
+		if err := writeSummarySheet(f, summary, styles); err != nil {
+			return err
+		}
+	}
+
+	return f.Write(w)
+}
+
+func newXLSXStyles(f *excelize.File) (*xlsxStyles, error) {
+	var s xlsxStyles
+	var err error
+	if s.header, err = f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}); err != nil {
+		return nil, err
+	}
+	dateFmt, dateTimeFmt := "yyyy-mm-dd", "yyyy-mm-dd hh:mm:ss"
+	if s.date, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateFmt}); err != nil {
+		return nil, err
+	}
+	if s.dateTime, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateTimeFmt}); err != nil {
+		return nil, err
+	}
+	if s.percent, err = f.NewStyle(&excelize.Style{NumFmt: 10}); err != nil { // 0.00%
+		return nil, err
+	}
+	return &s, nil
+}
+
+// writeSheet streams a header row and one row per submission to a sheet
+func writeSheet(f *excelize.File, sheet string, columns []Column, styles *xlsxStyles, query *gorm.DB,
+	value func(Column, *models.Submission) interface{}) error {
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	if err := sw.SetColWidth(1, len(columns), 20); err != nil {
+		return err
+	}
+
+	row := make([]interface{}, len(columns))
+	for i, col := range columns {
+		row[i] = excelize.Cell{StyleID: styles.header, Value: col.Header}
+	}
+	if err := sw.SetRow("A1", row); err != nil {
+		return err
+	}
+
+	n := 1
+	err = Each(query, func(sub *models.Submission) error {
+		n++
+		for i, col := range columns {
+			row[i] = value(col, sub)
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, n)
+		return sw.SetRow(cell, row)
+	})
+	if err != nil {
+		return err
+	}
+	return sw.Flush()
+}
+
+// cellValue converts a value to a typed cell: numbers for number, rating and
+// scale fields, dates for date fields and date-times for the submission time
+func cellValue(col Column, v interface{}, sep string, styles *xlsxStyles) interface{} {
+	switch col.Kind {
+	case ColumnSubmittedAt:
+		if t, ok := v.(time.Time); ok {
+			return excelize.Cell{StyleID: styles.dateTime, Value: t.UTC()}
+		}
+	case ColumnField:
+		switch col.Field.Type {
+		case models.FieldTypeNumber, models.FieldTypeRating, models.FieldTypeScale:
+			if n, ok := number(v); ok {
+				return n
+			}
+		case models.FieldTypeDate:
+			if s, ok := v.(string); ok {
+				if t, err := time.Parse("2006-01-02", s); err == nil {
+					return excelize.Cell{StyleID: styles.date, Value: t}
+				}
+			}
+		}
+		if b, ok := v.(bool); ok {
+			return b
+		}
+	case ColumnMetadata:
+		if n, ok := v.(int); ok {
+			return n
+		}
+	}
+	return FormatValue(v, sep)
+}
+
+// number returns the numeric value of a JSON number or numeric string
+func number(v interface{}) (float64, bool) {
+	switch typed := v.(type) {
+	case float64:
+		return typed, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// fieldSummary accumulates the answers to a single field
+type fieldSummary struct {
+	field    *models.FormField
+	answered int
+	options  map[string]int
+	count    int // Numeric answers
+	min, max float64
+	sum      float64
+}
+
+// summary holds the field summaries in column order
+type summary struct {
+	fields []*fieldSummary
+	byID   map[string]*fieldSummary
+}
+
+func newSummary(columns []Column) *summary {
+	s := &summary{byID: make(map[string]*fieldSummary)}
+	for _, col := range columns {
+		if col.Kind == ColumnField {
+			fs := &fieldSummary{field: col.Field, options: make(map[string]int)}
+			s.fields = append(s.fields, fs)
+			s.byID[col.Key] = fs
+		}
+	}
+	return s
+}
+
+func (s *summary) add(field *models.FormField, v interface{}) {
+	if fs := s.byID[field.ID]; fs != nil {
+		fs.add(v)
+	}
+}
+
+func (fs *fieldSummary) add(v interface{}) {
+	switch typed := v.(type) {
+	case nil:
+		return
+	case string:
+		if typed == "" {
+			return
+		}
+	case []interface{}:
+		if len(typed) == 0 {
+			return
+		}
+	}
+	fs.answered++
+
+	t := fs.field.Type
+	switch {
+	case t.IsChoice():
+		items, ok := v.([]interface{})
+		if !ok {
+			items = []interface{}{v}
+		}
+		for _, item := range items {
+			fs.options[FormatValue(item, "")]++
+		}
+	case t == models.FieldTypeNumber || t == models.FieldTypeRating || t == models.FieldTypeScale:
+		n, ok := number(v)
+		if !ok {
+			return
+		}
+		if fs.count == 0 || n < fs.min {
+			fs.min = n
+		}
+		if fs.count == 0 || n > fs.max {
+			fs.max = n
+		}
+		fs.count++
+		fs.sum += n
+	}
+}
+
+// optionOrder lists the field's options followed by other answers
+func (fs *fieldSummary) optionOrder() []string {
+	seen := make(map[string]bool)
+	var order []string
+	for _, o := range fs.field.Options {
+		if !seen[o] {
+			seen[o] = true
+			order = append(order, o)
+		}
+	}
+	var others []string
+	for o := range fs.options {
+		if !seen[o] {
+			others = append(others, o)
+		}
+	}
+	sort.Strings(others)
+	return append(order, others...)
+}
+
+// writeSummarySheet lists per field how often it was answered, the count and share
+// of each option for choice fields and min, max and average for numeric fields
+func writeSummarySheet(f *excelize.File, s *summary, styles *xlsxStyles) error {
+	if _, err := f.NewSheet(SheetSummary); err != nil {
+		return err
+	}
+	sw, err := f.NewStreamWriter(SheetSummary)
+	if err != nil {
+		return err
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	if err := sw.SetColWidth(1, 3, 24); err != nil {
+		return err
+	}
+
+	headers := []string{"Field", "Type", "Answered", "Option", "Count", "Share", "Min", "Max", "Average"}
+	row := make([]interface{}, len(headers))
+	for i, h := range headers {
+		row[i] = excelize.Cell{StyleID: styles.header, Value: h}
+	}
+	n := 0
+	write := func(values ...interface{}) error {
+		n++
+		cell, _ := excelize.CoordinatesToCellName(1, n)
+		return sw.SetRow(cell, values)
+	}
+	if err := write(row...); err != nil {
+		return err
+	}
+
+	for _, fs := range s.fields {
+		label := fs.field.Label
+		if label == "" {
+			label = fs.field.ID
+		}
+		base := []interface{}{label, string(fs.field.Type), fs.answered}
+
+		switch {
+		case fs.field.Type.IsChoice() && len(fs.optionOrder()) > 0:
+			for _, option := range fs.optionOrder() {
+				count := fs.options[option]
+				var share interface{}
+				if fs.answered > 0 {
+					share = excelize.Cell{StyleID: styles.percent, Value: float64(count) / float64(fs.answered)}
+				}
+				if err := write(append(base, option, count, share)...); err != nil {
+					return err
+				}
+			}
+		case fs.count > 0:
+			if err := write(append(base, nil, nil, nil, fs.min, fs.max, fs.sum/float64(fs.count))...); err != nil {
+				return err
+			}
+		default:
+			if err := write(base...); err != nil {
+				return err
+			}
+		}
+	}
+	return sw.Flush()
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	form := &models.Form{
+		Title: "Survey",
+		Fields: models.FormFields{
+			{ID: "name", Type: models.FieldTypeText, Label: "Name"},
+			{ID: "age", Type: models.FieldTypeNumber, Label: "Age"},
+			{ID: "day", Type: models.FieldTypeDate, Label: "Day"},
+			{ID: "color", Type: models.FieldTypeRadio, Label: "Color", Options: []string{"Red", "Blue"}},
+		},
+	}
+	db.Create(form)
+
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	answers := []models.SubmissionData{
+		{"name": "Ada", "age": float64(36), "day": "2025-02-14", "color": "Red"},
+		{"name": "Grace", "age": "40", "day": "soon", "color": "Red"},
+		{"name": "Linus"},
+	}
+	for i, data := range answers {
+		db.Create(&models.Submission{
+			FormID:    form.ID,
+			Data:      data,
+			Metadata:  models.SubmissionMetadata{IP: "127.0.0.1", UTMSource: "ads"},
+			CreatedAt: start.Add(time.Duration(i) * time.Hour),
+		})
+	}
+
+	var buf bytes.Buffer
+	opts := XLSXOptions{MetadataSheet: true, SummarySheet: true}
+	query := db.Model(&models.Submission{}).Where("form_id = ?", form.ID)
+	if err := WriteXLSX(&buf, form, query, opts); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("invalid XLSX: %v", err)
+	}
+	defer f.Close()
+
+	if sheets := f.GetSheetList(); len(sheets) != 3 || sheets[0] != SheetSubmissions {
+		t.Fatalf("unexpected sheets %v", sheets)
+	}
+
+	panes, err := f.GetPanes(SheetSubmissions)
+	if err != nil || !panes.Freeze || panes.YSplit != 1 {
+		t.Errorf("expected frozen header row, got %+v (%v)", panes, err)
+	}
+
+	rows, _ := f.GetRows(SheetSubmissions, excelize.Options{RawCellValue: true})
+	if len(rows) != 4 {
+		t.Fatalf("expected header and 3 rows, got %v", rows)
+	}
+	if got := rows[0]; len(got) != 6 || got[2] != "Name" || got[5] != "Color" {
+		t.Errorf("unexpected header %v", got)
+	}
+
+	// Strings are written inline, numbers and dates as untyped numeric cells
+	textCells := map[string]bool{
+		"B2": false, // Submitted at as a date-time serial
+		"D2": false,
+		"D3": false, // Numeric string
+		"E2": false,
+		"E3": true, // Unparseable date stays text
+		"C2": true,
+	}
+	for cell, want := range textCells {
+		typ, _ := f.GetCellType(SheetSubmissions, cell)
+		if got := typ == excelize.CellTypeInlineString || typ == excelize.CellTypeSharedString; got != want {
+			t.Errorf("%s: expected text %v, got cell type %v", cell, want, typ)
+		}
+	}
+	if v := rows[2][3]; v != "40" {
+		t.Errorf("expected numeric age 40, got %q", v)
+	}
+	if v, _ := f.GetCellValue(SheetSubmissions, "E2"); v != "2025-02-14" {
+		t.Errorf("expected formatted date, got %q", v)
+	}
+	if v, _ := f.GetCellValue(SheetSubmissions, "B2"); v != "2025-03-01 12:00:00" {
+		t.Errorf("expected formatted date-time, got %q", v)
+	}
+
+	meta, _ := f.GetRows(SheetMetadata)
+	if len(meta) != 4 || meta[0][2] != "IP" || meta[1][2] != "127.0.0.1" {
+		t.Errorf("unexpected metadata sheet %v", meta)
+	}
+
+	summary, _ := f.GetRows(SheetSummary, excelize.Options{RawCellValue: true})
+	want := [][]string{
+		{"Field", "Type", "Answered", "Option", "Count", "Share", "Min", "Max", "Average"},
+		{"Name", "text", "3"},
+		{"Age", "number", "2", "", "", "", "36", "40", "38"},
+		{"Day", "date", "2"},
+		{"Color", "radio", "2", "Red", "2", "1"},
+		{"Color", "radio", "2", "Blue", "0", "0"},
+	}
+	if len(summary) != len(want) {
+		t.Fatalf("expected %d summary rows, got %v", len(want), summary)
+	}
+	for i := range want {
+		for j := range want[i] {
+			if j >= len(summary[i]) || summary[i][j] != want[i][j] {
+				t.Errorf("summary row %d: expected %v, got %v", i, want[i], summary[i])
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"formera/internal/database"
+	"formera/internal/logger"
+	"formera/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultAnalyticsDays is the range reported when no from date is given
+const defaultAnalyticsDays = 30
+
+// maxCompletionTime discards implausible durations, e.g. from tabs left open for days
+const maxCompletionTime = 24 * time.Hour
+
+type AnalyticsHandler struct {
+	appHost string // Referrals from the frontend itself are direct traffic
+}
+
+func NewAnalyticsHandler(baseURL string) *AnalyticsHandler {
+	return &AnalyticsHandler{appHost: referrerDomain(baseURL)}
+}
+
+// FunnelDay holds the funnel counts of a single UTC day
+type FunnelDay struct {
+	Date        string `json:"date" example:"2025-01-15"`
+	Views       int    `json:"views" example:"120"`
+	Starts      int    `json:"starts" example:"45"`
+	Submissions int    `json:"submissions" example:"30"`
+}
+
+// FunnelResponse represents the view/start/submission funnel of a form
+type FunnelResponse struct {
+	Views       int `json:"views" example:"1200"`
+	Starts      int `json:"starts" example:"450"`
+	Submissions int `json:"submissions" example:"300"`
+	// Rates are null when the denominator is zero
+	StartRate               *float64    `json:"start_rate" example:"0.375"`      // starts / views
+	ConversionRate          *float64    `json:"conversion_rate" example:"0.25"`  // submissions / views
+	CompletionRate          *float64    `json:"completion_rate" example:"0.667"` // submissions / starts
+	MedianCompletionSeconds *float64    `json:"median_completion_seconds" example:"94"`
+	Daily                   []FunnelDay `json:"daily"`
+}
+
+// RecordStart godoc
+// @Summary      Record form start
+// @Description  Beacon sent when a respondent first interacts with a form
+// @Tags         Public
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Success      200 {object} MessageResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      429 {object} ErrorResponse "Rate limit exceeded"
+// @Router       /public/forms/{id}/start [post]
+func (h *AnalyticsHandler) RecordStart(c *gin.Context) {
+	var form models.Form
+	if result := database.DB.Select("id").Where("id = ? AND status = ?", c.Param("id"), models.FormStatusPublished).First(&form); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found or not published"})
+		return
+	}
+
+	recordFormEvent(form.ID, models.AnalyticsStarts)
+	c.JSON(http.StatusOK, gin.H{"message": "Start recorded"})
+}
+
+// Funnel godoc
+// @Summary      Get form funnel analytics
+// @Description  Get views, starts, submissions, conversion rates and median completion time
+// @Description  per UTC day. Defaults to the last 30 days.
+// @Tags         Submissions
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        from query string false "Start date (YYYY-MM-DD)"
+// @Param        to query string false "End date, inclusive (YYYY-MM-DD)"
+// @Success      200 {object} FunnelResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/analytics [get]
+func (h *AnalyticsHandler) Funnel(c *gin.Context) {
+	formID := c.Param("id")
+
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	r, msg := parseTimeRange(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	r = utcDayRange(r)
+
+	buckets, ok := r.buckets(*r.From, *r.To)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date range too large"})
+		return
+	}
+
+	daily := make(map[string]*FunnelDay, len(buckets))
+	response := FunnelResponse{Daily: make([]FunnelDay, 0, len(buckets))}
+	for _, b := range buckets {
+		response.Daily = append(response.Daily, FunnelDay{Date: r.bucketLabel(b)})
+	}
+	for i := range response.Daily {
+		daily[response.Daily[i].Date] = &response.Daily[i]
+	}
+
+	var rows []models.FormAnalytics
+	if err := database.DB.Where("form_id = ? AND date >= ? AND date < ?",
+		formID, r.From.Format("2006-01-02"), r.To.Format("2006-01-02")).
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute analytics"})
+		return
+	}
+	for _, row := range rows {
+		if day := daily[row.Date]; day != nil {
+			day.Views = row.Views
+			day.Starts = row.Starts
+		}
+	}
+
+	filter, filterArgs := r.sqlFilter()
+	var submissions []struct {
+		Date  string
+		Count int
+	}
+	if err := database.DB.Model(&models.Submission{}).
+		Select("strftime('%Y-%m-%d', created_at) AS date, COUNT(*) AS count").
+		Where("form_id = ?", formID).
+		Where(filter, filterArgs...).
+		Group("date").
+		Scan(&submissions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute analytics"})
+		return
+	}
+	for _, s := range submissions {
+		if day := daily[s.Date]; day != nil {
+			day.Submissions = s.Count
+		}
+	}
+
+	for _, day := range response.Daily {
+		response.Views += day.Views
+		response.Starts += day.Starts
+		response.Submissions += day.Submissions
+	}
+	response.StartRate = ratio(response.Starts, response.Views)
+	response.ConversionRate = ratio(response.Submissions, response.Views)
+	response.CompletionRate = ratio(response.Submissions, response.Starts)
+
+	median, err := medianCompletionSeconds(formID, filter, filterArgs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute analytics"})
+		return
+	}
+	response.MedianCompletionSeconds = median
+
+	c.JSON(http.StatusOK, response)
+}
+
+// utcDayRange aligns a range to whole UTC days, defaulting to the last 30 days
+func utcDayRange(r *timeRange) *timeRange {
+	aligned := &timeRange{Granularity: GranularityDay, Location: time.UTC}
+
+	to := time.Now()
+	if r.To != nil {
+		to = r.To.Add(-time.Nanosecond)
+	}
+	end := aligned.nextBucket(aligned.bucketStart(to))
+
+	start := end.AddDate(0, 0, -defaultAnalyticsDays)
+	if r.From != nil {
+		start = aligned.bucketStart(*r.From)
+	}
+
+	aligned.From = &start
+	aligned.To = &end
+	return aligned
+}
+
+// medianCompletionSeconds returns the median time between start and submit, or nil without data
+func medianCompletionSeconds(formID, filter string, filterArgs []interface{}) (*float64, error) {
+	query := func() *gorm.DB {
+		return database.DB.Model(&models.Submission{}).
+			Where("form_id = ? AND completion_seconds IS NOT NULL", formID).
+			Where(filter, filterArgs...)
+	}
+
+	var count int64
+	if err := query().Count(&count).Error; err != nil || count == 0 {
+		return nil, err
+	}
+
+	// The middle value, or the two middle values for an even count
+	var values []int
+	if err := query().
+		Order("completion_seconds ASC").
+		Offset(int((count-1)/2)).
+		Limit(int(2-count%2)).
+		Pluck("completion_seconds", &values).Error; err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	sum := 0
+	for _, v := range values {
+		sum += v
+	}
+	median := float64(sum) / float64(len(values))
+	return &median, nil
+}
+
+// ratio returns a/b rounded to four decimals, or nil if b is zero
+func ratio(a, b int) *float64 {
+	if b == 0 {
+		return nil
+	}
+	r := math.Round(float64(a)/float64(b)*10000) / 10000
+	return &r
+}
+
+// recordFormEvent increments a daily funnel counter of a form
+func recordFormEvent(formID, counter string) {
+	row := models.FormAnalytics{FormID: formID, Date: time.Now().UTC().Format("2006-01-02")}
+	switch counter {
+	case models.AnalyticsViews:
+		row.Views = 1
+	case models.AnalyticsStarts:
+		row.Starts = 1
+	default:
+		return
+	}
+
+	if err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "form_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{counter: gorm.Expr(counter + " + 1")}),
+	}).Create(&row).Error; err != nil {
+		logger.Warn().Err(err).Str("form_id", formID).Str("counter", counter).Msg("Failed to record form analytics")
+	}
+}
+
+// deleteFormAnalytics removes the funnel and attribution counters of the given forms
+func deleteFormAnalytics(tx *gorm.DB, formIDs []string) error {
+	if err := tx.Where("form_id IN ?", formIDs).Delete(&models.FormAnalytics{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("form_id IN ?", formIDs).Delete(&models.AttributionView{}).Error
+}
+
+// completionSeconds returns the time from the client-reported start until now,
+// or nil if it is missing or implausible
+func completionSeconds(startedAt *time.Time, now time.Time) *int {
+	if startedAt == nil {
+		return nil
+	}
+	d := now.Sub(*startedAt)
+	if d <= 0 || d > maxCompletionTime {
+		return nil
+	}
+	seconds := int(math.Round(d.Seconds()))
+	return &seconds
+}
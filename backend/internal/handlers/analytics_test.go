@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func getFunnel(t *testing.T, userID, formID, query string) (int, FunnelResponse) {
+	t.Helper()
+	handler := NewAnalyticsHandler("")
+	router := gin.New()
+	router.GET("/forms/:id/analytics", func(c *gin.Context) {
+		c.Set("user_id", userID)
+		handler.Funnel(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/forms/"+formID+"/analytics"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response FunnelResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+	}
+	return w.Code, response
+}
+
+func TestAnalyticsHandler_RecordsViewsAndStarts(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{UserID: user.ID, Title: "Funnel", Status: models.FormStatusPublished}
+	draft := &models.Form{UserID: user.ID, Title: "Draft", Status: models.FormStatusDraft}
+	db.Create(form)
+	db.Create(draft)
+
+	formHandler := NewFormHandler(nil)
+	analyticsHandler := NewAnalyticsHandler("")
+	router := gin.New()
+	router.GET("/public/forms/:id", formHandler.GetPublic)
+	router.POST("/public/forms/:id/start", analyticsHandler.RecordStart)
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/public/forms/"+form.ID, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/public/forms/"+form.ID+"/start", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/public/forms/"+draft.ID+"/start", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for draft, got %d", http.StatusNotFound, w.Code)
+	}
+
+	var row models.FormAnalytics
+	if err := db.Where("form_id = ?", form.ID).First(&row).Error; err != nil {
+		t.Fatalf("expected analytics row: %v", err)
+	}
+	if row.Views != 3 || row.Starts != 1 {
+		t.Errorf("expected 3 views and 1 start, got %d and %d", row.Views, row.Starts)
+	}
+	if row.Date != time.Now().UTC().Format("2006-01-02") {
+		t.Errorf("expected today's UTC date, got %s", row.Date)
+	}
+
+	var count int64
+	db.Model(&models.FormAnalytics{}).Where("form_id = ?", draft.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected no analytics for draft, got %d rows", count)
+	}
+}
+
+func TestAnalyticsHandler_Submit_StoresCompletionTime(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Funnel",
+		Status: models.FormStatusPublished,
+		Fields: models.FormFields{{ID: "field1", Label: "Name", Type: "text"}},
+	}
+	db.Create(form)
+
+	handler := NewSubmissionHandler(nil, nil, nil)
+	router := gin.New()
+	router.POST("/public/forms/:id/submit", handler.Submit)
+
+	tests := []struct {
+		name      string
+		startedAt time.Time
+		want      *int
+	}{
+		{"recent start", time.Now().Add(-90 * time.Second), intPtr(90)},
+		{"future start", time.Now().Add(time.Hour), nil},
+		{"stale start", time.Now().Add(-48 * time.Hour), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			startedAt := tt.startedAt
+			jsonBody, _ := json.Marshal(SubmitRequest{
+				Data:      map[string]interface{}{"field1": tt.name},
+				StartedAt: &startedAt,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/public/forms/"+form.ID+"/submit", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+			}
+
+			var submission models.Submission
+			db.Where("form_id = ? AND json_extract(data, '$.field1') = ?", form.ID, tt.name).First(&submission)
+			switch {
+			case tt.want == nil && submission.CompletionSeconds != nil:
+				t.Errorf("expected no completion time, got %d", *submission.CompletionSeconds)
+			case tt.want != nil && (submission.CompletionSeconds == nil || *submission.CompletionSeconds != *tt.want):
+				t.Errorf("expected completion time %d, got %v", *tt.want, submission.CompletionSeconds)
+			}
+		})
+	}
+}
+
+func TestAnalyticsHandler_Funnel(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{UserID: user.ID, Title: "Funnel", Status: models.FormStatusPublished}
+	db.Create(form)
+
+	db.Create(&models.FormAnalytics{FormID: form.ID, Date: "2025-03-01", Views: 10, Starts: 4})
+	db.Create(&models.FormAnalytics{FormID: form.ID, Date: "2025-03-03", Views: 6, Starts: 4})
+	db.Create(&models.FormAnalytics{FormID: form.ID, Date: "2025-03-10", Views: 99, Starts: 99}) // Out of range
+
+	submissions := []struct {
+		createdAt string
+		seconds   *int
+	}{
+		{"2025-03-01T09:00:00Z", intPtr(30)},
+		{"2025-03-01T23:30:00Z", intPtr(90)},
+		{"2025-03-03T12:00:00Z", intPtr(60)},
+		{"2025-03-03T13:00:00Z", nil},
+		{"2025-03-10T12:00:00Z", intPtr(1000)}, // Out of range
+	}
+	for _, s := range submissions {
+		createdAt, _ := time.Parse(time.RFC3339, s.createdAt)
+		db.Create(&models.Submission{
+			FormID:            form.ID,
+			Data:              models.SubmissionData{},
+			CompletionSeconds: s.seconds,
+			CreatedAt:         createdAt,
+		})
+	}
+
+	code, funnel := getFunnel(t, user.ID, form.ID, "?from=2025-03-01&to=2025-03-03")
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+
+	if funnel.Views != 16 || funnel.Starts != 8 || funnel.Submissions != 4 {
+		t.Errorf("unexpected totals: %d views, %d starts, %d submissions", funnel.Views, funnel.Starts, funnel.Submissions)
+	}
+	if funnel.StartRate == nil || *funnel.StartRate != 0.5 {
+		t.Errorf("expected start rate 0.5, got %v", funnel.StartRate)
+	}
+	if funnel.ConversionRate == nil || *funnel.ConversionRate != 0.25 {
+		t.Errorf("expected conversion rate 0.25, got %v", funnel.ConversionRate)
+	}
+	if funnel.CompletionRate == nil || *funnel.CompletionRate != 0.5 {
+		t.Errorf("expected completion rate 0.5, got %v", funnel.CompletionRate)
+	}
+	if funnel.MedianCompletionSeconds == nil || *funnel.MedianCompletionSeconds != 60 {
+		t.Errorf("expected median 60, got %v", funnel.MedianCompletionSeconds)
+	}
+
+	want := []FunnelDay{
+		{Date: "2025-03-01", Views: 10, Starts: 4, Submissions: 2},
+		{Date: "2025-03-02"},
+		{Date: "2025-03-03", Views: 6, Starts: 4, Submissions: 2},
+	}
+	if len(funnel.Daily) != len(want) {
+		t.Fatalf("expected %d days, got %+v", len(want), funnel.Daily)
+	}
+	for i := range want {
+		if funnel.Daily[i] != want[i] {
+			t.Errorf("day %d: expected %+v, got %+v", i, want[i], funnel.Daily[i])
+		}
+	}
+
+	// Without data, rates and median are null
+	code, funnel = getFunnel(t, user.ID, form.ID, "?from=2025-02-01&to=2025-02-02")
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if funnel.StartRate != nil || funnel.ConversionRate != nil || funnel.MedianCompletionSeconds != nil {
+		t.Errorf("expected null rates without data, got %+v", funnel)
+	}
+
+	// Defaults to the last 30 days
+	_, funnel = getFunnel(t, user.ID, form.ID, "")
+	if len(funnel.Daily) != defaultAnalyticsDays {
+		t.Errorf("expected %d days by default, got %d", defaultAnalyticsDays, len(funnel.Daily))
+	}
+
+	if code, _ := getFunnel(t, user.ID, form.ID, "?from=nope"); code != http.StatusBadRequest {
+		t.Errorf("expected status %d for invalid date, got %d", http.StatusBadRequest, code)
+	}
+	if code, _ := getFunnel(t, other.ID, form.ID, ""); code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, code)
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"formera/internal/database"
+	"formera/internal/export"
+	"formera/internal/logger"
+	"formera/internal/models"
+	"formera/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Largest ZIP export streamed directly, by submissions and by the size of the
+// included uploads; larger exports run as a background job
+const (
+	maxSyncArchiveSubmissions = 1000
+	maxSyncArchiveUploadSize  = 100 << 20
+)
+
+// ExportHandler serves ZIP exports including uploads and background exports
+type ExportHandler struct {
+	runner  *export.Runner
+	storage storage.Storage
+}
+
+// NewExportHandler creates a new export handler. Uploads are read from store,
+// background exports are generated by runner.
+func NewExportHandler(runner *export.Runner, store storage.Storage) *ExportHandler {
+	return &ExportHandler{runner: runner, storage: store}
+}
+
+// Archive godoc
+// @Summary      Export submissions with uploads
+// @Description  Download a ZIP archive with the submissions as CSV or JSON and every uploaded file in one folder per submission.
+// @Description  Exports of more than 1000 submissions or 100 MB of uploads, or with async=true, run in the background: the response is 202 with the export, which can be downloaded once it succeeded.
+// @Tags         Exports
+// @Produce      application/zip
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        format query string false "Data file format: csv or json" default(csv)
+// @Param        async query bool false "Always export in the background" default(false)
+// @Param        delimiter query string false "comma, semicolon, tab or pipe" default(comma)
+// @Param        bom query bool false "Prefix a UTF-8 byte order mark for Excel" default(false)
+// @Param        separator query string false "Separator of multi-value answers" default(; )
+// @Param        headers query string false "labels or ids" default(labels)
+// @Param        metadata query bool false "Include IP, user agent, referrer and completion time" default(false)
+// @Param        utm query bool false "Include UTM parameters" default(false)
+// @Param        columns query string false "Comma-separated column keys; uploads are limited to the selected fields"
+// @Param        from query string false "Start date (YYYY-MM-DD or RFC 3339)"
+// @Param        to query string false "End date, inclusive for YYYY-MM-DD"
+// @Param        last query string false "Relative range ending now, such as 24h, 7d or 4w"
+// @Param        tz query string false "IANA time zone of dates" default(UTC)
+// @Param        filter[field_id] query string false "Only submissions whose answer to the field equals or contains the value"
+// @Param        preset query string false "Export preset ID whose parameters apply unless given explicitly"
+// @Success      200 {file} file "ZIP archive"
+// @Success      202 {object} models.Export
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/export/zip [get]
+func (h *ExportHandler) Archive(c *gin.Context) {
+	userID := c.GetString("user_id")
+	formID := c.Param("id")
+
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	if !applyExportPreset(c, &form) {
+		return
+	}
+	if !withFieldHistory(c, &form) {
+		return
+	}
+
+	opts, msg := parseArchiveOptions(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	async, ok := queryBool(c, "async")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid async (expected true or false)"})
+		return
+	}
+
+	query, filter, msg := exportQuery(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if !async {
+		var count int64
+		if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count submissions"})
+			return
+		}
+		async = count > maxSyncArchiveSubmissions
+	}
+	if !async {
+		size, err := export.EstimateUploadSize(&form, query, opts)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to estimate export size"})
+			return
+		}
+		async = size > maxSyncArchiveUploadSize
+	}
+
+	if async {
+		exp, err := h.runner.Enqueue(&form, userID, opts, filter)
+		if err != nil {
+			logger.Error().Err(err).Str("form_id", formID).Msg("Failed to queue export")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue export"})
+			return
+		}
+		c.Header("Location", fmt.Sprintf("/api/forms/%s/exports/%s", formID, exp.ID))
+		c.JSON(http.StatusAccepted, exp)
+		return
+	}
+
+	// Copying the uploads outlasts the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-submissions.zip", form.ID))
+
+	if _, err := export.WriteArchive(c.Writer, &form, query, h.storage, opts); err != nil {
+		// Headers are already sent, so the truncated download is all the client sees
+		logger.Error().Err(err).Str("form_id", formID).Msg("ZIP export failed")
+	}
+}
+
+// List godoc
+// @Summary      List background exports
+// @Description  Get the background exports of a form, newest first
+// @Tags         Exports
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Success      200 {array} models.Export
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/exports [get]
+func (h *ExportHandler) List(c *gin.Context) {
+	formID := c.Param("id")
+
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	var exports []models.Export
+	if result := database.DB.Where("form_id = ?", formID).Order("created_at DESC").Find(&exports); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch exports"})
+		return
+	}
+	for i := range exports {
+		setDownloadURL(&exports[i])
+	}
+
+	c.JSON(http.StatusOK, exports)
+}
+
+// Get godoc
+// @Summary      Get background export
+// @Description  Get the status of a background export, including the download link once it succeeded
+// @Tags         Exports
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        exportId path string true "Export ID"
+// @Success      200 {object} models.Export
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/exports/{exportId} [get]
+func (h *ExportHandler) Get(c *gin.Context) {
+	exp, ok := h.findExport(c)
+	if !ok {
+		return
+	}
+	setDownloadURL(exp)
+	c.JSON(http.StatusOK, exp)
+}
+
+// Download godoc
+// @Summary      Download background export
+// @Description  Download the file of a finished background export
+// @Tags         Exports
+// @Produce      application/zip
+// @Param        id path string true "Form ID"
+// @Param        exportId path string true "Export ID"
+// @Success      200 {file} file "ZIP archive"
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse "Export not finished yet"
+// @Failure      410 {object} ErrorResponse "Export expired"
+// @Security     BearerAuth
+// @Router       /forms/{id}/exports/{exportId}/download [get]
+func (h *ExportHandler) Download(c *gin.Context) {
+	exp, ok := h.findExport(c)
+	if !ok {
+		return
+	}
+
+	switch exp.Status {
+	case models.ExportStatusSucceeded:
+	case models.ExportStatusExpired:
+		c.JSON(http.StatusGone, gin.H{"error": "Export has expired"})
+		return
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": "Export is not ready"})
+		return
+	}
+
+	content, err := h.storage.GetFileByPath(exp.Path)
+	if err != nil {
+		c.JSON(http.StatusGone, gin.H{"error": "Export has expired"})
+		return
+	}
+	defer content.Reader.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", exp.Filename))
+	c.Header("Content-Type", "application/zip")
+	if content.Size > 0 {
+		c.Header("Content-Length", fmt.Sprint(content.Size))
+	}
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, content.Reader); err != nil {
+		logger.Error().Err(err).Str("export_id", exp.ID).Msg("Export download failed")
+	}
+}
+
+// findExport loads an export of a form the current user can view, or responds with 404
+func (h *ExportHandler) findExport(c *gin.Context) (*models.Export, bool) {
+	formID := c.Param("id")
+
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return nil, false
+	}
+
+	var exp models.Export
+	if result := database.DB.Where("id = ? AND form_id = ?", c.Param("exportId"), formID).First(&exp); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return nil, false
+	}
+	return &exp, true
+}
+
+// setDownloadURL links finished exports to their download endpoint
+func setDownloadURL(exp *models.Export) {
+	if exp.Status == models.ExportStatusSucceeded {
+		exp.DownloadURL = fmt.Sprintf("/api/forms/%s/exports/%s/download", exp.FormID, exp.ID)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"formera/internal/export"
+	"formera/internal/jobs"
+	"formera/internal/models"
+	"formera/internal/storage"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExportHandler_Archive(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	store, _ := storage.NewLocalStorage(t.TempDir(), "http://localhost/uploads")
+	upload, err := store.Upload("photo.png", "image/png", 3, strings.NewReader("png"))
+	if err != nil {
+		t.Fatalf("failed to upload fixture: %v", err)
+	}
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Export",
+		Fields: models.FormFields{{ID: "photo", Type: models.FieldTypeFile, Label: "Photo"}},
+	}
+	db.Create(form)
+	sub := &models.Submission{FormID: form.ID, Data: models.SubmissionData{"photo": []interface{}{upload.Path}}}
+	db.Create(sub)
+
+	queue := jobs.New(db, jobs.Config{})
+	runner := export.NewRunner(db, store)
+	runner.Register(queue)
+	handler := NewExportHandler(runner, store)
+
+	serve := func(userID, url string) *httptest.ResponseRecorder {
+		router := gin.New()
+		setUser := func(c *gin.Context) { c.Set("user_id", userID) }
+		router.GET("/forms/:id/export/zip", setUser, handler.Archive)
+		router.GET("/forms/:id/exports", setUser, handler.List)
+		router.GET("/forms/:id/exports/:exportId", setUser, handler.Get)
+		router.GET("/forms/:id/exports/:exportId/download", setUser, handler.Download)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+	zipEntries := func(w *httptest.ResponseRecorder) []string {
+		t.Helper()
+		zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		if err != nil {
+			t.Fatalf("invalid ZIP: %v", err)
+		}
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	// Small exports are streamed directly
+	w := serve(user.ID, "/forms/"+form.ID+"/export/zip?format=json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	names := zipEntries(w)
+	if len(names) != 2 || names[0] != "submissions.json" || !strings.HasPrefix(names[1], sub.ID+"/") {
+		t.Errorf("unexpected entries %v", names)
+	}
+
+	// Background export
+	w = serve(user.ID, "/forms/"+form.ID+"/export/zip?async=true")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusAccepted, w.Code, w.Body.String())
+	}
+	var exp models.Export
+	json.Unmarshal(w.Body.Bytes(), &exp)
+	if exp.Status != models.ExportStatusPending || w.Header().Get("Location") != "/api/forms/"+form.ID+"/exports/"+exp.ID {
+		t.Errorf("unexpected export %+v", exp)
+	}
+	if w := serve(user.ID, "/forms/"+form.ID+"/exports/"+exp.ID+"/download"); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d before the export finished, got %d", http.StatusConflict, w.Code)
+	}
+
+	queue.RunDue()
+
+	w = serve(user.ID, "/forms/"+form.ID+"/exports/"+exp.ID)
+	json.Unmarshal(w.Body.Bytes(), &exp)
+	if exp.Status != models.ExportStatusSucceeded || exp.DownloadURL != "/api/forms/"+form.ID+"/exports/"+exp.ID+"/download" {
+		t.Fatalf("unexpected export %+v", exp)
+	}
+	w = serve(user.ID, "/forms/"+form.ID+"/exports/"+exp.ID+"/download")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if names := zipEntries(w); len(names) != 2 || names[0] != "submissions.csv" {
+		t.Errorf("unexpected entries %v", names)
+	}
+
+	var list []models.Export
+	json.Unmarshal(serve(user.ID, "/forms/"+form.ID+"/exports").Body.Bytes(), &list)
+	if len(list) != 1 || list[0].ID != exp.ID {
+		t.Errorf("expected the export in the list, got %+v", list)
+	}
+
+	db.Model(&models.Export{}).Where("id = ?", exp.ID).Update("status", models.ExportStatusExpired)
+	if w := serve(user.ID, "/forms/"+form.ID+"/exports/"+exp.ID+"/download"); w.Code != http.StatusGone {
+		t.Errorf("expected status %d for an expired export, got %d", http.StatusGone, w.Code)
+	}
+
+	for _, query := range []string{"?format=xml", "?async=maybe", "?delimiter=x"} {
+		if w := serve(user.ID, "/forms/"+form.ID+"/export/zip"+query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+	for _, url := range []string{
+		"/forms/" + form.ID + "/export/zip",
+		"/forms/" + form.ID + "/exports",
+		"/forms/" + form.ID + "/exports/" + exp.ID,
+		"/forms/" + form.ID + "/exports/" + exp.ID + "/download",
+	} {
+		if w := serve(other.ID, url); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d for other user, got %d", url, http.StatusNotFound, w.Code)
+		}
+	}
+
+	// Few submissions with large uploads run in the background as well
+	db.Create(&storage.FileRecord{ID: "large", Path: "files/2025/01/large.zip", Filename: "large.zip", Size: maxSyncArchiveUploadSize + 1})
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"photo": []interface{}{"files/2025/01/large.zip"}}})
+	if w := serve(user.ID, "/forms/"+form.ID+"/export/zip"); w.Code != http.StatusAccepted {
+		t.Errorf("expected status %d for large uploads, got %d", http.StatusAccepted, w.Code)
+	}
+	if w := serve(user.ID, "/forms/"+form.ID+"/export/zip?columns=submitted_at"); w.Code != http.StatusOK {
+		t.Errorf("expected status %d without upload columns, got %d", http.StatusOK, w.Code)
+	}
+}
@@ -0,0 +1,333 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"formera/internal/database"
+	"formera/internal/logger"
+	"formera/internal/models"
+	"formera/internal/privacy"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Attribution dimensions
+const (
+	DimensionSource   = "source"
+	DimensionMedium   = "medium"
+	DimensionCampaign = "campaign"
+	DimensionReferrer = "referrer"
+)
+
+var attributionDimensions = []string{DimensionSource, DimensionMedium, DimensionCampaign, DimensionReferrer}
+
+// maxAttributionValue limits the length of tracked values, which come from public URLs
+const maxAttributionValue = 200
+
+// AttributionRow holds the counts of one traffic source. Dimensions that are not
+// grouped by are omitted, missing values are reported as "(none)".
+type AttributionRow struct {
+	Source         string   `json:"source,omitempty" example:"newsletter"`
+	Medium         string   `json:"medium,omitempty" example:"email"`
+	Campaign       string   `json:"campaign,omitempty" example:"spring-sale"`
+	ReferrerDomain string   `json:"referrer_domain,omitempty" example:"google.com"`
+	Views          int      `json:"views" example:"200"`
+	Submissions    int      `json:"submissions" example:"24"`
+	ConversionRate *float64 `json:"conversion_rate" example:"0.12"` // null without tracked views
+}
+
+// AttributionResponse represents submissions grouped by traffic source
+type AttributionResponse struct {
+	From           string           `json:"from" example:"2025-01-01"`
+	To             string           `json:"to" example:"2025-01-31"` // Inclusive
+	GroupBy        []string         `json:"group_by" example:"source,medium"`
+	Views          int              `json:"views" example:"1200"`
+	Submissions    int              `json:"submissions" example:"96"`
+	ConversionRate *float64         `json:"conversion_rate" example:"0.08"`
+	Rows           []AttributionRow `json:"rows"`
+}
+
+// attributionKey identifies a traffic source
+type attributionKey struct {
+	Source, Medium, Campaign, ReferrerDomain string
+}
+
+// Attribution godoc
+// @Summary      Get submission attribution
+// @Description  Group submissions by UTM source, medium, campaign and referrer domain per UTC day
+// @Description  range, with conversion against the views tracked for the same source.
+// @Description  Defaults to the last 30 days. Referrals from the app itself count as direct traffic.
+// @Tags         Submissions
+// @Produce      json
+// @Produce      text/csv
+// @Param        id path string true "Form ID"
+// @Param        from query string false "Start date (YYYY-MM-DD)"
+// @Param        to query string false "End date, inclusive (YYYY-MM-DD)"
+// @Param        group_by query string false "Comma-separated dimensions: source, medium, campaign, referrer" default(source,medium,campaign,referrer)
+// @Param        format query string false "json or csv" default(json)
+// @Success      200 {object} AttributionResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/attribution [get]
+func (h *AnalyticsHandler) Attribution(c *gin.Context) {
+	formID := c.Param("id")
+
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	groupBy, ok := parseAttributionDimensions(c.Query("group_by"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_by (expected source, medium, campaign or referrer)"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format (expected json or csv)"})
+		return
+	}
+
+	r, msg := parseTimeRange(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	r = utcDayRange(r)
+
+	rows, err := h.attributionRows(formID, r, groupBy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute attribution"})
+		return
+	}
+
+	response := AttributionResponse{
+		From:    r.From.Format("2006-01-02"),
+		To:      r.To.AddDate(0, 0, -1).Format("2006-01-02"),
+		GroupBy: groupBy,
+		Rows:    rows,
+	}
+	for _, row := range rows {
+		response.Views += row.Views
+		response.Submissions += row.Submissions
+	}
+	response.ConversionRate = ratio(response.Submissions, response.Views)
+
+	if format == "csv" {
+		writeAttributionCSV(c, &form, &response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// attributionRows counts submissions and tracked views per traffic source
+func (h *AnalyticsHandler) attributionRows(formID string, r *timeRange, groupBy []string) ([]AttributionRow, error) {
+	filter, filterArgs := r.sqlFilter()
+
+	var submissions []struct {
+		Source, Medium, Campaign, Referrer string
+		Count                              int
+	}
+	if err := database.DB.Model(&models.Submission{}).
+		Select("COALESCE(json_extract(metadata, '$.utm_source'), '') AS source, "+
+			"COALESCE(json_extract(metadata, '$.utm_medium'), '') AS medium, "+
+			"COALESCE(json_extract(metadata, '$.utm_campaign'), '') AS campaign, "+
+			"COALESCE(json_extract(metadata, '$.referrer'), '') AS referrer, "+
+			"COUNT(*) AS count").
+		Where("form_id = ?", formID).
+		Where(filter, filterArgs...).
+		Group("source, medium, campaign, referrer").
+		Scan(&submissions).Error; err != nil {
+		return nil, err
+	}
+
+	var views []struct {
+		Source, Medium, Campaign, ReferrerDomain string
+		Views                                    int
+	}
+	if err := database.DB.Model(&models.AttributionView{}).
+		Select("source, medium, campaign, referrer_domain, SUM(views) AS views").
+		Where("form_id = ? AND date >= ? AND date < ?",
+			formID, r.From.Format("2006-01-02"), r.To.Format("2006-01-02")).
+		Group("source, medium, campaign, referrer_domain").
+		Scan(&views).Error; err != nil {
+		return nil, err
+	}
+
+	grouped := make(map[attributionKey]*AttributionRow)
+	row := func(k attributionKey) *AttributionRow {
+		k = k.project(groupBy)
+		if grouped[k] == nil {
+			grouped[k] = &AttributionRow{
+				Source:         k.Source,
+				Medium:         k.Medium,
+				Campaign:       k.Campaign,
+				ReferrerDomain: k.ReferrerDomain,
+			}
+		}
+		return grouped[k]
+	}
+	for _, s := range submissions {
+		domain := referrerDomain(s.Referrer)
+		if domain == h.appHost {
+			domain = ""
+		}
+		row(attributionKey{s.Source, s.Medium, s.Campaign, domain}).Submissions += s.Count
+	}
+	for _, v := range views {
+		row(attributionKey{v.Source, v.Medium, v.Campaign, v.ReferrerDomain}).Views += v.Views
+	}
+
+	rows := make([]AttributionRow, 0, len(grouped))
+	for _, r := range grouped {
+		r.ConversionRate = ratio(r.Submissions, r.Views)
+		rows = append(rows, *r)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Submissions != b.Submissions {
+			return a.Submissions > b.Submissions
+		}
+		if a.Views != b.Views {
+			return a.Views > b.Views
+		}
+		return attributionKey{a.Source, a.Medium, a.Campaign, a.ReferrerDomain}.String() <
+			attributionKey{b.Source, b.Medium, b.Campaign, b.ReferrerDomain}.String()
+	})
+	return rows, nil
+}
+
+// project keeps the grouped dimensions, labelling missing values, and clears the others
+func (k attributionKey) project(groupBy []string) attributionKey {
+	var p attributionKey
+	for _, dim := range groupBy {
+		switch dim {
+		case DimensionSource:
+			p.Source = labelAnswer(k.Source)
+		case DimensionMedium:
+			p.Medium = labelAnswer(k.Medium)
+		case DimensionCampaign:
+			p.Campaign = labelAnswer(k.Campaign)
+		case DimensionReferrer:
+			p.ReferrerDomain = labelAnswer(k.ReferrerDomain)
+		}
+	}
+	return p
+}
+
+func (k attributionKey) String() string {
+	return k.Source + "\x00" + k.Medium + "\x00" + k.Campaign + "\x00" + k.ReferrerDomain
+}
+
+// parseAttributionDimensions parses the group_by parameter, defaulting to all dimensions
+func parseAttributionDimensions(value string) ([]string, bool) {
+	if value == "" {
+		return attributionDimensions, true
+	}
+	seen := make(map[string]bool)
+	var dims []string
+	for _, dim := range strings.Split(value, ",") {
+		dim = strings.TrimSpace(dim)
+		switch dim {
+		case DimensionSource, DimensionMedium, DimensionCampaign, DimensionReferrer:
+		default:
+			return nil, false
+		}
+		if !seen[dim] {
+			seen[dim] = true
+			dims = append(dims, dim)
+		}
+	}
+	return dims, true
+}
+
+func writeAttributionCSV(c *gin.Context, form *models.Form, response *AttributionResponse) {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-attribution-%s-%s.csv", form.ID, response.From, response.To))
+
+	writer := csv.NewWriter(c.Writer)
+	defer writer.Flush()
+
+	headers := append([]string{}, response.GroupBy...)
+	_ = writer.Write(append(headers, "views", "submissions", "conversion_rate"))
+
+	for _, row := range response.Rows {
+		var record []string
+		for _, dim := range response.GroupBy {
+			switch dim {
+			case DimensionSource:
+				record = append(record, row.Source)
+			case DimensionMedium:
+				record = append(record, row.Medium)
+			case DimensionCampaign:
+				record = append(record, row.Campaign)
+			case DimensionReferrer:
+				record = append(record, row.ReferrerDomain)
+			}
+		}
+		rate := ""
+		if row.ConversionRate != nil {
+			rate = strconv.FormatFloat(*row.ConversionRate, 'f', -1, 64)
+		}
+		_ = writer.Write(append(record, strconv.Itoa(row.Views), strconv.Itoa(row.Submissions), rate))
+	}
+}
+
+// referrerDomain returns the lower-cased host of a referrer URL without "www.",
+// or an empty string if it is not an absolute http(s) URL
+func referrerDomain(referrer string) string {
+	u, err := url.Parse(strings.TrimSpace(referrer))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// recordAttributionView counts a view of a public form for the traffic source in
+// the utm_source, utm_medium, utm_campaign and referrer query parameters
+func recordAttributionView(c *gin.Context, form *models.Form) {
+	row := models.AttributionView{
+		FormID:   form.ID,
+		Date:     time.Now().UTC().Format("2006-01-02"),
+		Source:   truncateAttribution(c.Query("utm_source")),
+		Medium:   truncateAttribution(c.Query("utm_medium")),
+		Campaign: truncateAttribution(c.Query("utm_campaign")),
+		Views:    1,
+	}
+
+	// Referrers are only kept where submissions would keep them
+	var settings models.Settings
+	database.DB.First(&settings)
+	if privacy.Resolve(&settings, &form.Settings).CollectReferrer {
+		row.ReferrerDomain = truncateAttribution(referrerDomain(c.Query("referrer")))
+	}
+
+	if err := database.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "form_id"}, {Name: "date"}, {Name: "source"},
+			{Name: "medium"}, {Name: "campaign"}, {Name: "referrer_domain"},
+		},
+		DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("views + 1")}),
+	}).Create(&row).Error; err != nil {
+		logger.Warn().Err(err).Str("form_id", form.ID).Msg("Failed to record attribution view")
+	}
+}
+
+func truncateAttribution(s string) string {
+	if len(s) > maxAttributionValue {
+		return strings.ToValidUTF8(s[:maxAttributionValue], "")
+	}
+	return s
+}
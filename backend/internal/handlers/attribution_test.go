@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func getAttribution(t *testing.T, userID, formID, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	handler := NewAnalyticsHandler("https://forms.example.com")
+	router := gin.New()
+	router.GET("/forms/:id/attribution", func(c *gin.Context) {
+		c.Set("user_id", userID)
+		handler.Attribution(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/forms/"+formID+"/attribution"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestReferrerDomain(t *testing.T) {
+	tests := map[string]string{
+		"https://www.Google.com/search?q=x": "google.com",
+		"http://news.ycombinator.com/":      "news.ycombinator.com",
+		"android-app://com.slack":           "",
+		"not a url":                         "",
+		"":                                  "",
+	}
+	for in, want := range tests {
+		if got := referrerDomain(in); got != want {
+			t.Errorf("referrerDomain(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAnalyticsHandler_Attribution_TracksViewsAndSubmissions(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Attribution",
+		Status: models.FormStatusPublished,
+		Fields: models.FormFields{{ID: "field1", Label: "Name", Type: "text"}},
+	}
+	db.Create(form)
+
+	formHandler := NewFormHandler(nil)
+	submissionHandler := NewSubmissionHandler(nil, nil, nil)
+	router := gin.New()
+	router.GET("/public/forms/:id", formHandler.GetPublic)
+	router.POST("/public/forms/:id/submit", submissionHandler.Submit)
+
+	tracking := url.Values{
+		"utm_source":   {"newsletter"},
+		"utm_medium":   {"email"},
+		"utm_campaign": {"spring"},
+		"referrer":     {"https://www.mail.example.org/inbox"},
+	}
+	for i := 0; i < 4; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/public/forms/"+form.ID+"?"+tracking.Encode(), nil))
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/public/forms/"+form.ID, nil))
+
+	jsonBody, _ := json.Marshal(SubmitRequest{
+		Data: map[string]interface{}{"field1": "Ada"},
+		Metadata: map[string]string{
+			"utm_source":   "newsletter",
+			"utm_medium":   "email",
+			"utm_campaign": "spring",
+			"referrer":     "https://www.mail.example.org/inbox",
+		},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/public/forms/"+form.ID+"/submit", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Referer", "https://forms.example.com/f/"+form.ID)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var submission models.Submission
+	db.Where("form_id = ?", form.ID).First(&submission)
+	if submission.Metadata.Referrer != "https://www.mail.example.org/inbox" || submission.Metadata.Tracking["referrer"] != "" {
+		t.Errorf("expected client referrer in metadata, got %+v", submission.Metadata)
+	}
+
+	w = getAttribution(t, user.ID, form.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response AttributionResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	want := []AttributionRow{
+		{Source: "newsletter", Medium: "email", Campaign: "spring", ReferrerDomain: "mail.example.org", Views: 4, Submissions: 1},
+		{Source: "(none)", Medium: "(none)", Campaign: "(none)", ReferrerDomain: "(none)", Views: 1},
+	}
+	if len(response.Rows) != len(want) {
+		t.Fatalf("expected %d rows, got %+v", len(want), response.Rows)
+	}
+	for i, row := range response.Rows {
+		row.ConversionRate = nil
+		if row != want[i] {
+			t.Errorf("row %d: expected %+v, got %+v", i, want[i], row)
+		}
+	}
+	if rate := response.Rows[0].ConversionRate; rate == nil || *rate != 0.25 {
+		t.Errorf("expected conversion rate 0.25, got %v", rate)
+	}
+	if response.Views != 5 || response.Submissions != 1 {
+		t.Errorf("expected 5 views and 1 submission, got %d and %d", response.Views, response.Submissions)
+	}
+}
+
+func TestAnalyticsHandler_Attribution_GroupByAndCSV(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{UserID: user.ID, Title: "Attribution", Status: models.FormStatusPublished}
+	db.Create(form)
+
+	day := time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC)
+	metas := []models.SubmissionMetadata{
+		{UTMSource: "google", UTMMedium: "cpc", UTMCampaign: "a"},
+		{UTMSource: "google", UTMMedium: "cpc", UTMCampaign: "b"},
+		{UTMSource: "google", UTMMedium: "organic"},
+		{Referrer: "https://forms.example.com/f/" + form.ID}, // The app itself counts as direct
+	}
+	for _, meta := range metas {
+		db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{}, Metadata: meta, CreatedAt: day})
+	}
+	db.Create(&models.Submission{
+		FormID:    form.ID,
+		Data:      models.SubmissionData{},
+		Metadata:  models.SubmissionMetadata{UTMSource: "google"},
+		CreatedAt: day.AddDate(0, 1, 0), // Out of range
+	})
+	db.Create(&models.AttributionView{FormID: form.ID, Date: "2025-03-02", Source: "google", Medium: "cpc", Campaign: "a", Views: 10})
+	db.Create(&models.AttributionView{FormID: form.ID, Date: "2025-03-02", Source: "google", Medium: "cpc", Campaign: "b", Views: 10})
+
+	w := getAttribution(t, user.ID, form.ID, "?from=2025-03-01&to=2025-03-31&group_by=source,medium")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var response AttributionResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+
+	if response.From != "2025-03-01" || response.To != "2025-03-31" {
+		t.Errorf("unexpected range %s - %s", response.From, response.To)
+	}
+	type row struct {
+		source, medium string
+		views, subs    int
+	}
+	want := []row{{"google", "cpc", 20, 2}, {"(none)", "(none)", 0, 1}, {"google", "organic", 0, 1}}
+	if len(response.Rows) != len(want) {
+		t.Fatalf("expected %d rows, got %+v", len(want), response.Rows)
+	}
+	for i, r := range response.Rows {
+		got := row{r.Source, r.Medium, r.Views, r.Submissions}
+		if got != want[i] || r.Campaign != "" || r.ReferrerDomain != "" {
+			t.Errorf("row %d: expected %+v, got %+v", i, want[i], r)
+		}
+	}
+	if response.Rows[1].ConversionRate != nil {
+		t.Error("expected null conversion rate without tracked views")
+	}
+
+	w = getAttribution(t, user.ID, form.ID, "?from=2025-03-01&to=2025-03-31&group_by=source&format=csv")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("expected text/csv, got %s", ct)
+	}
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	wantCSV := [][]string{
+		{"source", "views", "submissions", "conversion_rate"},
+		{"google", "20", "3", "0.15"},
+		{"(none)", "0", "1", ""},
+	}
+	if len(records) != len(wantCSV) {
+		t.Fatalf("expected %d records, got %v", len(wantCSV), records)
+	}
+	for i := range wantCSV {
+		for j := range wantCSV[i] {
+			if records[i][j] != wantCSV[i][j] {
+				t.Errorf("record %d: expected %v, got %v", i, wantCSV[i], records[i])
+				break
+			}
+		}
+	}
+
+	for _, query := range []string{"?group_by=country", "?format=xml", "?from=nope"} {
+		if w := getAttribution(t, user.ID, form.ID, query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+	if w := getAttribution(t, other.ID, form.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, w.Code)
+	}
+}
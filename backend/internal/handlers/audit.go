@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"formera/internal/database"
+	"formera/internal/models"
+	"formera/internal/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct{}
+
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{}
+}
+
+// List godoc
+// @Summary      List audit log
+// @Description  Get paginated audit log entries, newest first (admin only)
+// @Tags         Audit
+// @Produce      json
+// @Param        action query string false "Filter by action"
+// @Param        page query int false "Page number" default(1)
+// @Param        page_size query int false "Items per page" default(20)
+// @Success      200 {object} pagination.Result
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse "Admin access required"
+// @Security     BearerAuth
+// @Router       /audit-logs [get]
+func (h *AuditHandler) List(c *gin.Context) {
+	params := pagination.GetParams(c)
+
+	query := database.DB.Model(&models.AuditLog{})
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	var totalItems int64
+	query.Count(&totalItems)
+
+	var entries []models.AuditLog
+	if result := query.Order("created_at DESC").
+		Scopes(pagination.Paginate(params)).
+		Find(&entries); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.CreateResult(entries, params, totalItems))
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"formera/internal/bundle"
+	"formera/internal/database"
+	"formera/internal/logger"
+	"formera/internal/models"
+	"formera/internal/sanitizer"
+	"formera/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BundleHandler exports and imports form definitions with their images
+type BundleHandler struct {
+	storage storage.Storage
+}
+
+// NewBundleHandler creates a new form bundle handler
+func NewBundleHandler(store storage.Storage) *BundleHandler {
+	return &BundleHandler{storage: store}
+}
+
+// Export godoc
+// @Summary      Export form definition
+// @Description  Download the fields, settings and design of a form with its background image, image fields and images embedded in HTML content, to import it on another instance. JSON bundles embed the images base64 encoded, ZIP bundles contain form.json and an assets folder. Submissions, the slug and the password are not exported.
+// @Tags         Forms
+// @Produce      json
+// @Produce      application/zip
+// @Param        id path string true "Form ID"
+// @Param        format query string false "json or zip" default(json)
+// @Success      200 {file} file
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/bundle [get]
+func (h *BundleHandler) Export(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format (expected json or zip)"})
+		return
+	}
+
+	b, err := bundle.Build(&form, h.storage)
+	if err != nil {
+		logger.Error().Err(err).Str("form_id", form.ID).Msg("Failed to bundle form")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export form"})
+		return
+	}
+
+	// Written to a buffer first, so failures still result in an error response
+	var buf bytes.Buffer
+	contentType := "application/json"
+	if format == "zip" {
+		contentType = "application/zip"
+		err = bundle.WriteZip(&buf, b)
+	} else {
+		err = bundle.WriteJSON(&buf, b)
+	}
+	if err != nil {
+		logger.Error().Err(err).Str("form_id", form.ID).Msg("Failed to write form bundle")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export form"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-form.%s", form.Slug, format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// Import godoc
+// @Summary      Import form definition
+// @Description  Create a draft form from a JSON or ZIP bundle exported by a form. The images of the bundle are uploaded again and the references in the fields and design point to the new files.
+// @Tags         Forms
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file formData file true "Form bundle (.json or .zip)"
+// @Param        format query string false "json or zip, detected from the file name if empty"
+// @Success      201 {object} models.Form
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/import [post]
+func (h *BundleHandler) Import(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	upload, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		return
+	}
+	defer upload.Close()
+	if header.Size > storage.MaxFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File too large. Maximum size: %d MB", storage.MaxFileSize/(1024*1024))})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(path.Ext(header.Filename)), ".")
+	}
+	var b *bundle.Bundle
+	switch format {
+	case "json":
+		b, err = bundle.ReadJSON(upload)
+	case "zip":
+		b, err = bundle.ReadZip(upload, header.Size)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format (expected json or zip)"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bundle: " + err.Error()})
+		return
+	}
+
+	def := &b.Form
+	if strings.TrimSpace(def.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bundle: title is required"})
+		return
+	}
+	if !isValidPrivacy(def.Settings.Privacy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IP storage mode"})
+		return
+	}
+	if msg := validateConfirmation(def.Fields, &def.Settings); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	// Uploads are removed again if the import fails
+	var records []storage.FileRecord
+	cleanup := func() {
+		for _, r := range records {
+			if err := h.storage.Delete(r.ID); err != nil {
+				logger.Warn().Err(err).Str("path", r.Path).Msg("Failed to delete image of failed form import")
+			}
+		}
+	}
+
+	var uploadErr error
+	err = b.Restore(func(a *bundle.Asset) (*storage.UploadResult, error) {
+		if err := storage.ValidateImageUpload(a.ContentType, int64(len(a.Data))); err != nil {
+			return nil, err
+		}
+		if !verifyImageMagicBytes(bytes.NewReader(a.Data), a.ContentType) {
+			return nil, errors.New("content does not match declared type")
+		}
+		result, err := h.storage.Upload(a.Filename, a.ContentType, int64(len(a.Data)), bytes.NewReader(a.Data))
+		if err != nil {
+			uploadErr = err
+			return nil, err
+		}
+		records = append(records, storage.FileRecord{
+			ID:        result.ID,
+			UserID:    userID,
+			Filename:  result.Filename,
+			MimeType:  result.MimeType,
+			Size:      result.Size,
+			Path:      result.Path,
+			URL:       result.URL,
+			CreatedAt: time.Now(),
+		})
+		return result, nil
+	})
+	if uploadErr != nil {
+		cleanup()
+		logger.Error().Err(uploadErr).Msg("Failed to upload image of form import")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Upload failed"})
+		return
+	}
+	if err != nil {
+		cleanup()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bundle: " + err.Error()})
+		return
+	}
+
+	form := &models.Form{
+		UserID:      userID,
+		Title:       sanitizer.StripHTML(def.Title),
+		Description: sanitizer.SanitizeHTML(def.Description),
+		Fields:      def.Fields,
+		Settings:    def.Settings,
+		Status:      models.FormStatusDraft,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(form).Error; err != nil {
+			return err
+		}
+		for i := range records {
+			if err := tx.Create(&records[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		cleanup()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import form"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, form)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"formera/internal/bundle"
+	"formera/internal/models"
+	"formera/internal/storage"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBundleHandler_ExportImport(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	store, _ := storage.NewLocalStorage(t.TempDir(), "http://localhost/uploads")
+	png := []byte("\x89PNG\r\n\x1a\nimage data")
+	uploaded, _ := store.Upload("banner.png", "image/png", int64(len(png)), bytes.NewReader(png))
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Summer party",
+		Slug:   "summer-party",
+		Status: models.FormStatusPublished,
+		Fields: models.FormFields{
+			{ID: "banner", Type: models.FieldTypeImage, ImageURL: uploaded.Path},
+			{ID: "name", Type: models.FieldTypeText, Label: "Name", Required: true},
+		},
+		Settings: models.FormSettings{Design: &models.FormDesign{BackgroundImage: uploaded.URL, PrimaryColor: "#ff0000"}},
+	}
+	db.Create(form)
+
+	handler := NewBundleHandler(store)
+	router := func(userID string) *gin.Engine {
+		r := gin.New()
+		setUser := func(c *gin.Context) { c.Set("user_id", userID) }
+		r.GET("/forms/:id/bundle", setUser, handler.Export)
+		r.POST("/forms/import", setUser, handler.Import)
+		return r
+	}
+	importFile := func(userID, filename string, content []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, _ := mw.CreateFormFile("file", filename)
+		fw.Write(content)
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/forms/import", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		router(userID).ServeHTTP(w, req)
+		return w
+	}
+
+	for _, format := range []string{"json", "zip"} {
+		w := httptest.NewRecorder()
+		router(user.ID).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/forms/"+form.ID+"/bundle?format="+format, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d: %s", format, http.StatusOK, w.Code, w.Body.String())
+		}
+		if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, "summer-party-form."+format) {
+			t.Errorf("%s: unexpected Content-Disposition %q", format, cd)
+		}
+
+		w = importFile(other.ID, "party."+format, w.Body.Bytes())
+		if w.Code != http.StatusCreated {
+			t.Fatalf("%s: expected status %d, got %d: %s", format, http.StatusCreated, w.Code, w.Body.String())
+		}
+		var imported models.Form
+		json.Unmarshal(w.Body.Bytes(), &imported)
+		if imported.ID == form.ID || imported.UserID != other.ID || imported.Status != models.FormStatusDraft || imported.Slug == form.Slug {
+			t.Errorf("%s: expected a new draft form of the importing user, got %+v", format, imported)
+		}
+		if len(imported.Fields) != 2 || imported.Settings.Design == nil || imported.Settings.Design.PrimaryColor != "#ff0000" {
+			t.Fatalf("%s: fields or design not imported: %+v", format, imported)
+		}
+
+		path := imported.Fields[0].ImageURL
+		if path == uploaded.Path || bundle.StoragePath(path) != path {
+			t.Errorf("%s: expected a new storage path for the image field, got %q", format, path)
+		}
+		if bg := imported.Settings.Design.BackgroundImage; bg == uploaded.URL || !strings.HasPrefix(bg, "http://localhost/uploads/images/") {
+			t.Errorf("%s: expected a new URL for the background, got %q", format, bg)
+		}
+		var record storage.FileRecord
+		if err := db.Where("path = ? AND user_id = ?", path, other.ID).First(&record).Error; err != nil {
+			t.Errorf("%s: expected the uploaded image to be tracked: %v", format, err)
+		}
+	}
+
+	// Images are validated like uploads
+	fake := `{"format": "formera-form", "version": 1, "form": {"title": "Fake", "fields": [{"id": "img", "type": "image", "imageUrl": "images/x.png"}]},
+		"assets": [{"ref": "images/x.png", "filename": "x.png", "content_type": "image/png", "data": "PHNjcmlwdD4="}]}`
+	if w := importFile(user.ID, "fake.json", []byte(fake)); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for a disguised image, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+	if w := importFile(user.ID, "form.txt", []byte("{}")); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown format, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w := httptest.NewRecorder()
+	router(other.ID).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/forms/"+form.ID+"/bundle", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, w.Code)
+	}
+}
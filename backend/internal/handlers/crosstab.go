@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"formera/internal/database"
+	"formera/internal/export"
+	"formera/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// noAnswer labels submissions without an answer in breakdowns
+const noAnswer = "(none)"
+
+// CrosstabField identifies a field of a crosstab
+type CrosstabField struct {
+	ID    string `json:"id" example:"field_department"`
+	Label string `json:"label" example:"Department"`
+}
+
+// CrosstabCell holds the submissions giving both a row and a column answer.
+// Percentages are relative to the row total, the column total and all
+// submissions, and 0 if the total is 0.
+type CrosstabCell struct {
+	Count         int     `json:"count" example:"12"`
+	RowPercent    float64 `json:"row_percent" example:"40"`
+	ColumnPercent float64 `json:"column_percent" example:"25.53"`
+	TotalPercent  float64 `json:"total_percent" example:"8"`
+}
+
+// CrosstabResponse is a matrix of answer counts between two fields.
+// Submissions with several answers to a multi-value field count once for each
+// answer, so cells may add up to more than the row or column total.
+type CrosstabResponse struct {
+	Row          CrosstabField    `json:"row"`
+	Column       CrosstabField    `json:"column"`
+	RowValues    []string         `json:"row_values" example:"Sales,Support,(none)"`
+	ColumnValues []string         `json:"column_values" example:"Yes,No"`
+	Cells        [][]CrosstabCell `json:"cells"`         // cells[row][column]
+	RowTotals    []int            `json:"row_totals"`    // Submissions per row answer
+	ColumnTotals []int            `json:"column_totals"` // Submissions per column answer
+	Total        int              `json:"total" example:"150"`
+}
+
+// Crosstab godoc
+// @Summary      Cross-tabulate two fields
+// @Description  Count submissions by the answers to two choice, rating or scale fields.
+// @Description  Options are listed in field order, followed by other answers and "(none)" for unanswered.
+// @Tags         Submissions
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        row query string true "Field ID of the rows"
+// @Param        column query string true "Field ID of the columns"
+// @Param        from query string false "Start date (YYYY-MM-DD or RFC 3339)"
+// @Param        to query string false "End date, inclusive for YYYY-MM-DD"
+// @Param        tz query string false "IANA time zone of dates" default(UTC)
+// @Param        filter[field_id] query string false "Only count submissions whose answer to the field equals or contains the value"
+// @Success      200 {object} CrosstabResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/crosstab [get]
+func (h *SubmissionHandler) Crosstab(c *gin.Context) {
+	formID := c.Param("id")
+
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	rowField := form.Fields.Find(c.Query("row"))
+	columnField := form.Fields.Find(c.Query("column"))
+	if !isCategorical(rowField) || !isCategorical(columnField) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "row and column must reference choice, rating or scale fields"})
+		return
+	}
+	if rowField.ID == columnField.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "row and column must be different fields"})
+		return
+	}
+
+	filter, msg := parseSubmissionFilter(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	cond, condArgs := filter.sql()
+
+	var cells []struct {
+		RowValue    string
+		ColumnValue string
+		Count       int
+	}
+	// A missing answer or an empty selection yields a NULL row from the LEFT JOIN
+	args := append([]interface{}{export.AnswerPath(rowField.ID), export.AnswerPath(columnField.ID), formID}, condArgs...)
+	if err := database.DB.Raw(
+		"SELECT COALESCE(CAST(r.value AS TEXT), '') AS row_value, COALESCE(CAST(col.value AS TEXT), '') AS column_value, "+
+			"COUNT(DISTINCT submissions.id) AS count "+
+			"FROM submissions "+
+			"LEFT JOIN json_each(submissions.data, ?) AS r "+
+			"LEFT JOIN json_each(submissions.data, ?) AS col "+
+			"WHERE submissions.form_id = ? AND "+cond+
+			" GROUP BY row_value, column_value", args...).
+		Scan(&cells).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute crosstab"})
+		return
+	}
+
+	rowTotals, err := countAnswers(formID, rowField.ID, cond, condArgs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute crosstab"})
+		return
+	}
+	columnTotals, err := countAnswers(formID, columnField.ID, cond, condArgs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute crosstab"})
+		return
+	}
+
+	var total int64
+	if err := database.DB.Model(&models.Submission{}).
+		Where("form_id = ?", formID).
+		Where(cond, condArgs...).
+		Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute crosstab"})
+		return
+	}
+
+	response := CrosstabResponse{
+		Row:          CrosstabField{ID: rowField.ID, Label: rowField.Label},
+		Column:       CrosstabField{ID: columnField.ID, Label: columnField.Label},
+		RowValues:    answerOrder(rowField, rowTotals),
+		ColumnValues: answerOrder(columnField, columnTotals),
+		Total:        int(total),
+	}
+
+	rowIndex := make(map[string]int, len(response.RowValues))
+	for i, v := range response.RowValues {
+		rowIndex[v] = i
+		response.RowTotals = append(response.RowTotals, rowTotals[v])
+	}
+	columnIndex := make(map[string]int, len(response.ColumnValues))
+	for i, v := range response.ColumnValues {
+		columnIndex[v] = i
+		response.ColumnTotals = append(response.ColumnTotals, columnTotals[v])
+	}
+
+	response.Cells = make([][]CrosstabCell, len(response.RowValues))
+	for i := range response.Cells {
+		response.Cells[i] = make([]CrosstabCell, len(response.ColumnValues))
+	}
+	for _, cell := range cells {
+		i := rowIndex[labelAnswer(cell.RowValue)]
+		j := columnIndex[labelAnswer(cell.ColumnValue)]
+		response.Cells[i][j].Count += cell.Count
+	}
+	for i, row := range response.Cells {
+		for j := range row {
+			cell := &row[j]
+			cell.RowPercent = percent(cell.Count, response.RowTotals[i])
+			cell.ColumnPercent = percent(cell.Count, response.ColumnTotals[j])
+			cell.TotalPercent = percent(cell.Count, response.Total)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// isCategorical reports whether a field has a small set of discrete answers
+func isCategorical(field *models.FormField) bool {
+	if field == nil || strings.Contains(field.ID, `"`) {
+		return false
+	}
+	return field.Type.IsChoice() || field.Type == models.FieldTypeRating || field.Type == models.FieldTypeScale
+}
+
+// countAnswers counts the matching submissions per answer to a field, keyed by labelAnswer
+func countAnswers(formID, fieldID, cond string, condArgs []interface{}) (map[string]int, error) {
+	var rows []struct {
+		Answer string
+		Count  int
+	}
+	args := append([]interface{}{export.AnswerPath(fieldID), formID}, condArgs...)
+	if err := database.DB.Raw(
+		"SELECT COALESCE(CAST(a.value AS TEXT), '') AS answer, COUNT(DISTINCT submissions.id) AS count "+
+			"FROM submissions LEFT JOIN json_each(submissions.data, ?) AS a "+
+			"WHERE submissions.form_id = ? AND "+cond+
+			" GROUP BY answer", args...).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[labelAnswer(row.Answer)] += row.Count
+	}
+	return counts, nil
+}
+
+// answerOrder lists the field's options followed by other given answers, sorted
+// numerically where possible, and "(none)" if some submissions did not answer
+func answerOrder(field *models.FormField, counts map[string]int) []string {
+	values := make([]string, 0, len(field.Options)+len(counts))
+	seen := make(map[string]bool)
+	for _, option := range field.Options {
+		if !seen[option] && option != "" {
+			seen[option] = true
+			values = append(values, option)
+		}
+	}
+
+	var others []string
+	for v := range counts {
+		if !seen[v] && v != noAnswer {
+			others = append(others, v)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		a, errA := strconv.ParseFloat(others[i], 64)
+		b, errB := strconv.ParseFloat(others[j], 64)
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return others[i] < others[j]
+	})
+	values = append(values, others...)
+
+	if counts[noAnswer] > 0 {
+		values = append(values, noAnswer)
+	}
+	return values
+}
+
+func labelAnswer(value string) string {
+	if value == "" {
+		return noAnswer
+	}
+	return value
+}
+
+// percent returns a as a percentage of b rounded to two decimals, or 0 if b is zero
+func percent(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return math.Round(float64(a)/float64(b)*10000) / 100
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func getCrosstab(t *testing.T, userID, formID string, query url.Values) (int, CrosstabResponse) {
+	t.Helper()
+	handler := NewSubmissionHandler(nil, nil, nil)
+	router := gin.New()
+	router.GET("/forms/:id/crosstab", func(c *gin.Context) {
+		c.Set("user_id", userID)
+		handler.Crosstab(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/forms/"+formID+"/crosstab?"+query.Encode(), nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response CrosstabResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+	}
+	return w.Code, response
+}
+
+// createSurvey creates a form asking for a department (radio) and used tools (checkbox)
+func createSurvey(t *testing.T, db *gorm.DB, userID string) *models.Form {
+	t.Helper()
+	form := &models.Form{
+		UserID: userID,
+		Title:  "Survey",
+		Fields: models.FormFields{
+			{ID: "dept", Label: "Department", Type: models.FieldTypeRadio, Options: []string{"Sales", "Support", "Legal"}},
+			{ID: "tools", Label: "Tools", Type: models.FieldTypeCheckbox, Options: []string{"Mail", "Chat"}},
+			{ID: "comment", Label: "Comment", Type: models.FieldTypeText},
+		},
+	}
+	db.Create(form)
+
+	answers := []models.SubmissionData{
+		{"dept": "Sales", "tools": []interface{}{"Mail", "Chat"}, "comment": "a"},
+		{"dept": "Sales", "tools": []interface{}{"Mail"}},
+		{"dept": "Support", "tools": []interface{}{"Chat"}, "comment": "a"},
+		{"dept": "Support", "tools": []interface{}{}},
+		{"tools": []interface{}{"Mail", "Fax"}},
+	}
+	for i, data := range answers {
+		db.Create(&models.Submission{
+			FormID:    form.ID,
+			Data:      data,
+			CreatedAt: time.Date(2025, 3, 1+i, 12, 0, 0, 0, time.UTC),
+		})
+	}
+	return form
+}
+
+func TestSubmissionHandler_Crosstab(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	form := createSurvey(t, db, user.ID)
+
+	code, response := getCrosstab(t, user.ID, form.ID, url.Values{"row": {"dept"}, "column": {"tools"}})
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+
+	if response.Row.Label != "Department" || response.Column.Label != "Tools" {
+		t.Errorf("unexpected fields %+v %+v", response.Row, response.Column)
+	}
+	// Unused options are kept, unknown answers follow the options
+	if want := []string{"Sales", "Support", "Legal", "(none)"}; !reflect.DeepEqual(response.RowValues, want) {
+		t.Errorf("expected rows %v, got %v", want, response.RowValues)
+	}
+	if want := []string{"Mail", "Chat", "Fax", "(none)"}; !reflect.DeepEqual(response.ColumnValues, want) {
+		t.Errorf("expected columns %v, got %v", want, response.ColumnValues)
+	}
+
+	counts := make([][]int, len(response.Cells))
+	for i, row := range response.Cells {
+		for _, cell := range row {
+			counts[i] = append(counts[i], cell.Count)
+		}
+	}
+	wantCounts := [][]int{
+		{2, 1, 0, 0}, // Sales
+		{0, 1, 0, 1}, // Support
+		{0, 0, 0, 0}, // Legal
+		{1, 0, 1, 0}, // (none)
+	}
+	if !reflect.DeepEqual(counts, wantCounts) {
+		t.Errorf("expected counts %v, got %v", wantCounts, counts)
+	}
+
+	if want := []int{2, 2, 0, 1}; !reflect.DeepEqual(response.RowTotals, want) {
+		t.Errorf("expected row totals %v, got %v", want, response.RowTotals)
+	}
+	if want := []int{3, 2, 1, 1}; !reflect.DeepEqual(response.ColumnTotals, want) {
+		t.Errorf("expected column totals %v, got %v", want, response.ColumnTotals)
+	}
+	if response.Total != 5 {
+		t.Errorf("expected total 5, got %d", response.Total)
+	}
+
+	salesMail := response.Cells[0][0]
+	if salesMail.RowPercent != 100 || salesMail.ColumnPercent != 66.67 || salesMail.TotalPercent != 40 {
+		t.Errorf("unexpected percentages %+v", salesMail)
+	}
+	if legal := response.Cells[2][0]; legal.RowPercent != 0 {
+		t.Errorf("expected 0%% for empty row, got %+v", legal)
+	}
+}
+
+func TestSubmissionHandler_Crosstab_Filters(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	form := createSurvey(t, db, user.ID)
+
+	// Only submissions that selected Chat
+	code, response := getCrosstab(t, user.ID, form.ID, url.Values{
+		"row": {"dept"}, "column": {"tools"}, "filter[tools]": {"Chat"},
+	})
+	if code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+	}
+	if response.Total != 2 || !reflect.DeepEqual(response.RowTotals, []int{1, 1, 0}) {
+		t.Errorf("expected 2 submissions from Sales and Support, got %d %v", response.Total, response.RowTotals)
+	}
+
+	// Date range and a text answer
+	_, response = getCrosstab(t, user.ID, form.ID, url.Values{
+		"row": {"dept"}, "column": {"tools"}, "from": {"2025-03-02"}, "to": {"2025-03-03"}, "filter[comment]": {"a"},
+	})
+	if response.Total != 1 || response.Cells[1][1].Count != 1 {
+		t.Errorf("expected the Support/Chat submission only, got %+v", response)
+	}
+
+	tests := []url.Values{
+		{"row": {"dept"}},
+		{"row": {"dept"}, "column": {"dept"}},
+		{"row": {"dept"}, "column": {"comment"}},
+		{"row": {"dept"}, "column": {"tools"}, "filter[missing]": {"x"}},
+		{"row": {"dept"}, "column": {"tools"}, "from": {"nope"}},
+	}
+	for _, query := range tests {
+		if code, _ := getCrosstab(t, user.ID, form.ID, query); code != http.StatusBadRequest {
+			t.Errorf("%v: expected status %d, got %d", query, http.StatusBadRequest, code)
+		}
+	}
+
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+	if code, _ := getCrosstab(t, other.ID, form.ID, url.Values{"row": {"dept"}, "column": {"tools"}}); code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, code)
+	}
+}
+
+func TestSubmissionHandler_Stats_Filters(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	form := createSurvey(t, db, user.ID)
+
+	handler := NewSubmissionHandler(nil, nil, nil)
+	router := gin.New()
+	router.GET("/forms/:id/stats", func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		handler.Stats(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/forms/"+form.ID+"/stats?filter[dept]=Sales", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		TotalSubmissions int                       `json:"total_submissions"`
+		FieldStats       map[string]map[string]int `json:"field_stats"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.TotalSubmissions != 2 {
+		t.Errorf("expected 2 submissions, got %d", response.TotalSubmissions)
+	}
+	if want := map[string]int{"Mail": 2, "Chat": 1}; !reflect.DeepEqual(response.FieldStats["tools"], want) {
+		t.Errorf("expected tool counts %v, got %v", want, response.FieldStats["tools"])
+	}
+}
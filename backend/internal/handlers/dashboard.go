@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"formera/internal/access"
+	"formera/internal/database"
+	"formera/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Limits of the near_capacity list in the dashboard summary
+const (
+	defaultCapacityLimit = 5
+	maxCapacityLimit     = 50
+)
+
+type DashboardHandler struct{}
+
+func NewDashboardHandler() *DashboardHandler {
+	return &DashboardHandler{}
+}
+
+// DashboardForm summarizes the activity of a single form
+type DashboardForm struct {
+	ID                    string            `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	Title                 string            `json:"title" example:"Contact Form"`
+	Status                models.FormStatus `json:"status" example:"published"`
+	TotalSubmissions      int               `json:"total_submissions" example:"150"`
+	SubmissionsLast7Days  int               `json:"submissions_last_7_days" example:"12"`
+	SubmissionsLast30Days int               `json:"submissions_last_30_days" example:"48"`
+	LastSubmissionAt      *time.Time        `json:"last_submission_at"`
+	MaxSubmissions        int               `json:"max_submissions,omitempty" example:"200"`
+	RemainingSubmissions  *int              `json:"remaining_submissions,omitempty" example:"50"` // Only for forms with a limit
+	CapacityPercent       *float64          `json:"capacity_percent,omitempty" example:"75"`      // Only for forms with a limit
+}
+
+// DashboardTotals sums the activity over all forms of the user
+type DashboardTotals struct {
+	Forms                 int `json:"forms" example:"8"`
+	PublishedForms        int `json:"published_forms" example:"5"`
+	Submissions           int `json:"submissions" example:"1200"`
+	SubmissionsLast7Days  int `json:"submissions_last_7_days" example:"40"`
+	SubmissionsLast30Days int `json:"submissions_last_30_days" example:"210"`
+}
+
+// DashboardResponse represents the account-wide activity summary
+type DashboardResponse struct {
+	Totals DashboardTotals `json:"totals"`
+	Forms  []DashboardForm `json:"forms"` // Newest form first
+	// Forms with a submission limit, closest to it first
+	NearCapacity []DashboardForm `json:"near_capacity"`
+}
+
+// Summary godoc
+// @Summary      Get dashboard summary
+// @Description  Get submission totals, recent activity and last submission of all forms the
+// @Description  current user owns or that are shared with them, plus the forms closest to their submission limit
+// @Tags         Forms
+// @Produce      json
+// @Param        limit query int false "Number of forms in near_capacity (max 50)" default(5)
+// @Success      200 {object} DashboardResponse
+// @Failure      401 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /dashboard [get]
+func (h *DashboardHandler) Summary(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	limit := defaultCapacityLimit
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v >= 0 {
+		limit = min(v, maxCapacityLimit)
+	}
+
+	now := time.Now()
+	var rows []struct {
+		ID                 string
+		Title              string
+		Status             models.FormStatus
+		Total              int
+		Last7Days          int
+		Last30Days         int
+		LastSubmissionUnix *int64
+		MaxSubmissions     int
+	}
+	const createdAt = "CAST(strftime('%s', submissions.created_at) AS INTEGER)"
+	if err := database.DB.Table("forms").
+		Select("forms.id, forms.title, forms.status, "+
+			"COUNT(submissions.id) AS total, "+
+			"COALESCE(SUM("+createdAt+" >= ?), 0) AS last7_days, "+
+			"COALESCE(SUM("+createdAt+" >= ?), 0) AS last30_days, "+
+			"MAX("+createdAt+") AS last_submission_unix, "+
+			"COALESCE(json_extract(forms.settings, '$.max_submissions'), 0) AS max_submissions",
+			now.AddDate(0, 0, -7).Unix(), now.AddDate(0, 0, -30).Unix()).
+		Joins("LEFT JOIN submissions ON submissions.form_id = forms.id").
+		Scopes(access.Scope(userID)).
+		Group("forms.id").
+		Order("forms.created_at DESC").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute dashboard"})
+		return
+	}
+
+	response := DashboardResponse{
+		Forms:        make([]DashboardForm, 0, len(rows)),
+		NearCapacity: []DashboardForm{},
+	}
+	for _, row := range rows {
+		form := DashboardForm{
+			ID:                    row.ID,
+			Title:                 row.Title,
+			Status:                row.Status,
+			TotalSubmissions:      row.Total,
+			SubmissionsLast7Days:  row.Last7Days,
+			SubmissionsLast30Days: row.Last30Days,
+			MaxSubmissions:        row.MaxSubmissions,
+		}
+		if row.LastSubmissionUnix != nil {
+			t := time.Unix(*row.LastSubmissionUnix, 0).UTC()
+			form.LastSubmissionAt = &t
+		}
+		if row.MaxSubmissions > 0 {
+			remaining := max(row.MaxSubmissions-row.Total, 0)
+			used := percent(row.Total, row.MaxSubmissions)
+			form.RemainingSubmissions = &remaining
+			form.CapacityPercent = &used
+			response.NearCapacity = append(response.NearCapacity, form)
+		}
+		response.Forms = append(response.Forms, form)
+
+		response.Totals.Forms++
+		if row.Status == models.FormStatusPublished {
+			response.Totals.PublishedForms++
+		}
+		response.Totals.Submissions += row.Total
+		response.Totals.SubmissionsLast7Days += row.Last7Days
+		response.Totals.SubmissionsLast30Days += row.Last30Days
+	}
+
+	sort.SliceStable(response.NearCapacity, func(i, j int) bool {
+		a, b := response.NearCapacity[i], response.NearCapacity[j]
+		if *a.CapacityPercent != *b.CapacityPercent {
+			return *a.CapacityPercent > *b.CapacityPercent
+		}
+		return *a.RemainingSubmissions < *b.RemainingSubmissions
+	})
+	if len(response.NearCapacity) > limit {
+		response.NearCapacity = response.NearCapacity[:limit]
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func getDashboard(t *testing.T, userID, query string) DashboardResponse {
+	t.Helper()
+	handler := NewDashboardHandler()
+	router := gin.New()
+	router.GET("/dashboard", func(c *gin.Context) {
+		c.Set("user_id", userID)
+		handler.Summary(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response DashboardResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return response
+}
+
+func TestDashboardHandler_Summary(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	now := time.Now()
+	old := &models.Form{UserID: user.ID, Title: "Old", Status: models.FormStatusPublished, CreatedAt: now.AddDate(0, -2, 0)}
+	limited := &models.Form{
+		UserID:    user.ID,
+		Title:     "Limited",
+		Status:    models.FormStatusPublished,
+		Settings:  models.FormSettings{MaxSubmissions: 4},
+		CreatedAt: now.AddDate(0, -1, 0),
+	}
+	roomy := &models.Form{UserID: user.ID, Title: "Roomy", Settings: models.FormSettings{MaxSubmissions: 100}, CreatedAt: now.AddDate(0, 0, -1)}
+	empty := &models.Form{UserID: user.ID, Title: "Empty", CreatedAt: now}
+	foreign := &models.Form{UserID: other.ID, Title: "Foreign"}
+	for _, f := range []*models.Form{old, limited, roomy, empty, foreign} {
+		db.Create(f)
+	}
+
+	submit := func(form *models.Form, age time.Duration) {
+		db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{}, CreatedAt: now.Add(-age)})
+	}
+	day := 24 * time.Hour
+	submit(old, 60*day)
+	submit(old, 20*day)
+	submit(limited, 40*day)
+	submit(limited, 10*day)
+	submit(limited, 2*day)
+	submit(limited, time.Hour)
+	submit(roomy, 3*day)
+	submit(foreign, time.Hour)
+
+	response := getDashboard(t, user.ID, "")
+
+	wantTotals := DashboardTotals{Forms: 4, PublishedForms: 2, Submissions: 7, SubmissionsLast7Days: 3, SubmissionsLast30Days: 5}
+	if response.Totals != wantTotals {
+		t.Errorf("expected totals %+v, got %+v", wantTotals, response.Totals)
+	}
+
+	if len(response.Forms) != 4 {
+		t.Fatalf("expected 4 forms, got %d", len(response.Forms))
+	}
+	titles := []string{"Empty", "Roomy", "Limited", "Old"}
+	for i, f := range response.Forms {
+		if f.Title != titles[i] {
+			t.Errorf("form %d: expected %s, got %s", i, titles[i], f.Title)
+		}
+	}
+
+	l := response.Forms[2]
+	if l.TotalSubmissions != 4 || l.SubmissionsLast7Days != 2 || l.SubmissionsLast30Days != 3 {
+		t.Errorf("unexpected counts for Limited: %+v", l)
+	}
+	if l.LastSubmissionAt == nil || l.LastSubmissionAt.Sub(now.Add(-time.Hour)).Abs() > time.Second {
+		t.Errorf("expected last submission an hour ago, got %v", l.LastSubmissionAt)
+	}
+	if l.RemainingSubmissions == nil || *l.RemainingSubmissions != 0 || *l.CapacityPercent != 100 {
+		t.Errorf("expected Limited to be full, got %+v", l)
+	}
+	if e := response.Forms[0]; e.TotalSubmissions != 0 || e.LastSubmissionAt != nil || e.RemainingSubmissions != nil {
+		t.Errorf("unexpected summary for Empty: %+v", e)
+	}
+
+	if len(response.NearCapacity) != 2 || response.NearCapacity[0].Title != "Limited" || response.NearCapacity[1].Title != "Roomy" {
+		t.Errorf("expected Limited before Roomy near capacity, got %+v", response.NearCapacity)
+	}
+	if response := getDashboard(t, user.ID, "?limit=1"); len(response.NearCapacity) != 1 {
+		t.Errorf("expected near_capacity to be limited to 1, got %d", len(response.NearCapacity))
+	}
+
+	if response := getDashboard(t, other.ID, ""); response.Totals.Forms != 1 || response.Totals.Submissions != 1 {
+		t.Errorf("expected only the other user's form, got %+v", response.Totals)
+	}
+}
+
+func TestDashboardHandler_Summary_SingleQuery(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	for i := 0; i < 20; i++ {
+		form := &models.Form{UserID: user.ID, Title: fmt.Sprintf("Form %d", i)}
+		db.Create(form)
+		db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{}})
+	}
+
+	queries := 0
+	db.Callback().Query().Before("gorm:query").Register("test:count_queries", func(*gorm.DB) { queries++ })
+	db.Callback().Row().Before("gorm:row").Register("test:count_rows", func(*gorm.DB) { queries++ })
+	defer db.Callback().Query().Remove("test:count_queries")
+	defer db.Callback().Row().Remove("test:count_rows")
+
+	response := getDashboard(t, user.ID, "")
+	if response.Totals.Forms != 20 || response.Totals.Submissions != 20 {
+		t.Fatalf("unexpected totals %+v", response.Totals)
+	}
+	if queries != 1 {
+		t.Errorf("expected a single query, got %d", queries)
+	}
+}
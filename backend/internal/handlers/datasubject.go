@@ -0,0 +1,503 @@
+package handlers
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"formera/internal/database"
+	"formera/internal/export"
+	"formera/internal/jobs"
+	"formera/internal/logger"
+	"formera/internal/models"
+	"formera/internal/storage"
+	"formera/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// minIdentifierLength prevents overly broad searches such as "a" that would match every submission
+const minIdentifierLength = 3
+
+// DataSubjectHandler handles data subject access and erasure requests (GDPR Art. 15 and 17)
+type DataSubjectHandler struct {
+	storage  storage.Storage
+	webhooks *webhooks.Dispatcher
+}
+
+// NewDataSubjectHandler creates a new data subject handler. The webhook
+// dispatcher may be nil if erased submissions should not be reported.
+func NewDataSubjectHandler(store storage.Storage, hooks *webhooks.Dispatcher) *DataSubjectHandler {
+	return &DataSubjectHandler{storage: store, webhooks: hooks}
+}
+
+type DataSubjectRequest struct {
+	Identifier string `json:"identifier" binding:"required"`
+}
+
+// MatchedField describes where the identifier was found in a submission
+type MatchedField struct {
+	FieldID string           `json:"field_id"` // Field ID, or "metadata.<key>" for metadata matches
+	Label   string           `json:"label"`
+	Type    models.FieldType `json:"type,omitempty"`
+}
+
+// DataSubjectMatch is a submission that references the data subject
+type DataSubjectMatch struct {
+	FormID        string            `json:"form_id"`
+	FormTitle     string            `json:"form_title"`
+	Submission    models.Submission `json:"submission"`
+	MatchedFields []MatchedField    `json:"matched_fields"`
+	Files         []string          `json:"files,omitempty"`
+}
+
+// DataSubjectErasureResponse summarizes an erasure
+type DataSubjectErasureResponse struct {
+	AuditID            string   `json:"audit_id"`
+	DeletedSubmissions int      `json:"deleted_submissions"`
+	DeletedFiles       int      `json:"deleted_files"`
+	FileErrors         []string `json:"file_errors,omitempty"`
+}
+
+type dataSubjectAnswer struct {
+	FieldID string           `json:"field_id"`
+	Label   string           `json:"label"`
+	Type    models.FieldType `json:"type,omitempty"`
+	Value   interface{}      `json:"value"`
+}
+
+type dataSubjectRecord struct {
+	FormID        string                    `json:"form_id"`
+	FormTitle     string                    `json:"form_title"`
+	SubmissionID  string                    `json:"submission_id"`
+	SubmittedAt   time.Time                 `json:"submitted_at"`
+	Answers       []dataSubjectAnswer       `json:"answers"`
+	Metadata      models.SubmissionMetadata `json:"metadata"`
+	MatchedFields []MatchedField            `json:"matched_fields"`
+	Files         []string                  `json:"files,omitempty"` // Paths inside the bundle
+}
+
+type dataSubjectBundle struct {
+	Identifier   string              `json:"identifier"`
+	GeneratedAt  time.Time           `json:"generated_at"`
+	Submissions  []dataSubjectRecord `json:"submissions"`
+	MissingFiles []string            `json:"missing_files,omitempty"`
+}
+
+// Search godoc
+// @Summary      Search data subject
+// @Description  Find all submissions across all forms that contain an email address or other identifier (admin only)
+// @Tags         Data Subjects
+// @Produce      json
+// @Param        identifier query string true "Email address or other identifier"
+// @Success      200 {array} DataSubjectMatch
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse "Admin access required"
+// @Security     BearerAuth
+// @Router       /data-subjects/search [get]
+func (h *DataSubjectHandler) Search(c *gin.Context) {
+	identifier := strings.TrimSpace(c.Query("identifier"))
+	if len(identifier) < minIdentifierLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Identifier must be at least %d characters", minIdentifierLength)})
+		return
+	}
+
+	matches, err := findDataSubjectMatches(database.DB, identifier)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search submissions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, matches)
+}
+
+// Export godoc
+// @Summary      Export data subject bundle
+// @Description  Download a ZIP bundle with all submissions and uploaded files referencing the identifier (admin only)
+// @Tags         Data Subjects
+// @Produce      application/zip
+// @Param        identifier query string true "Email address or other identifier"
+// @Success      200 {file} file "ZIP bundle"
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse "Admin access required"
+// @Security     BearerAuth
+// @Router       /data-subjects/export [get]
+func (h *DataSubjectHandler) Export(c *gin.Context) {
+	identifier := strings.TrimSpace(c.Query("identifier"))
+	if len(identifier) < minIdentifierLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Identifier must be at least %d characters", minIdentifierLength)})
+		return
+	}
+
+	matches, err := findDataSubjectMatches(database.DB, identifier)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search submissions"})
+		return
+	}
+
+	formFields := make(map[string]models.FormFields)
+	for _, m := range matches {
+		if _, ok := formFields[m.FormID]; !ok {
+			var form models.Form
+			database.DB.Select("id", "fields").First(&form, "id = ?", m.FormID)
+			formFields[m.FormID] = form.Fields
+		}
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=data-subject-%s.zip", time.Now().Format("20060102-150405")))
+
+	zw := zip.NewWriter(c.Writer)
+	defer zw.Close()
+
+	bundle := dataSubjectBundle{
+		Identifier:  identifier,
+		GeneratedAt: time.Now().UTC(),
+		Submissions: make([]dataSubjectRecord, 0, len(matches)),
+	}
+
+	for _, m := range matches {
+		record := dataSubjectRecord{
+			FormID:        m.FormID,
+			FormTitle:     m.FormTitle,
+			SubmissionID:  m.Submission.ID,
+			SubmittedAt:   m.Submission.CreatedAt,
+			Answers:       submissionAnswers(formFields[m.FormID], m.Submission.Data),
+			Metadata:      m.Submission.Metadata,
+			MatchedFields: m.MatchedFields,
+		}
+
+		for _, filePath := range m.Files {
+			name := path.Join("files", m.Submission.ID, path.Base(filePath))
+			if err := h.copyFileToZip(zw, filePath, name); err != nil {
+				bundle.MissingFiles = append(bundle.MissingFiles, filePath)
+				continue
+			}
+			record.Files = append(record.Files, name)
+		}
+
+		bundle.Submissions = append(bundle.Submissions, record)
+	}
+
+	w, err := zw.Create("data.json")
+	if err != nil {
+		return
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(bundle)
+}
+
+// Erase godoc
+// @Summary      Erase data subject
+// @Description  Delete all submissions and uploaded files referencing the identifier in a single audited operation (admin only)
+// @Tags         Data Subjects
+// @Accept       json
+// @Produce      json
+// @Param        request body DataSubjectRequest true "Identifier"
+// @Success      200 {object} DataSubjectErasureResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse "Admin access required"
+// @Security     BearerAuth
+// @Router       /data-subjects/erase [post]
+func (h *DataSubjectHandler) Erase(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req DataSubjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	identifier := strings.TrimSpace(req.Identifier)
+	if len(identifier) < minIdentifierLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Identifier must be at least %d characters", minIdentifierLength)})
+		return
+	}
+
+	matches, err := findDataSubjectMatches(database.DB, identifier)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search submissions"})
+		return
+	}
+
+	submissionIDs := make([]string, 0, len(matches))
+	formIDs := make(map[string]bool)
+	var filePaths []string
+	for _, m := range matches {
+		submissionIDs = append(submissionIDs, m.Submission.ID)
+		formIDs[m.FormID] = true
+		filePaths = append(filePaths, m.Files...)
+	}
+
+	// Resolve storage IDs before the records are removed
+	var fileRecords []storage.FileRecord
+	if len(filePaths) > 0 {
+		database.DB.Where("path IN ?", filePaths).Find(&fileRecords)
+	}
+	fileIDs := make(map[string]string, len(filePaths))
+	for _, p := range filePaths {
+		fileIDs[p] = strings.TrimSuffix(path.Base(p), path.Ext(p))
+	}
+	for _, r := range fileRecords {
+		fileIDs[r.Path] = r.ID
+	}
+
+	affectedForms := make([]string, 0, len(formIDs))
+	for id := range formIDs {
+		affectedForms = append(affectedForms, id)
+	}
+
+	// The identifier itself is not stored in the audit log, only a hash of it
+	audit := &models.AuditLog{
+		UserID: userID,
+		Action: models.AuditActionDataSubjectErase,
+		Details: models.AuditDetails{
+			"identifier_sha256": hashIdentifier(identifier),
+			"submission_ids":    submissionIDs,
+			"form_ids":          affectedForms,
+			"files":             filePaths,
+		},
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(submissionIDs) > 0 {
+			if err := tx.Where("id IN ?", submissionIDs).Delete(&models.Submission{}).Error; err != nil {
+				return err
+			}
+			if err := webhooks.PurgeSubmissions(tx, submissionIDs); err != nil {
+				return err
+			}
+			if err := jobs.RedactSubmissions(tx, submissionIDs); err != nil {
+				return err
+			}
+		}
+		if len(filePaths) > 0 {
+			if err := tx.Where("path IN ?", filePaths).Delete(&storage.FileRecord{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(audit).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to erase data"})
+		return
+	}
+
+	// Receivers such as CRMs are told to drop the erased submissions
+	if len(affectedForms) > 0 {
+		var forms []models.Form
+		database.DB.Where("id IN ?", affectedForms).Find(&forms)
+		erased := make([]models.Submission, len(matches))
+		for i, m := range matches {
+			erased[i] = m.Submission
+		}
+		dispatchDeleted(h.webhooks, forms, erased)
+	}
+
+	// Stored files cannot take part in the transaction, so they are removed afterwards
+	response := DataSubjectErasureResponse{
+		AuditID:            audit.ID,
+		DeletedSubmissions: len(submissionIDs),
+	}
+	for _, p := range filePaths {
+		if err := h.storage.Delete(fileIDs[p]); err != nil && err != storage.ErrFileNotFound {
+			logger.Warn().Err(err).Str("path", p).Msg("Failed to delete data subject file")
+			response.FileErrors = append(response.FileErrors, p)
+			continue
+		}
+		response.DeletedFiles++
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *DataSubjectHandler) copyFileToZip(zw *zip.Writer, filePath, name string) error {
+	content, err := h.storage.GetFileByPath(filePath)
+	if err != nil {
+		return err
+	}
+	defer content.Reader.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, content.Reader)
+	return err
+}
+
+// findDataSubjectMatches scans all submissions for answers containing the identifier.
+// A LIKE query narrows the candidates, matching on word boundaries happens in Go.
+func findDataSubjectMatches(db *gorm.DB, identifier string) ([]DataSubjectMatch, error) {
+	encoded, _ := json.Marshal(identifier)
+	pattern := "%" + escapeLike(strings.Trim(string(encoded), `"`)) + "%"
+
+	forms := make(map[string]*models.Form)
+	matches := []DataSubjectMatch{}
+
+	var batch []models.Submission
+	result := db.Where(`data LIKE ? ESCAPE '\' OR metadata LIKE ? ESCAPE '\'`, pattern, pattern).
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			for _, sub := range batch {
+				form, ok := forms[sub.FormID]
+				if !ok {
+					form = &models.Form{}
+					if err := db.Select("id", "title", "fields").First(form, "id = ?", sub.FormID).Error; err != nil {
+						form = &models.Form{ID: sub.FormID}
+					}
+					forms[sub.FormID] = form
+				}
+
+				matched := matchSubmission(form.Fields, sub, identifier)
+				if len(matched) == 0 {
+					continue
+				}
+				matches = append(matches, DataSubjectMatch{
+					FormID:        sub.FormID,
+					FormTitle:     form.Title,
+					Submission:    sub,
+					MatchedFields: matched,
+					Files:         export.ReferencedFiles(sub.Data),
+				})
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return matches, nil
+}
+
+// matchSubmission returns the fields whose value equals the identifier (case-insensitive)
+func matchSubmission(fields models.FormFields, sub models.Submission, identifier string) []MatchedField {
+	byID := make(map[string]models.FormField, len(fields))
+	for _, f := range fields {
+		byID[f.ID] = f
+	}
+
+	var matched []MatchedField
+	for _, key := range sortedKeys(sub.Data) {
+		val := sub.Data[key]
+		if !valueMatches(val, identifier) {
+			continue
+		}
+		m := MatchedField{FieldID: key, Label: key}
+		if f, ok := byID[key]; ok {
+			m.Label = f.Label
+			m.Type = f.Type
+		}
+		matched = append(matched, m)
+	}
+
+	meta := sub.Metadata
+	if valueMatches(meta.IP, identifier) {
+		matched = append(matched, MatchedField{FieldID: "metadata.ip", Label: "IP"})
+	}
+	for key, val := range meta.Tracking {
+		if valueMatches(val, identifier) {
+			matched = append(matched, MatchedField{FieldID: "metadata.tracking." + key, Label: key})
+		}
+	}
+
+	return matched
+}
+
+// valueMatches reports whether an answer contains the identifier, ignoring case,
+// as a whole word or address: "jane@example.com" matches "Mail Jane@Example.com."
+// but not "notjane@example.com" or "jane@example.com.au"
+func valueMatches(val interface{}, identifier string) bool {
+	switch v := val.(type) {
+	case string:
+		return containsWord(strings.ToLower(v), strings.ToLower(identifier))
+	case []interface{}:
+		for _, item := range v {
+			if valueMatches(item, identifier) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsWord(s, word string) bool {
+	if word == "" {
+		return false
+	}
+	for offset := 0; offset < len(s); {
+		i := strings.Index(s[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, size := utf8.DecodeRuneInString(s[end:])
+		// A trailing dot ends a sentence unless the address continues after it
+		if after == '.' {
+			after, _ = utf8.DecodeRuneInString(s[end+size:])
+		}
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+// isWordRune reports whether r can be part of a word, name or email address
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("._%+-@", r)
+}
+
+// submissionAnswers lists the answers of a submission in field order, labelled with the field definitions.
+// Answers for fields no longer on the form are appended with their ID as label.
+func submissionAnswers(fields models.FormFields, data models.SubmissionData) []dataSubjectAnswer {
+	answers := make([]dataSubjectAnswer, 0, len(data))
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		val, ok := data[f.ID]
+		if !ok {
+			continue
+		}
+		seen[f.ID] = true
+		answers = append(answers, dataSubjectAnswer{FieldID: f.ID, Label: f.Label, Type: f.Type, Value: val})
+	}
+	for _, key := range sortedKeys(data) {
+		if !seen[key] {
+			answers = append(answers, dataSubjectAnswer{FieldID: key, Label: key, Value: data[key]})
+		}
+	}
+	return answers
+}
+
+func sortedKeys(data models.SubmissionData) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func hashIdentifier(identifier string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(identifier)))
+	return hex.EncodeToString(sum[:])
+}
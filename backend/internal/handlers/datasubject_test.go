@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/storage"
+	"formera/internal/testutil"
+	"formera/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupDataSubjectFixtures(t *testing.T, db *gorm.DB, store storage.Storage) (string, *models.Submission) {
+	t.Helper()
+	user := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleAdmin)
+
+	contact := &models.Form{
+		UserID: user.ID,
+		Title:  "Contact",
+		Fields: models.FormFields{
+			{ID: "email", Label: "E-Mail", Type: models.FieldTypeEmail},
+			{ID: "cv", Label: "CV", Type: models.FieldTypeFile},
+		},
+	}
+	survey := &models.Form{
+		UserID: user.ID,
+		Title:  "Survey",
+		Fields: models.FormFields{
+			{ID: "name", Label: "Name", Type: models.FieldTypeText},
+			{ID: "comment", Label: "Comment", Type: models.FieldTypeTextarea},
+		},
+	}
+	db.Create(contact)
+	db.Create(survey)
+
+	upload, err := store.Upload("cv.txt", "text/plain", 5, strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("failed to upload fixture: %v", err)
+	}
+	db.Create(&storage.FileRecord{ID: upload.ID, Path: upload.Path, Filename: upload.Filename})
+
+	match := &models.Submission{FormID: contact.ID, Data: models.SubmissionData{
+		"email": "Jane.Doe@example.com",
+		"cv":    []interface{}{upload.Path},
+	}}
+	db.Create(match)
+	db.Create(&models.Submission{FormID: survey.ID, Data: models.SubmissionData{"name": "jane.doe@example.com"}})
+	// Mentioned in a free text answer
+	db.Create(&models.Submission{FormID: survey.ID, Data: models.SubmissionData{"comment": "Please reply to Jane.Doe@example.com."}})
+	// Substring only, must not match
+	db.Create(&models.Submission{FormID: contact.ID, Data: models.SubmissionData{"email": "notjane.doe@example.com"}})
+
+	return user.ID, match
+}
+
+func TestDataSubjectHandler_Search(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store, _ := storage.NewLocalStorage(t.TempDir(), "http://localhost/uploads")
+	setupDataSubjectFixtures(t, db, store)
+
+	handler := NewDataSubjectHandler(store, nil)
+	router := gin.New()
+	router.GET("/data-subjects/search", handler.Search)
+
+	req := httptest.NewRequest(http.MethodGet, "/data-subjects/search?identifier=jane.doe@example.com", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var matches []DataSubjectMatch
+	if err := json.Unmarshal(w.Body.Bytes(), &matches); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(matches) != 3 {
+		t.Fatalf("expected 3 matches across forms, got %d", len(matches))
+	}
+
+	for _, m := range matches {
+		if m.FormTitle == "Contact" {
+			if m.MatchedFields[0].Type != models.FieldTypeEmail {
+				t.Errorf("expected match on email field, got %+v", m.MatchedFields)
+			}
+			if len(m.Files) != 1 {
+				t.Errorf("expected referenced upload, got %v", m.Files)
+			}
+		}
+	}
+}
+
+func TestDataSubjectHandler_Search_TooShort(t *testing.T) {
+	testutil.SetupTestDB(t)
+
+	handler := NewDataSubjectHandler(nil, nil)
+	router := gin.New()
+	router.GET("/data-subjects/search", handler.Search)
+
+	req := httptest.NewRequest(http.MethodGet, "/data-subjects/search?identifier=a", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestDataSubjectHandler_Export(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store, _ := storage.NewLocalStorage(t.TempDir(), "http://localhost/uploads")
+	_, match := setupDataSubjectFixtures(t, db, store)
+
+	handler := NewDataSubjectHandler(store, nil)
+	router := gin.New()
+	router.GET("/data-subjects/export", handler.Export)
+
+	req := httptest.NewRequest(http.MethodGet, "/data-subjects/export?identifier=jane.doe@example.com", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("response is not a valid zip: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	if !names["data.json"] {
+		t.Error("bundle is missing data.json")
+	}
+	found := false
+	for name := range names {
+		if strings.HasPrefix(name, "files/"+match.ID+"/") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("bundle is missing uploaded file, got %v", names)
+	}
+}
+
+func TestDataSubjectHandler_Erase(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	store, _ := storage.NewLocalStorage(t.TempDir(), "http://localhost/uploads")
+	adminID, match := setupDataSubjectFixtures(t, db, store)
+	filePath := match.Data["cv"].([]interface{})[0].(string)
+
+	// Receivers of every form are told about the erased submissions
+	var forms []models.Form
+	db.Find(&forms)
+	for _, form := range forms {
+		db.Create(&models.Webhook{FormID: form.ID, URL: "https://example.com/hook", Events: models.WebhookEvents{models.WebhookEventSubmissionDeleted}, Active: true})
+	}
+
+	var matchHook models.Webhook
+	db.First(&matchHook, "form_id = ?", match.FormID)
+	db.Create(&models.WebhookDelivery{WebhookID: matchHook.ID, SubmissionID: match.ID, Event: models.WebhookEventSubmissionCreated, Payload: `{"answers":{"email":"jane.doe@example.com"}}`})
+
+	sentMail := &models.Job{Type: "mail.send", Payload: `{"To":["jane.doe@example.com"]}`, Status: models.JobStatusSucceeded, SubmissionID: match.ID}
+	queuedMail := &models.Job{Type: "mail.send", Payload: `{"To":["jane.doe@example.com"]}`, Status: models.JobStatusPending, SubmissionID: match.ID}
+	db.Create(sentMail)
+	db.Create(queuedMail)
+
+	handler := NewDataSubjectHandler(store, webhooks.NewDispatcher(db, webhooks.Config{}))
+	router := gin.New()
+	router.POST("/data-subjects/erase", func(c *gin.Context) {
+		c.Set("user_id", adminID)
+		handler.Erase(c)
+	})
+
+	jsonBody, _ := json.Marshal(DataSubjectRequest{Identifier: "jane.doe@example.com"})
+	req := httptest.NewRequest(http.MethodPost, "/data-subjects/erase", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response DataSubjectErasureResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if response.DeletedSubmissions != 3 || response.DeletedFiles != 1 {
+		t.Errorf("expected 3 submissions and 1 file deleted, got %+v", response)
+	}
+
+	var remaining int64
+	db.Model(&models.Submission{}).Count(&remaining)
+	if remaining != 1 {
+		t.Errorf("expected only the non-matching submission to remain, got %d", remaining)
+	}
+
+	if _, err := store.GetFileByPath(filePath); err != storage.ErrFileNotFound {
+		t.Errorf("expected uploaded file to be deleted, got %v", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	db.Find(&deliveries)
+	if len(deliveries) != 3 {
+		t.Fatalf("expected earlier deliveries to be purged and a deleted event per erased submission, got %+v", deliveries)
+	}
+	for _, d := range deliveries {
+		if d.Event != models.WebhookEventSubmissionDeleted {
+			t.Errorf("expected only deleted events, got %s", d.Event)
+		}
+		if strings.Contains(d.Payload, "jane.doe") || strings.Contains(d.Payload, `"answers"`) {
+			t.Errorf("deleted events must not contain the erased data, got %s", d.Payload)
+		}
+	}
+
+	var mails []models.Job
+	db.Where("submission_id = ?", match.ID).Find(&mails)
+	if len(mails) != 1 || mails[0].ID != sentMail.ID || strings.Contains(mails[0].Payload, "jane.doe") {
+		t.Errorf("expected queued mail to be deleted and sent mail to be redacted, got %+v", mails)
+	}
+
+	var audit models.AuditLog
+	if err := db.First(&audit, "id = ?", response.AuditID).Error; err != nil {
+		t.Fatalf("expected audit log entry: %v", err)
+	}
+	if audit.UserID != adminID || audit.Action != models.AuditActionDataSubjectErase {
+		t.Errorf("unexpected audit entry: %+v", audit)
+	}
+	if strings.Contains(w.Body.String(), "jane.doe") || audit.Details["identifier_sha256"] == "jane.doe@example.com" {
+		t.Error("identifier should not be stored in clear text")
+	}
+}
+
+func TestValueMatches(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  bool
+	}{
+		{"jane.doe@example.com", true},
+		{" Jane.Doe@Example.com ", true},
+		{"Please reply to jane.doe@example.com.", true},
+		{"Contacts: (jane.doe@example.com), bob@example.com", true},
+		{[]interface{}{"other", "jane.doe@example.com"}, true},
+		{"notjane.doe@example.com", false},
+		{"jane.doe@example.com.au", false},
+		{"jane.doe@example.community", false},
+		{"x.jane.doe@example.com", false},
+		{float64(42), false},
+	}
+
+	for _, tt := range tests {
+		if got := valueMatches(tt.value, "jane.doe@example.com"); got != tt.want {
+			t.Errorf("valueMatches(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
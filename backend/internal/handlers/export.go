@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"formera/internal/database"
+	"formera/internal/export"
+	"formera/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxSeparatorLength limits the multi-value separator of exports
+const maxSeparatorLength = 10
+
+// Export formats that can be saved in presets
+var exportFormats = map[string]bool{"csv": true, "json": true, "xlsx": true, "zip": true, "pdf": true}
+
+// applyExportPreset merges the parameters of the preset given by ?preset=<id>
+// into the request query; parameters of the request take precedence. Must run
+// before the query is read. Responds with 404 and returns false for unknown presets.
+func applyExportPreset(c *gin.Context, form *models.Form) bool {
+	values := c.Request.URL.Query()
+	presetID := values.Get("preset")
+	if presetID == "" {
+		return true
+	}
+
+	var preset models.ExportPreset
+	if result := database.DB.Where("id = ? AND form_id = ?", presetID, form.ID).First(&preset); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export preset not found"})
+		return false
+	}
+	for key, value := range preset.Params {
+		if _, ok := values[key]; !ok {
+			values.Set(key, value)
+		}
+	}
+	c.Request.URL.RawQuery = values.Encode()
+	return true
+}
+
+// paramsContext wraps stored export parameters in a context, so that they can
+// be validated with the parsers of the export endpoints
+func paramsContext(params models.ExportParams) *gin.Context {
+	values := url.Values{}
+	for key, value := range params {
+		values.Set(key, value)
+	}
+	return &gin.Context{Request: &http.Request{URL: &url.URL{RawQuery: values.Encode()}}}
+}
+
+// exportQuery returns the submissions of the form matched by the date range and
+// answer filters of the submission list. Returns an error message for invalid input.
+func exportQuery(c *gin.Context, form *models.Form) (*gorm.DB, export.Filter, string) {
+	filter, msg := parseSubmissionFilter(c, form)
+	if msg != "" {
+		return nil, export.Filter{}, msg
+	}
+	f := filter.export()
+	return f.Apply(database.DB.Model(&models.Submission{}).Where("form_id = ?", form.ID)), f, ""
+}
+
+// parseColumns reads the comma-separated column selection
+func parseColumns(c *gin.Context, form *models.Form) ([]string, string) {
+	raw := c.Query("columns")
+	if raw == "" {
+		return nil, ""
+	}
+	var columns []string
+	for _, key := range strings.Split(raw, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			columns = append(columns, key)
+		}
+	}
+	if unknown := export.UnknownColumns(form, columns); len(unknown) > 0 {
+		return nil, "Unknown columns: " + strings.Join(unknown, ", ")
+	}
+	return columns, ""
+}
+
+// parseExportOptions reads the columns, headers, separator, metadata and utm query
+// parameters shared by all export formats. Returns an error message for invalid input.
+func parseExportOptions(c *gin.Context, form *models.Form) (export.Options, string) {
+	opts := export.Options{
+		Headers:             export.HeaderLabels,
+		MultiValueSeparator: export.DefaultMultiValueSeparator,
+	}
+
+	switch h := export.HeaderMode(c.Query("headers")); h {
+	case "":
+	case export.HeaderLabels, export.HeaderIDs:
+		opts.Headers = h
+	default:
+		return opts, "Invalid headers (expected labels or ids)"
+	}
+
+	if sep, ok := c.GetQuery("separator"); ok {
+		if sep == "" || utf8.RuneCountInString(sep) > maxSeparatorLength {
+			return opts, "separator must be 1 to 10 characters"
+		}
+		opts.MultiValueSeparator = sep
+	}
+
+	var ok bool
+	if opts.IncludeMetadata, ok = queryBool(c, "metadata"); !ok {
+		return opts, "Invalid metadata (expected true or false)"
+	}
+	if opts.IncludeUTM, ok = queryBool(c, "utm"); !ok {
+		return opts, "Invalid utm (expected true or false)"
+	}
+
+	columns, msg := parseColumns(c, form)
+	if msg != "" {
+		return opts, msg
+	}
+	opts.Columns = columns
+	return opts, ""
+}
+
+// parseCSVOptions adds the delimiter and bom query parameters to the export options
+func parseCSVOptions(c *gin.Context, form *models.Form) (export.CSVOptions, string) {
+	base, msg := parseExportOptions(c, form)
+	if msg != "" {
+		return export.CSVOptions{}, msg
+	}
+	opts := export.CSVOptions{Options: base}
+
+	var ok bool
+	if opts.Delimiter, ok = parseDelimiter(c.Query("delimiter")); !ok {
+		return opts, "Invalid delimiter (expected comma, semicolon, tab or pipe)"
+	}
+	if opts.BOM, ok = queryBool(c, "bom"); !ok {
+		return opts, "Invalid bom (expected true or false)"
+	}
+	return opts, ""
+}
+
+// parseDelimiter reads a CSV delimiter by character or name, comma if empty
+func parseDelimiter(value string) (rune, bool) {
+	switch value {
+	case "", ",", "comma":
+		return ',', true
+	case ";", "semicolon":
+		return ';', true
+	case "\t", "tab":
+		return '\t', true
+	case "|", "pipe":
+		return '|', true
+	}
+	return 0, false
+}
+
+// parseXLSXOptions adds the metadata_sheet and summary_sheet query parameters to the export options
+func parseXLSXOptions(c *gin.Context, form *models.Form) (export.XLSXOptions, string) {
+	base, msg := parseExportOptions(c, form)
+	if msg != "" {
+		return export.XLSXOptions{}, msg
+	}
+	opts := export.XLSXOptions{Options: base}
+
+	var ok bool
+	if opts.MetadataSheet, ok = queryBool(c, "metadata_sheet"); !ok {
+		return opts, "Invalid metadata_sheet (expected true or false)"
+	}
+	if opts.SummarySheet, ok = queryBool(c, "summary_sheet"); !ok {
+		return opts, "Invalid summary_sheet (expected true or false)"
+	}
+	return opts, ""
+}
+
+// parseArchiveOptions adds the format of the data file to the CSV options
+func parseArchiveOptions(c *gin.Context, form *models.Form) (export.ArchiveOptions, string) {
+	csvOpts, msg := parseCSVOptions(c, form)
+	if msg != "" {
+		return export.ArchiveOptions{}, msg
+	}
+	opts := export.ArchiveOptions{CSVOptions: csvOpts, Format: export.FormatCSV}
+	switch f := c.Query("format"); f {
+	case "", export.FormatCSV:
+	case export.FormatJSON:
+		opts.Format = f
+	default:
+		return opts, "Invalid format (expected csv or json)"
+	}
+	return opts, ""
+}
+
+// queryBool parses an optional boolean query parameter, false if absent
+func queryBool(c *gin.Context, key string) (bool, bool) {
+	value := c.Query(key)
+	if value == "" {
+		return false, true
+	}
+	b, err := strconv.ParseBool(value)
+	return b, err == nil
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestSubmissionHandler_ExportCSV(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Export",
+		Fields: models.FormFields{
+			{ID: "h1", Type: models.FieldTypeHeading, Label: "Intro"},
+			{ID: "tools", Type: models.FieldTypeCheckbox, Label: "Tools"},
+		},
+	}
+	db.Create(form)
+	db.Create(&models.Submission{
+		FormID:   form.ID,
+		Data:     models.SubmissionData{"tools": []interface{}{"Mail", "Chat"}},
+		Metadata: models.SubmissionMetadata{UTMSource: "ads"},
+	})
+
+	handler := NewSubmissionHandler(nil, nil, nil)
+	export := func(userID, query string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/forms/:id/export/csv", func(c *gin.Context) {
+			c.Set("user_id", userID)
+			handler.ExportCSV(c)
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/forms/"+form.ID+"/export/csv"+query, nil))
+		return w
+	}
+
+	w := export(user.ID, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if lines[0] != "ID,Submitted At,Tools" || !strings.HasSuffix(lines[1], ",Mail; Chat") {
+		t.Errorf("unexpected default export:\n%s", w.Body.String())
+	}
+
+	w = export(user.ID, "?delimiter=tab&headers=ids&separator=/&utm=true&bom=true")
+	lines = strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if !strings.HasPrefix(lines[0], "\uFEFFid\tsubmitted_at\ttools\tutm_source\t") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "\tMail/Chat\tads") {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"tools": []interface{}{"Docs"}}})
+	w = export(user.ID, "?columns=tools,utm_source&filter[tools]=Chat")
+	if got := strings.TrimSpace(w.Body.String()); got != "Tools,UTM Source\nMail; Chat,ads" {
+		t.Errorf("unexpected filtered export:\n%s", got)
+	}
+	w = export(user.ID, "?columns=tools&from=2000-01-01&to=2000-12-31")
+	if got := strings.TrimSpace(w.Body.String()); got != "Tools" {
+		t.Errorf("expected only the header outside the date range, got:\n%s", got)
+	}
+
+	for _, query := range []string{"?delimiter=x", "?headers=names", "?metadata=maybe", "?separator=", "?columns=h1", "?filter[missing]=x", "?last=1y"} {
+		if w := export(user.ID, query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+	if w := export(other.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestSubmissionHandler_ExportXLSX(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Export",
+		Fields: models.FormFields{{ID: "age", Type: models.FieldTypeNumber, Label: "Age"}},
+	}
+	db.Create(form)
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"age": float64(36)}})
+
+	handler := NewSubmissionHandler(nil, nil, nil)
+	export := func(userID, query string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.GET("/forms/:id/export/xlsx", func(c *gin.Context) {
+			c.Set("user_id", userID)
+			handler.ExportXLSX(c)
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/forms/"+form.ID+"/export/xlsx"+query, nil))
+		return w
+	}
+
+	w := export(user.ID, "?headers=ids&summary_sheet=true")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet" {
+		t.Errorf("unexpected content type %s", ct)
+	}
+
+	f, err := excelize.OpenReader(w.Body)
+	if err != nil {
+		t.Fatalf("invalid XLSX: %v", err)
+	}
+	defer f.Close()
+	if sheets := f.GetSheetList(); len(sheets) != 2 || sheets[1] != "Summary" {
+		t.Errorf("expected submissions and summary sheets, got %v", sheets)
+	}
+	if v, _ := f.GetCellValue("Submissions", "C1"); v != "age" {
+		t.Errorf("expected id header, got %q", v)
+	}
+	if v, _ := f.GetCellValue("Submissions", "C2"); v != "36" {
+		t.Errorf("expected 36, got %q", v)
+	}
+
+	for _, query := range []string{"?summary_sheet=maybe", "?metadata_sheet=x", "?headers=names"} {
+		if w := export(user.ID, query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, w.Code)
+		}
+	}
+	if w := export(other.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"formera/internal/export"
+	"formera/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// submissionFilter narrows the submissions of a form by date range and answers
+type submissionFilter struct {
+	Range *timeRange
+	// Answers maps field IDs to the value the answer must equal or, for
+	// multi-value answers such as checkboxes, contain
+	Answers map[string]string
+}
+
+// parseSubmissionFilter reads the from, to and tz parameters, a relative range
+// such as last=7d and answer filters given as filter[<field ID>]=<value>.
+// Returns an error message for invalid input.
+func parseSubmissionFilter(c *gin.Context, form *models.Form) (*submissionFilter, string) {
+	r, msg := parseTimeRange(c)
+	if msg != "" {
+		return nil, msg
+	}
+	if last := c.Query("last"); last != "" {
+		if r.From != nil {
+			return nil, "last cannot be combined with from"
+		}
+		d, ok := parseLastPeriod(last)
+		if !ok {
+			return nil, "Invalid last (expected a number of hours, days or weeks such as 24h, 7d or 4w)"
+		}
+		from := time.Now().Add(-d)
+		if r.To != nil && !from.Before(*r.To) {
+			return nil, "from must be before to"
+		}
+		r.From = &from
+	}
+
+	f := &submissionFilter{Range: r, Answers: c.QueryMap("filter")}
+	for fieldID := range f.Answers {
+		field := form.Fields.Find(fieldID)
+		if field == nil || field.Type.IsLayout() || strings.Contains(fieldID, `"`) {
+			return nil, "Invalid filter field: " + fieldID
+		}
+	}
+	return f, ""
+}
+
+// parseLastPeriod parses a relative period of hours, days or weeks: "24h", "7d", "4w"
+func parseLastPeriod(value string) (time.Duration, bool) {
+	units := map[byte]time.Duration{'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if len(value) < 2 {
+		return 0, false
+	}
+	unit, ok := units[value[len(value)-1]]
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if !ok || err != nil || n <= 0 || n > 10000 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// export returns the filter in the serializable form used by exports
+func (f *submissionFilter) export() export.Filter {
+	return export.Filter{From: f.Range.From, To: f.Range.To, Answers: f.Answers}
+}
+
+// sql returns the condition and arguments selecting the matching rows of the submissions table
+func (f *submissionFilter) sql() (string, []interface{}) {
+	return f.export().SQL()
+}
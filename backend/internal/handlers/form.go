@@ -1,23 +1,32 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"regexp"
 	"strings"
 
+	"formera/internal/access"
 	"formera/internal/database"
 	"formera/internal/models"
 	"formera/internal/pagination"
 	"formera/internal/sanitizer"
+	"formera/internal/versions"
+	"formera/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
-type FormHandler struct{}
+type FormHandler struct {
+	webhooks *webhooks.Dispatcher
+}
 
-func NewFormHandler() *FormHandler {
-	return &FormHandler{}
+// NewFormHandler creates a new form handler. The webhook dispatcher may be nil
+// if deleted events should not be sent.
+func NewFormHandler(hooks *webhooks.Dispatcher) *FormHandler {
+	return &FormHandler{webhooks: hooks}
 }
 
 type CreateFormRequest struct {
@@ -62,6 +71,27 @@ func normalizeSlug(slug string) string {
 	return slug
 }
 
+// validateConfirmation ensures a confirmation receipt targets an email field of the form
+func validateConfirmation(fields models.FormFields, settings *models.FormSettings) string {
+	if !settings.SendConfirmation {
+		return ""
+	}
+	for _, field := range fields {
+		if field.ID == settings.ConfirmationEmailField {
+			if field.Type != models.FieldTypeEmail {
+				return "Confirmation email field must be an email field"
+			}
+			return ""
+		}
+	}
+	return "Confirmation email field not found"
+}
+
+// isValidPrivacy checks form privacy overrides; an empty IP storage mode inherits the instance default
+func isValidPrivacy(p *models.FormPrivacy) bool {
+	return p == nil || p.IPStorage == "" || p.IPStorage.IsValid()
+}
+
 // Create godoc
 // @Summary      Create form
 // @Description  Create a new form
@@ -83,6 +113,15 @@ func (h *FormHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if !isValidPrivacy(req.Settings.Privacy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IP storage mode"})
+		return
+	}
+	if msg := validateConfirmation(req.Fields, &req.Settings); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
 	form := &models.Form{
 		UserID:      userID,
 		Title:       sanitizer.StripHTML(req.Title),
@@ -102,7 +141,7 @@ func (h *FormHandler) Create(c *gin.Context) {
 
 // List godoc
 // @Summary      List forms
-// @Description  Get paginated list of user's forms
+// @Description  Get paginated list of the forms the user owns or that are shared with them
 // @Tags         Forms
 // @Produce      json
 // @Param        page query int false "Page number" default(1)
@@ -116,10 +155,10 @@ func (h *FormHandler) List(c *gin.Context) {
 	params := pagination.GetParams(c)
 
 	var totalItems int64
-	database.DB.Model(&models.Form{}).Where("user_id = ?", userID).Count(&totalItems)
+	database.DB.Model(&models.Form{}).Scopes(access.Scope(userID)).Count(&totalItems)
 
 	var forms []models.Form
-	if result := database.DB.Where("user_id = ?", userID).
+	if result := database.DB.Model(&models.Form{}).Scopes(access.Scope(userID)).
 		Order("created_at DESC").
 		Scopes(pagination.Paginate(params)).
 		Find(&forms); result.Error != nil {
@@ -127,6 +166,15 @@ func (h *FormHandler) List(c *gin.Context) {
 		return
 	}
 
+	roles, err := access.Roles(database.DB, forms, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch forms"})
+		return
+	}
+	for i := range forms {
+		forms[i].Role = roles[forms[i].ID]
+	}
+
 	c.JSON(http.StatusOK, pagination.CreateResult(forms, params, totalItems))
 }
 
@@ -142,15 +190,12 @@ func (h *FormHandler) List(c *gin.Context) {
 // @Security     BearerAuth
 // @Router       /forms/{id} [get]
 func (h *FormHandler) Get(c *gin.Context) {
-	userID := c.GetString("user_id")
-	formID := c.Param("id")
-
 	var form models.Form
-	if result := database.DB.Where("id = ? AND user_id = ?", formID, userID).First(&form); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
 		return
 	}
 
+	c.Header("ETag", form.ETag())
 	c.JSON(http.StatusOK, form)
 }
 
@@ -160,6 +205,10 @@ func (h *FormHandler) Get(c *gin.Context) {
 // @Tags         Public
 // @Produce      json
 // @Param        id path string true "Form ID or slug"
+// @Param        utm_source query string false "Traffic source of the view"
+// @Param        utm_medium query string false "Traffic medium of the view"
+// @Param        utm_campaign query string false "Campaign of the view"
+// @Param        referrer query string false "URL of the page linking to the form"
 // @Success      200 {object} models.Form
 // @Failure      404 {object} ErrorResponse
 // @Router       /public/forms/{id} [get]
@@ -173,6 +222,9 @@ func (h *FormHandler) GetPublic(c *gin.Context) {
 		return
 	}
 
+	recordFormEvent(form.ID, models.AnalyticsViews)
+	recordAttributionView(c, &form)
+
 	if form.PasswordProtected {
 		c.JSON(http.StatusOK, gin.H{
 			"id":                 form.ID,
@@ -288,26 +340,29 @@ func (h *FormHandler) CheckSlugAvailability(c *gin.Context) {
 
 // Update godoc
 // @Summary      Update form
-// @Description  Update a form by ID
+// @Description  Update a form by ID. Send the ETag of the edited revision in If-Match to avoid overwriting changes saved in the meantime; stale revisions are rejected with 409 and the current form.
 // @Tags         Forms
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "Form ID"
+// @Param        If-Match header string false "ETag of the edited revision"
 // @Param        request body UpdateFormRequest true "Update data"
 // @Success      200 {object} models.Form
 // @Failure      400 {object} ErrorResponse
 // @Failure      401 {object} ErrorResponse
 // @Failure      404 {object} ErrorResponse
-// @Failure      409 {object} ErrorResponse "Slug already taken"
+// @Failure      409 {object} StaleFormResponse "Slug already taken, or the form was changed since the given revision"
 // @Security     BearerAuth
 // @Router       /forms/{id} [put]
 func (h *FormHandler) Update(c *gin.Context) {
-	userID := c.GetString("user_id")
 	formID := c.Param("id")
 
 	var form models.Form
-	if result := database.DB.Where("id = ? AND user_id = ?", formID, userID).First(&form); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+	if !authorizeForm(c, &form, models.FormRoleEditor) {
+		return
+	}
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && !matchesETag(ifMatch, form.ETag()) {
+		respondStaleForm(c, &form)
 		return
 	}
 
@@ -317,6 +372,19 @@ func (h *FormHandler) Update(c *gin.Context) {
 		return
 	}
 
+	// Forms published before versioning get their unchanged fields as first version
+	if form.Status != models.FormStatusDraft && form.CurrentVersion == 0 {
+		if _, err := versions.Snapshot(database.DB, &form); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update form"})
+			return
+		}
+	}
+
+	if !isValidPrivacy(req.Settings.Privacy) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IP storage mode"})
+		return
+	}
+
 	if req.Title != "" {
 		form.Title = sanitizer.StripHTML(req.Title)
 	}
@@ -331,6 +399,11 @@ func (h *FormHandler) Update(c *gin.Context) {
 	}
 	form.Settings = req.Settings
 
+	if msg := validateConfirmation(form.Fields, &form.Settings); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
 	if req.Slug != nil {
 		slug := *req.Slug
 		if slug == "" {
@@ -368,14 +441,71 @@ func (h *FormHandler) Update(c *gin.Context) {
 		form.PasswordProtected = true
 	}
 
-	if result := database.DB.Save(&form); result.Error != nil {
+	revision := form.Revision
+	form.Revision++
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Claims the next revision, unless another update was saved since the form was loaded
+		result := tx.Model(&models.Form{}).Where("id = ? AND revision = ?", form.ID, revision).UpdateColumn("revision", form.Revision)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errStaleForm
+		}
+		if err := tx.Save(&form).Error; err != nil {
+			return err
+		}
+		// Drafts collect no submissions, so their edits need no version
+		if form.Status == models.FormStatusDraft {
+			return nil
+		}
+		_, err := versions.Snapshot(tx, &form)
+		return err
+	})
+	if errors.Is(err, errStaleForm) {
+		var current models.Form
+		if result := database.DB.First(&current, "id = ?", form.ID); result.Error != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+			return
+		}
+		current.Role = form.Role
+		respondStaleForm(c, &current)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update form"})
 		return
 	}
 
+	c.Header("ETag", form.ETag())
 	c.JSON(http.StatusOK, form)
 }
 
+// errStaleForm is returned when a form was updated concurrently
+var errStaleForm = errors.New("form was changed in the meantime")
+
+// matchesETag reports whether an If-Match header matches the entity tag.
+// Weak tags are compared by their value.
+func matchesETag(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// respondStaleForm responds with 409 and the current state of a form that was
+// changed since the client loaded it
+func respondStaleForm(c *gin.Context, current *models.Form) {
+	c.Header("ETag", current.ETag())
+	c.JSON(http.StatusConflict, gin.H{
+		"error": "Das Formular wurde inzwischen geändert. Bitte lade die aktuelle Version.",
+		"form":  current,
+	})
+}
+
 // Delete godoc
 // @Summary      Delete form
 // @Description  Delete a form and all its submissions
@@ -388,12 +518,10 @@ func (h *FormHandler) Update(c *gin.Context) {
 // @Security     BearerAuth
 // @Router       /forms/{id} [delete]
 func (h *FormHandler) Delete(c *gin.Context) {
-	userID := c.GetString("user_id")
 	formID := c.Param("id")
 
 	var form models.Form
-	if result := database.DB.Where("id = ? AND user_id = ?", formID, userID).First(&form); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+	if !authorizeForm(c, &form, models.FormRoleOwner) {
 		return
 	}
 
@@ -404,13 +532,52 @@ func (h *FormHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	// Delete all submissions first
+	// Delete all submissions first, keeping what the deleted events need
+	var submissions []models.Submission
+	tx.Select("id", "form_id", "created_at").Where("form_id = ?", formID).Find(&submissions)
 	if result := tx.Where("form_id = ?", formID).Delete(&models.Submission{}); result.Error != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete submissions"})
 		return
 	}
 
+	if err := deleteFormAnalytics(tx, []string{formID}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete analytics"})
+		return
+	}
+
+	// Files of background exports are removed by their scheduled cleanup
+	if result := tx.Where("form_id = ?", formID).Delete(&models.Export{}); result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete exports"})
+		return
+	}
+
+	if result := tx.Where("form_id = ?", formID).Delete(&models.ExportPreset{}); result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete export presets"})
+		return
+	}
+
+	if result := tx.Where("form_id = ?", formID).Delete(&models.ExportSchedule{}); result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete export schedules"})
+		return
+	}
+
+	if result := tx.Where("form_id = ?", formID).Delete(&models.FormVersion{}); result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete form versions"})
+		return
+	}
+
+	if result := tx.Where("form_id = ?", formID).Delete(&models.FormShare{}); result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete form shares"})
+		return
+	}
+
 	// Then delete the form
 	if result := tx.Delete(&form); result.Error != nil {
 		tx.Rollback()
@@ -424,6 +591,9 @@ func (h *FormHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	// Webhooks are removed after the commit, once they received the deleted events
+	dispatchFormsDeleted(h.webhooks, []models.Form{form}, submissions)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Form deleted successfully"})
 }
 
@@ -440,11 +610,9 @@ func (h *FormHandler) Delete(c *gin.Context) {
 // @Router       /forms/{id}/duplicate [post]
 func (h *FormHandler) Duplicate(c *gin.Context) {
 	userID := c.GetString("user_id")
-	formID := c.Param("id")
 
 	var originalForm models.Form
-	if result := database.DB.Where("id = ? AND user_id = ?", formID, userID).First(&originalForm); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+	if !authorizeForm(c, &originalForm, models.FormRoleViewer) {
 		return
 	}
 
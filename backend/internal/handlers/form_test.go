@@ -21,7 +21,7 @@ func init() {
 func TestFormHandler_Create(t *testing.T) {
 	testutil.SetupTestDB(t)
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.POST("/forms", func(c *gin.Context) {
 		c.Set("user_id", "test-user-id")
@@ -62,7 +62,7 @@ func TestFormHandler_Create(t *testing.T) {
 func TestFormHandler_Create_SanitizesXSS(t *testing.T) {
 	testutil.SetupTestDB(t)
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.POST("/forms", func(c *gin.Context) {
 		c.Set("user_id", "test-user-id")
@@ -112,7 +112,7 @@ func TestFormHandler_List(t *testing.T) {
 	db.Create(form1)
 	db.Create(form2)
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.GET("/forms", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -148,7 +148,7 @@ func TestFormHandler_List_Pagination(t *testing.T) {
 		db.Create(form)
 	}
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.GET("/forms", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -181,7 +181,7 @@ func TestFormHandler_Get(t *testing.T) {
 	form := &models.Form{UserID: user.ID, Title: "Test Form", Status: models.FormStatusDraft}
 	db.Create(form)
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.GET("/forms/:id", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -211,7 +211,7 @@ func TestFormHandler_Get_NotFound(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.GET("/forms/:id", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -236,7 +236,7 @@ func TestFormHandler_Get_WrongUser(t *testing.T) {
 	form := &models.Form{UserID: owner.ID, Title: "Test Form", Status: models.FormStatusDraft}
 	db.Create(form)
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.GET("/forms/:id", func(c *gin.Context) {
 		c.Set("user_id", otherUser.ID) // Different user trying to access
@@ -260,7 +260,7 @@ func TestFormHandler_Update(t *testing.T) {
 	form := &models.Form{UserID: user.ID, Title: "Original Title", Status: models.FormStatusDraft}
 	db.Create(form)
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.PUT("/forms/:id", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -296,6 +296,79 @@ func TestFormHandler_Update(t *testing.T) {
 	}
 }
 
+func TestFormHandler_Update_IfMatch(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{UserID: user.ID, Title: "Original Title", Status: models.FormStatusDraft}
+	db.Create(form)
+	if form.Revision != 1 {
+		t.Fatalf("expected revision 1 for a new form, got %d", form.Revision)
+	}
+
+	handler := NewFormHandler(nil)
+	router := gin.New()
+	router.PUT("/forms/:id", func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		handler.Update(c)
+	})
+	update := func(title, ifMatch string) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(UpdateFormRequest{Title: title})
+		req := httptest.NewRequest(http.MethodPut, "/forms/"+form.ID, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Two editors load revision 1; the first save wins
+	w := update("First editor", `"1"`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if etag := w.Header().Get("ETag"); etag != `"2"` {
+		t.Errorf("expected ETag \"2\", got %q", etag)
+	}
+
+	w = update("Second editor", `"1"`)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	var conflict struct {
+		Form models.Form `json:"form"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &conflict)
+	if conflict.Form.Title != "First editor" || conflict.Form.Revision != 2 || w.Header().Get("ETag") != `"2"` {
+		t.Errorf("expected the current form in the conflict response, got %+v", conflict.Form)
+	}
+
+	var stored models.Form
+	db.First(&stored, "id = ?", form.ID)
+	if stored.Title != "First editor" {
+		t.Errorf("expected the stale update to be rejected, got title %q", stored.Title)
+	}
+
+	for _, ifMatch := range []string{`W/"2"`, `"1", "2"`, "*", ""} {
+		w = update("Edit "+ifMatch, ifMatch)
+		if ifMatch == `"1", "2"` {
+			if w.Code != http.StatusConflict {
+				t.Errorf("If-Match %s: expected status %d, got %d", ifMatch, http.StatusConflict, w.Code)
+			}
+			continue
+		}
+		if w.Code != http.StatusOK {
+			t.Errorf("If-Match %s: expected status %d, got %d: %s", ifMatch, http.StatusOK, w.Code, w.Body.String())
+		}
+	}
+	db.First(&stored, "id = ?", form.ID)
+	if stored.Revision != 5 {
+		t.Errorf("expected revision 5 after four updates, got %d", stored.Revision)
+	}
+}
+
 func TestFormHandler_Delete(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
@@ -307,7 +380,7 @@ func TestFormHandler_Delete(t *testing.T) {
 	submission := &models.Submission{FormID: form.ID, Data: map[string]interface{}{"field1": "value1"}}
 	db.Create(submission)
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.DELETE("/forms/:id", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -350,7 +423,7 @@ func TestFormHandler_Duplicate(t *testing.T) {
 	}
 	db.Create(form)
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.POST("/forms/:id/duplicate", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -394,7 +467,7 @@ func TestFormHandler_GetPublic(t *testing.T) {
 	}
 	db.Create(form)
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.GET("/public/forms/:id", handler.GetPublic)
 
@@ -428,7 +501,7 @@ func TestFormHandler_GetPublic_Draft(t *testing.T) {
 	}
 	db.Create(form)
 
-	handler := NewFormHandler()
+	handler := NewFormHandler(nil)
 	router := gin.New()
 	router.GET("/public/forms/:id", handler.GetPublic)
 
@@ -440,3 +513,37 @@ func TestFormHandler_GetPublic_Draft(t *testing.T) {
 		t.Errorf("expected status %d for draft form, got %d", http.StatusNotFound, w.Code)
 	}
 }
+
+func TestFormHandler_Update_ConfirmationFieldMustBeEmail(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Test Form",
+		Fields: models.FormFields{{ID: "name", Label: "Name", Type: models.FieldTypeText}},
+	}
+	db.Create(form)
+
+	handler := NewFormHandler(nil)
+	router := gin.New()
+	router.PUT("/forms/:id", func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		handler.Update(c)
+	})
+
+	body := UpdateFormRequest{
+		Settings: models.FormSettings{SendConfirmation: true, ConfirmationEmailField: "name"},
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPut, "/forms/"+form.ID, bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
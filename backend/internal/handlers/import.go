@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"formera/internal/database"
+	"formera/internal/importer"
+	"formera/internal/logger"
+	"formera/internal/models"
+	"formera/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportResponse reports the outcome of an import. Error is set if rows are invalid.
+type ImportResponse struct {
+	importer.Result
+	Error string `json:"error,omitempty"`
+}
+
+// Import godoc
+// @Summary      Import submissions
+// @Description  Import submissions from a CSV file with a header row or a JSON array of objects, such as the exports of a form. The mapping assigns columns or JSON keys to field IDs or "submitted_at"; without it, columns named like a field ID, a field label or the submission time are mapped. Values are checked against the field types and submission times are preserved. If any row is invalid nothing is imported and the errors are listed per row. Imported submissions trigger no notifications or webhooks.
+// @Tags         Submissions
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        file formData file true "CSV or JSON file"
+// @Param        mapping formData string false "JSON object of columns to field IDs, e.g. {\"E-Mail\": \"email\", \"Date\": \"submitted_at\"}"
+// @Param        format query string false "csv or json, detected from the file name if empty"
+// @Param        delimiter query string false "CSV delimiter: comma, semicolon, tab or pipe" default(comma)
+// @Param        separator query string false "Separator of multiple checkbox options or files in a CSV cell" default(;)
+// @Param        dry_run query bool false "Only validate the file"
+// @Success      200 {object} ImportResponse "Dry run"
+// @Success      201 {object} ImportResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      422 {object} ImportResponse "Invalid rows"
+// @Security     BearerAuth
+// @Router       /forms/{id}/submissions/import [post]
+func (h *SubmissionHandler) Import(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleEditor) {
+		return
+	}
+
+	dryRun, ok := queryBool(c, "dry_run")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dry_run (expected true or false)"})
+		return
+	}
+	delimiter, ok := parseDelimiter(c.Query("delimiter"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delimiter (expected comma, semicolon, tab or pipe)"})
+		return
+	}
+	sep := c.Query("separator")
+	if utf8.RuneCountInString(sep) > maxSeparatorLength || (sep != "" && strings.TrimSpace(sep) == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "separator must be 1 to 10 characters"})
+		return
+	}
+
+	upload, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		return
+	}
+	defer upload.Close()
+	if header.Size > storage.MaxFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File too large. Maximum size: %d MB", storage.MaxFileSize/(1024*1024))})
+		return
+	}
+
+	var mapping map[string]string
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping (expected a JSON object of columns to field IDs)"})
+			return
+		}
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(path.Ext(header.Filename)), ".")
+	}
+	var file *importer.File
+	switch format {
+	case "csv":
+		file, err = importer.ReadCSV(upload, delimiter)
+	case "json":
+		file, err = importer.ReadJSON(upload)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format (expected csv or json)"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file: " + err.Error()})
+		return
+	}
+
+	if mapping, err = importer.ResolveMapping(&form, file.Columns, mapping); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping: " + err.Error()})
+		return
+	}
+
+	result, err := importer.Import(database.DB, &form, file, importer.Options{
+		Mapping:             mapping,
+		MultiValueSeparator: sep,
+		DryRun:              dryRun,
+	})
+	if err != nil {
+		logger.Error().Err(err).Str("form_id", form.ID).Msg("Failed to import submissions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import submissions"})
+		return
+	}
+
+	resp := ImportResponse{Result: *result}
+	switch {
+	case result.Invalid > 0:
+		resp.Error = fmt.Sprintf("%d of %d rows are invalid, nothing was imported", result.Invalid, result.Rows)
+		c.JSON(http.StatusUnprocessableEntity, resp)
+	case dryRun:
+		c.JSON(http.StatusOK, resp)
+	default:
+		logger.Info().Str("form_id", form.ID).Int("submissions", result.Imported).Msg("Submissions imported")
+		c.JSON(http.StatusCreated, resp)
+	}
+}
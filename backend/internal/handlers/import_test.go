@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSubmissionHandler_Import(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Registrations",
+		Fields: models.FormFields{
+			{ID: "name", Type: models.FieldTypeText, Label: "Name", Required: true},
+			{ID: "email", Type: models.FieldTypeEmail, Label: "Email"},
+		},
+	}
+	db.Create(form)
+
+	handler := NewSubmissionHandler(nil, nil, nil)
+	serve := func(userID, query, filename, content, mapping string) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.POST("/forms/:id/submissions/import", func(c *gin.Context) {
+			c.Set("user_id", userID)
+			handler.Import(c)
+		})
+
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, _ := mw.CreateFormFile("file", filename)
+		fw.Write([]byte(content))
+		if mapping != "" {
+			mw.WriteField("mapping", mapping)
+		}
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/forms/"+form.ID+"/submissions/import"+query, &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	count := func() int64 {
+		var n int64
+		db.Model(&models.Submission{}).Where("form_id = ?", form.ID).Count(&n)
+		return n
+	}
+
+	csv := "Full name;Mail;Date\nAda;ada@example.com;2024-03-01T08:00:00Z\nBob;bob at example.com;2024-03-02T08:00:00Z\n"
+	mapping := `{"Full name": "name", "Mail": "email", "Date": "submitted_at"}`
+
+	w := serve(user.ID, "?delimiter=semicolon&dry_run=true", "old.csv", csv, mapping)
+	var resp ImportResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusUnprocessableEntity || resp.Invalid != 1 || len(resp.Errors) != 1 || resp.Errors[0].Row != 3 || resp.Errors[0].Column != "Mail" {
+		t.Fatalf("expected the invalid row to be reported, got %d: %s", w.Code, w.Body.String())
+	}
+
+	csv = "Full name;Mail;Date\nAda;ada@example.com;2024-03-01T08:00:00Z\nBob;bob@example.com;2024-03-02T08:00:00Z\n"
+	if w := serve(user.ID, "?delimiter=semicolon&dry_run=true", "old.csv", csv, mapping); w.Code != http.StatusOK || count() != 0 {
+		t.Fatalf("expected a dry run without changes, got %d: %s", w.Code, w.Body.String())
+	}
+	w = serve(user.ID, "?delimiter=semicolon", "old.csv", csv, mapping)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusCreated || resp.Imported != 2 || count() != 2 {
+		t.Fatalf("expected two imported submissions, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// JSON keys matching field IDs are mapped without a mapping
+	w = serve(user.ID, "", "backup.json", `[{"name": "Eve", "email": "eve@example.com", "submitted_at": "2024-03-03T08:00:00Z"}]`, "")
+	if w.Code != http.StatusCreated || count() != 3 {
+		t.Errorf("expected JSON import, got %d: %s", w.Code, w.Body.String())
+	}
+
+	invalid := []struct{ query, filename, content, mapping string }{
+		{"", "data.xml", "<data/>", ""},
+		{"?delimiter=colon", "data.csv", "name\nAda\n", ""},
+		{"", "data.csv", "email\nada@example.com\n", ""},
+		{"", "data.csv", "Name\nAda\n", `{"Name": "unknown"}`},
+		{"", "data.json", `{"name": "Ada"}`, ""},
+	}
+	for _, tt := range invalid {
+		if w := serve(user.ID, tt.query, tt.filename, tt.content, tt.mapping); w.Code != http.StatusBadRequest {
+			t.Errorf("%s %q: expected status %d, got %d: %s", tt.filename, tt.content, http.StatusBadRequest, w.Code, w.Body.String())
+		}
+	}
+
+	if w := serve(other.ID, "", "data.csv", "name\nMallory\n", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, w.Code)
+	}
+}
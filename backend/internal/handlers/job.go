@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"formera/internal/database"
+	"formera/internal/jobs"
+	"formera/internal/models"
+	"formera/internal/pagination"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type JobHandler struct {
+	queue *jobs.Queue
+}
+
+func NewJobHandler(queue *jobs.Queue) *JobHandler {
+	return &JobHandler{queue: queue}
+}
+
+// List godoc
+// @Summary      List background jobs
+// @Description  Get paginated background jobs, newest first (admin only)
+// @Tags         Jobs
+// @Produce      json
+// @Param        status query string false "Filter by status (pending, running, succeeded, dead)"
+// @Param        type query string false "Filter by job type"
+// @Param        page query int false "Page number" default(1)
+// @Param        page_size query int false "Items per page" default(20)
+// @Success      200 {object} pagination.Result
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse "Admin access required"
+// @Security     BearerAuth
+// @Router       /jobs [get]
+func (h *JobHandler) List(c *gin.Context) {
+	params := pagination.GetParams(c)
+
+	query := database.DB.Model(&models.Job{})
+	if status := c.Query("status"); status != "" {
+		if !models.JobStatus(status).IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job status"})
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+	if jobType := c.Query("type"); jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+
+	var totalItems int64
+	query.Count(&totalItems)
+
+	var list []models.Job
+	if result := query.Order("created_at DESC").
+		Scopes(pagination.Paginate(params)).
+		Find(&list); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.CreateResult(list, params, totalItems))
+}
+
+// Get godoc
+// @Summary      Get background job
+// @Description  Get a background job including its payload and last error (admin only)
+// @Tags         Jobs
+// @Produce      json
+// @Param        id path string true "Job ID"
+// @Success      200 {object} models.Job
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse "Admin access required"
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /jobs/{id} [get]
+func (h *JobHandler) Get(c *gin.Context) {
+	var job models.Job
+	if result := database.DB.First(&job, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// Retry godoc
+// @Summary      Retry background job
+// @Description  Run a dead or pending job again immediately with a fresh set of attempts (admin only)
+// @Tags         Jobs
+// @Produce      json
+// @Param        id path string true "Job ID"
+// @Success      200 {object} models.Job
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse "Admin access required"
+// @Failure      404 {object} ErrorResponse
+// @Failure      409 {object} ErrorResponse "Job is running or already succeeded"
+// @Security     BearerAuth
+// @Router       /jobs/{id}/retry [post]
+func (h *JobHandler) Retry(c *gin.Context) {
+	job, err := h.queue.Retry(c.Param("id"))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	case errors.Is(err, jobs.ErrNotRetryable):
+		c.JSON(http.StatusConflict, gin.H{"error": "Only pending or dead jobs can be retried"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry job"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
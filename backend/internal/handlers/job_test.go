@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"formera/internal/jobs"
+	"formera/internal/models"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestJobHandler_Retry(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	dead := &models.Job{Type: "mail.send", Status: models.JobStatusDead, Attempts: 5, MaxAttempts: 5, RunAt: time.Now(), LastError: "timeout"}
+	done := &models.Job{Type: "mail.send", Status: models.JobStatusSucceeded, Attempts: 1, MaxAttempts: 5, RunAt: time.Now()}
+	db.Create(dead)
+	db.Create(done)
+
+	handler := NewJobHandler(jobs.New(db, jobs.Config{}))
+	router := gin.New()
+	router.POST("/jobs/:id/retry", handler.Retry)
+
+	tests := []struct {
+		name   string
+		id     string
+		status int
+	}{
+		{"dead job", dead.ID, http.StatusOK},
+		{"succeeded job", done.ID, http.StatusConflict},
+		{"unknown job", "missing", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/jobs/"+tt.id+"/retry", nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	db.First(dead, "id = ?", dead.ID)
+	if dead.Status != models.JobStatusPending || dead.Attempts != 0 {
+		t.Errorf("expected retried job to be pending, got %+v", dead)
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"formera/internal/database"
+	"formera/internal/export"
+	"formera/internal/logger"
+	"formera/internal/models"
+	"formera/internal/storage"
+	"formera/internal/versions"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBulkPDFs limits the number of submissions in a bulk PDF export, which is
+// rendered while the response is streamed
+const maxBulkPDFs = 1000
+
+// PDFHandler renders submissions as printable PDFs
+type PDFHandler struct {
+	storage storage.Storage
+}
+
+// NewPDFHandler creates a new PDF handler. Uploaded images and signatures are
+// loaded from store, which may be nil to list uploads by filename only.
+func NewPDFHandler(store storage.Storage) *PDFHandler {
+	return &PDFHandler{storage: store}
+}
+
+// Submission godoc
+// @Summary      Export submission as PDF
+// @Description  Render a single submission as a printable PDF with the form title, headings and answers in field order. Signatures and uploaded images are embedded.
+// @Tags         Submissions
+// @Produce      application/pdf
+// @Param        id path string true "Form ID"
+// @Param        submissionId path string true "Submission ID"
+// @Param        columns query string false "Comma-separated field IDs to include (default: all fields)"
+// @Param        preset query string false "Export preset ID whose parameters apply unless given explicitly"
+// @Success      200 {file} file "PDF file"
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Failure      500 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/submissions/{submissionId}/pdf [get]
+func (h *PDFHandler) Submission(c *gin.Context) {
+	formID := c.Param("id")
+	submissionID := c.Param("submissionId")
+
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+	if !applyExportPreset(c, &form) {
+		return
+	}
+	columns, msg := parseColumns(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var submission models.Submission
+	if result := database.DB.Where("id = ? AND form_id = ?", submissionID, formID).First(&submission); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+
+	// Rendered with the fields the submission answered
+	fields, err := versions.NewResolver(database.DB, &form).Fields(submission.FormVersion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load form version"})
+		return
+	}
+	versioned := form
+	versioned.Fields = fields
+
+	// Render before sending headers so failures can still be reported
+	var buf bytes.Buffer
+	if err := export.WritePDF(&buf, &versioned, &submission, h.storage, export.PDFOptions{Fields: columns}); err != nil {
+		logger.Error().Err(err).Str("submission_id", submissionID).Msg("PDF export failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render PDF"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", submission.ID))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// Bulk godoc
+// @Summary      Export submissions as PDFs
+// @Description  Download a ZIP archive with one PDF per submission, named by submission ID.
+// @Description  At most 1000 submissions can be exported at once; narrow larger selections by date or answers.
+// @Tags         Submissions
+// @Produce      application/zip
+// @Param        id path string true "Form ID"
+// @Param        ids query string false "Comma-separated submission IDs (default: all submissions)"
+// @Param        columns query string false "Comma-separated field IDs to include (default: all fields)"
+// @Param        from query string false "Start date (YYYY-MM-DD or RFC 3339)"
+// @Param        to query string false "End date, inclusive for YYYY-MM-DD"
+// @Param        last query string false "Relative range ending now, such as 24h, 7d or 4w"
+// @Param        tz query string false "IANA time zone of dates" default(UTC)
+// @Param        filter[field_id] query string false "Only submissions whose answer to the field equals or contains the value"
+// @Param        preset query string false "Export preset ID whose parameters apply unless given explicitly"
+// @Success      200 {file} file "ZIP archive"
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/export/pdf [get]
+func (h *PDFHandler) Bulk(c *gin.Context) {
+	formID := c.Param("id")
+
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+	if !applyExportPreset(c, &form) {
+		return
+	}
+
+	columns, msg := parseColumns(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	query, _, msg := exportQuery(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if raw := c.Query("ids"); raw != "" {
+		var ids []string
+		for _, id := range strings.Split(raw, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) > maxBulkPDFs {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d submission IDs are allowed", maxBulkPDFs)})
+			return
+		}
+		query = query.Where("id IN ?", ids)
+	}
+
+	var count int64
+	if err := query.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count submissions"})
+		return
+	}
+	if count > maxBulkPDFs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d submissions can be exported as PDFs at once, narrow the date range or filter", maxBulkPDFs)})
+		return
+	}
+
+	// Rendering many PDFs outlasts the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-submissions-pdf.zip", form.ID))
+
+	opts := export.PDFOptions{Fields: columns}
+	zw := zip.NewWriter(c.Writer)
+	defer zw.Close()
+
+	resolver := versions.NewResolver(database.DB, &form)
+	err := export.Each(query, func(sub *models.Submission) error {
+		fields, err := resolver.Fields(sub.FormVersion)
+		if err != nil {
+			return err
+		}
+		versioned := form
+		versioned.Fields = fields
+		w, err := zw.Create(sub.ID + ".pdf")
+		if err != nil {
+			return err
+		}
+		return export.WritePDF(w, &versioned, sub, h.storage, opts)
+	})
+	if err != nil {
+		// Headers are already sent, so the truncated download is all the client sees
+		logger.Error().Err(err).Str("form_id", formID).Msg("Bulk PDF export failed")
+	}
+}
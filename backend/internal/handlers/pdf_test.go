@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPDFHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Application",
+		Fields: models.FormFields{{ID: "name", Type: models.FieldTypeText, Label: "Name"}},
+	}
+	db.Create(form)
+	subs := []*models.Submission{
+		{FormID: form.ID, Data: models.SubmissionData{"name": "Ada"}},
+		{FormID: form.ID, Data: models.SubmissionData{"name": "Grace"}},
+	}
+	for _, sub := range subs {
+		db.Create(sub)
+	}
+
+	handler := NewPDFHandler(nil)
+	serve := func(userID, url string) *httptest.ResponseRecorder {
+		router := gin.New()
+		setUser := func(c *gin.Context) { c.Set("user_id", userID) }
+		router.GET("/forms/:id/submissions/:submissionId/pdf", setUser, handler.Submission)
+		router.GET("/forms/:id/export/pdf", setUser, handler.Bulk)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	w := serve(user.ID, "/forms/"+form.ID+"/submissions/"+subs[0].ID+"/pdf")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/pdf" || !strings.HasPrefix(w.Body.String(), "%PDF-") {
+		t.Errorf("expected a PDF, got %s", ct)
+	}
+
+	w = serve(user.ID, "/forms/"+form.ID+"/export/pdf?ids="+subs[1].ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("invalid ZIP: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != subs[1].ID+".pdf" {
+		t.Errorf("expected a single PDF for the selected submission, got %d files", len(zr.File))
+	}
+
+	w = serve(user.ID, "/forms/"+form.ID+"/export/pdf")
+	zr, _ = zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if zr == nil || len(zr.File) != 2 {
+		t.Errorf("expected a PDF per submission")
+	}
+
+	// Larger selections are rejected instead of being cut off by the write timeout
+	more := make([]*models.Submission, maxBulkPDFs)
+	for i := range more {
+		more[i] = &models.Submission{FormID: form.ID, Data: models.SubmissionData{"name": "Anon"}}
+	}
+	db.CreateInBatches(more, 200)
+	if w := serve(user.ID, "/forms/"+form.ID+"/export/pdf"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for more than %d submissions, got %d", http.StatusBadRequest, maxBulkPDFs, w.Code)
+	}
+
+	notFound := []struct{ userID, url string }{
+		{other.ID, "/forms/" + form.ID + "/submissions/" + subs[0].ID + "/pdf"},
+		{other.ID, "/forms/" + form.ID + "/export/pdf"},
+		{user.ID, "/forms/" + form.ID + "/submissions/missing/pdf"},
+	}
+	for _, tt := range notFound {
+		if w := serve(tt.userID, tt.url); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", tt.url, http.StatusNotFound, w.Code)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"formera/internal/database"
+	"formera/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPresetNameLength limits the name of an export preset
+const maxPresetNameLength = 100
+
+// ExportPresetRequest creates or replaces an export preset
+type ExportPresetRequest struct {
+	Name   string              `json:"name" binding:"required"`
+	Format string              `json:"format" binding:"required"` // csv, json, xlsx, zip or pdf
+	Params models.ExportParams `json:"params"`                    // Query parameters of the export, e.g. {"columns": "email,name", "last": "7d"}
+}
+
+// ListPresets godoc
+// @Summary      List export presets
+// @Description  Get the saved export configurations of a form, ordered by name
+// @Tags         Exports
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Success      200 {array} models.ExportPreset
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/export-presets [get]
+func (h *ExportHandler) ListPresets(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	var presets []models.ExportPreset
+	if result := database.DB.Where("form_id = ?", form.ID).Order("name").Find(&presets); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch export presets"})
+		return
+	}
+
+	c.JSON(http.StatusOK, presets)
+}
+
+// CreatePreset godoc
+// @Summary      Create export preset
+// @Description  Save an export configuration of a form. The parameters are the query parameters of the export endpoint of the format and are applied with ?preset=<id>.
+// @Tags         Exports
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        request body ExportPresetRequest true "Export preset"
+// @Success      201 {object} models.ExportPreset
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/export-presets [post]
+func (h *ExportHandler) CreatePreset(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleEditor) {
+		return
+	}
+
+	var req ExportPresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateExportPreset(&form, &req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	preset := &models.ExportPreset{
+		FormID: form.ID,
+		UserID: userID,
+		Name:   strings.TrimSpace(req.Name),
+		Format: req.Format,
+		Params: req.Params,
+	}
+	if result := database.DB.Create(preset); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create export preset"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, preset)
+}
+
+// UpdatePreset godoc
+// @Summary      Update export preset
+// @Description  Replace the name, format and parameters of an export preset
+// @Tags         Exports
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        presetId path string true "Export preset ID"
+// @Param        request body ExportPresetRequest true "Export preset"
+// @Success      200 {object} models.ExportPreset
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/export-presets/{presetId} [put]
+func (h *ExportHandler) UpdatePreset(c *gin.Context) {
+	form, preset, ok := h.findPreset(c)
+	if !ok {
+		return
+	}
+
+	var req ExportPresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateExportPreset(form, &req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	preset.Name = strings.TrimSpace(req.Name)
+	preset.Format = req.Format
+	preset.Params = req.Params
+	if result := database.DB.Save(preset); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update export preset"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preset)
+}
+
+// DeletePreset godoc
+// @Summary      Delete export preset
+// @Description  Delete a saved export configuration
+// @Tags         Exports
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        presetId path string true "Export preset ID"
+// @Success      200 {object} MessageResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/export-presets/{presetId} [delete]
+func (h *ExportHandler) DeletePreset(c *gin.Context) {
+	_, preset, ok := h.findPreset(c)
+	if !ok {
+		return
+	}
+
+	if result := database.DB.Delete(preset); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete export preset"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Export preset deleted successfully"})
+}
+
+// findPreset loads an export preset of a form the current user may edit, or responds with 404
+func (h *ExportHandler) findPreset(c *gin.Context) (*models.Form, *models.ExportPreset, bool) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleEditor) {
+		return nil, nil, false
+	}
+
+	var preset models.ExportPreset
+	if result := database.DB.Where("id = ? AND form_id = ?", c.Param("presetId"), form.ID).First(&preset); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export preset not found"})
+		return nil, nil, false
+	}
+	return &form, &preset, true
+}
+
+// validateExportPreset checks the parameters of a preset with the parsers of
+// its export endpoint, so that applying it cannot fail later on
+func validateExportPreset(form *models.Form, req *ExportPresetRequest) string {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxPresetNameLength {
+		return "name must be 1 to 100 characters"
+	}
+	if !exportFormats[req.Format] {
+		return "Invalid format (expected csv, json, xlsx, zip or pdf)"
+	}
+
+	if _, ok := req.Params["preset"]; ok {
+		return "Presets cannot reference other presets"
+	}
+	c := paramsContext(req.Params)
+
+	if _, msg := parseSubmissionFilter(c, form); msg != "" {
+		return msg
+	}
+	var msg string
+	switch req.Format {
+	case "csv":
+		_, msg = parseCSVOptions(c, form)
+	case "json":
+		_, msg = parseExportOptions(c, form)
+	case "xlsx":
+		_, msg = parseXLSXOptions(c, form)
+	case "zip":
+		if _, msg = parseArchiveOptions(c, form); msg == "" {
+			if _, ok := queryBool(c, "async"); !ok {
+				msg = "Invalid async (expected true or false)"
+			}
+		}
+	case "pdf":
+		_, msg = parseColumns(c, form)
+	}
+	return msg
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExportHandler_Presets(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Export",
+		Fields: models.FormFields{
+			{ID: "name", Type: models.FieldTypeText, Label: "Name"},
+			{ID: "plan", Type: models.FieldTypeSelect, Label: "Plan"},
+		},
+	}
+	db.Create(form)
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"name": "Ada", "plan": "pro"}})
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"name": "Bob", "plan": "free"}})
+
+	handler := NewExportHandler(nil, nil)
+	submissions := NewSubmissionHandler(nil, nil, nil)
+	serve := func(userID, method, url string, body interface{}) *httptest.ResponseRecorder {
+		router := gin.New()
+		setUser := func(c *gin.Context) { c.Set("user_id", userID) }
+		router.GET("/forms/:id/export-presets", setUser, handler.ListPresets)
+		router.POST("/forms/:id/export-presets", setUser, handler.CreatePreset)
+		router.PUT("/forms/:id/export-presets/:presetId", setUser, handler.UpdatePreset)
+		router.DELETE("/forms/:id/export-presets/:presetId", setUser, handler.DeletePreset)
+		router.GET("/forms/:id/export/csv", setUser, submissions.ExportCSV)
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, url, &buf))
+		return w
+	}
+	base := "/forms/" + form.ID + "/export-presets"
+
+	w := serve(user.ID, http.MethodPost, base, ExportPresetRequest{
+		Name:   "Pro names",
+		Format: "csv",
+		Params: models.ExportParams{"columns": "name", "filter[plan]": "pro", "headers": "ids"},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var preset models.ExportPreset
+	json.Unmarshal(w.Body.Bytes(), &preset)
+
+	w = serve(user.ID, http.MethodGet, "/forms/"+form.ID+"/export/csv?preset="+preset.ID, nil)
+	if got := strings.TrimSpace(w.Body.String()); got != "name\nAda" {
+		t.Errorf("unexpected export with preset:\n%s", got)
+	}
+	// Explicit parameters take precedence over the preset
+	w = serve(user.ID, http.MethodGet, "/forms/"+form.ID+"/export/csv?preset="+preset.ID+"&headers=labels&filter[plan]=free", nil)
+	if got := strings.TrimSpace(w.Body.String()); got != "Name\nBob" {
+		t.Errorf("unexpected export with overridden preset:\n%s", got)
+	}
+	if w := serve(user.ID, http.MethodGet, "/forms/"+form.ID+"/export/csv?preset=missing", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for unknown preset, got %d", http.StatusNotFound, w.Code)
+	}
+
+	invalid := []ExportPresetRequest{
+		{Name: " ", Format: "csv"},
+		{Name: "Report", Format: "docx"},
+		{Name: "Report", Format: "csv", Params: models.ExportParams{"columns": "missing"}},
+		{Name: "Report", Format: "csv", Params: models.ExportParams{"filter[missing]": "x"}},
+		{Name: "Report", Format: "csv", Params: models.ExportParams{"delimiter": "x"}},
+		{Name: "Report", Format: "zip", Params: models.ExportParams{"format": "xml"}},
+		{Name: "Report", Format: "csv", Params: models.ExportParams{"preset": preset.ID}},
+	}
+	for _, req := range invalid {
+		if w := serve(user.ID, http.MethodPost, base, req); w.Code != http.StatusBadRequest {
+			t.Errorf("%+v: expected status %d, got %d", req, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	w = serve(user.ID, http.MethodPut, base+"/"+preset.ID, ExportPresetRequest{Name: "Weekly", Format: "xlsx", Params: models.ExportParams{"last": "7d"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	w = serve(user.ID, http.MethodGet, base, nil)
+	var presets []models.ExportPreset
+	json.Unmarshal(w.Body.Bytes(), &presets)
+	if len(presets) != 1 || presets[0].Name != "Weekly" || presets[0].Params["last"] != "7d" || presets[0].Params["columns"] != "" {
+		t.Errorf("unexpected presets %+v", presets)
+	}
+
+	if w := serve(other.ID, http.MethodGet, base, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := serve(other.ID, http.MethodDelete, base+"/"+preset.ID, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := serve(user.ID, http.MethodDelete, base+"/"+preset.ID, nil); w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var count int64
+	db.Model(&models.ExportPreset{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected preset to be deleted, %d left", count)
+	}
+}
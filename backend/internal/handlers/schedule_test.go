@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"formera/internal/export"
+	"formera/internal/jobs"
+	"formera/internal/models"
+	"formera/internal/storage"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExportHandler_Schedules(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Registrations",
+		Fields: models.FormFields{{ID: "name", Type: models.FieldTypeText, Label: "Name"}},
+	}
+	db.Create(form)
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"name": "Ada"}, CreatedAt: time.Now().Add(-time.Hour)})
+
+	store, _ := storage.NewLocalStorage(t.TempDir(), "http://localhost/uploads")
+	queue := jobs.New(db, jobs.Config{})
+	runner := export.NewRunner(db, store)
+	runner.Register(queue)
+	handler := NewExportHandler(runner, store)
+
+	serve := func(userID, method, url string, body interface{}) *httptest.ResponseRecorder {
+		router := gin.New()
+		setUser := func(c *gin.Context) { c.Set("user_id", userID) }
+		router.GET("/forms/:id/export-schedules", setUser, handler.ListSchedules)
+		router.POST("/forms/:id/export-schedules", setUser, handler.CreateSchedule)
+		router.GET("/forms/:id/export-schedules/:scheduleId", setUser, handler.GetSchedule)
+		router.PUT("/forms/:id/export-schedules/:scheduleId", setUser, handler.UpdateSchedule)
+		router.DELETE("/forms/:id/export-schedules/:scheduleId", setUser, handler.DeleteSchedule)
+		router.GET("/forms/:id/export-schedules/:scheduleId/runs", setUser, handler.ScheduleRuns)
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, url, &buf))
+		return w
+	}
+	base := "/forms/" + form.ID + "/export-schedules"
+
+	req := ExportScheduleRequest{
+		Name:        "Weekly registrations",
+		Spec:        "0 7 * * mon",
+		Format:      "csv",
+		Params:      models.ExportParams{"columns": "name", "headers": "ids"},
+		Incremental: true,
+		Email:       "Finance <finance@example.com>; boss@example.com",
+	}
+	w := serve(user.ID, http.MethodPost, base, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var schedule models.ExportSchedule
+	json.Unmarshal(w.Body.Bytes(), &schedule)
+	if !schedule.Active || schedule.Timezone != "UTC" || schedule.NextRunAt == nil || schedule.Email != "finance@example.com, boss@example.com" {
+		t.Errorf("unexpected schedule %+v", schedule)
+	}
+
+	invalid := []ExportScheduleRequest{
+		{Name: "Report", Spec: "every monday", Format: "csv"},
+		{Name: "Report", Spec: "0 7 * * 1", Timezone: "Nowhere/City", Format: "csv"},
+		{Name: "Report", Spec: "0 7 * * 1", Format: "pdf"},
+		{Name: "Report", Spec: "0 7 * * 1", Format: "csv", Params: models.ExportParams{"last": "7d"}},
+		{Name: "Report", Spec: "0 7 * * 1", Format: "csv", Params: models.ExportParams{"columns": "missing"}},
+		{Name: "Report", Spec: "0 7 * * 1", Format: "zip", Params: models.ExportParams{"format": "xml"}},
+		{Name: "Report", Spec: "0 7 * * 1", Format: "csv", Email: "not an address"},
+	}
+	for _, req := range invalid {
+		if w := serve(user.ID, http.MethodPost, base, req); w.Code != http.StatusBadRequest {
+			t.Errorf("%+v: expected status %d, got %d", req, http.StatusBadRequest, w.Code)
+		}
+	}
+
+	// Run the queued job as if it were due
+	db.Model(&models.Job{}).Where("type = ?", export.ScheduleJobType).Update("run_at", time.Now())
+	queue.RunDue()
+
+	w = serve(user.ID, http.MethodGet, base+"/"+schedule.ID+"/runs", nil)
+	var runs struct {
+		Data       []models.Export `json:"data"`
+		TotalItems int64           `json:"total_items"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &runs)
+	if w.Code != http.StatusOK || runs.TotalItems != 1 || len(runs.Data) != 1 {
+		t.Fatalf("expected one run, got %d: %s", w.Code, w.Body.String())
+	}
+	if run := runs.Data[0]; run.Status != models.ExportStatusSucceeded || run.Submissions != 1 || run.DownloadURL == "" {
+		t.Errorf("unexpected run %+v", run)
+	}
+
+	paused := false
+	req.Active = &paused
+	w = serve(user.ID, http.MethodPut, base+"/"+schedule.ID, req)
+	var updated models.ExportSchedule
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if w.Code != http.StatusOK || updated.Active || updated.NextRunAt != nil || updated.LastRunAt == nil {
+		t.Errorf("expected paused schedule keeping its progress, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serve(user.ID, http.MethodGet, base, nil)
+	var schedules []models.ExportSchedule
+	json.Unmarshal(w.Body.Bytes(), &schedules)
+	if len(schedules) != 1 {
+		t.Errorf("expected one schedule, got %d", len(schedules))
+	}
+
+	if w := serve(other.ID, http.MethodGet, base+"/"+schedule.ID, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := serve(other.ID, http.MethodGet, base+"/"+schedule.ID+"/runs", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := serve(user.ID, http.MethodDelete, base+"/"+schedule.ID, nil); w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := serve(user.ID, http.MethodGet, base+"/"+schedule.ID, nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d after delete, got %d", http.StatusNotFound, w.Code)
+	}
+}
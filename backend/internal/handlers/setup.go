@@ -153,16 +153,19 @@ func (h *SetupHandler) GetSettings(c *gin.Context) {
 }
 
 type UpdateSettingsRequest struct {
-	AllowRegistration  *bool               `json:"allow_registration"`
-	AppName            string              `json:"app_name"`
-	FooterLinks        *models.FooterLinks `json:"footer_links"`
-	PrimaryColor       string              `json:"primary_color"`
-	LogoURL            *string             `json:"logo_url"`
-	LogoShowText       *bool               `json:"logo_show_text"`
-	FaviconURL         *string             `json:"favicon_url"`
-	LoginBackgroundURL *string             `json:"login_background_url"`
-	Language           string              `json:"language"`
-	Theme              string              `json:"theme"`
+	AllowRegistration  *bool                `json:"allow_registration"`
+	AppName            string               `json:"app_name"`
+	FooterLinks        *models.FooterLinks  `json:"footer_links"`
+	PrimaryColor       string               `json:"primary_color"`
+	LogoURL            *string              `json:"logo_url"`
+	LogoShowText       *bool                `json:"logo_show_text"`
+	FaviconURL         *string              `json:"favicon_url"`
+	LoginBackgroundURL *string              `json:"login_background_url"`
+	Language           string               `json:"language"`
+	Theme              string               `json:"theme"`
+	IPStorage          models.IPStorageMode `json:"ip_storage"`
+	CollectUserAgent   *bool                `json:"collect_user_agent"`
+	CollectReferrer    *bool                `json:"collect_referrer"`
 }
 
 // UpdateSettings godoc
@@ -185,6 +188,11 @@ func (h *SetupHandler) UpdateSettings(c *gin.Context) {
 		return
 	}
 
+	if req.IPStorage != "" && !req.IPStorage.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IP storage mode"})
+		return
+	}
+
 	var settings models.Settings
 	database.DB.First(&settings)
 
@@ -218,6 +226,15 @@ func (h *SetupHandler) UpdateSettings(c *gin.Context) {
 	if req.Theme != "" {
 		settings.Theme = req.Theme
 	}
+	if req.IPStorage != "" {
+		settings.IPStorage = req.IPStorage
+	}
+	if req.CollectUserAgent != nil {
+		settings.CollectUserAgent = *req.CollectUserAgent
+	}
+	if req.CollectReferrer != nil {
+		settings.CollectReferrer = *req.CollectReferrer
+	}
 
 	database.DB.Save(&settings)
 
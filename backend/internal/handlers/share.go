@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"formera/internal/access"
+	"formera/internal/database"
+	"formera/internal/logger"
+	"formera/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShareHandler manages which users can access a form
+type ShareHandler struct{}
+
+// NewShareHandler creates a new form sharing handler
+func NewShareHandler() *ShareHandler {
+	return &ShareHandler{}
+}
+
+// CreateShareRequest shares a form with a user
+type CreateShareRequest struct {
+	Email string          `json:"email" binding:"required"`
+	Role  models.FormRole `json:"role" binding:"required"`
+}
+
+// UpdateShareRequest changes the role of a share
+type UpdateShareRequest struct {
+	Role models.FormRole `json:"role" binding:"required"`
+}
+
+// FormShareResponse is a share with the user it grants access to
+type FormShareResponse struct {
+	models.FormShare
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// FormSharesResponse lists who can access a form
+type FormSharesResponse struct {
+	Owner  FormShareResponse   `json:"owner"` // Creator of the form
+	Shares []FormShareResponse `json:"shares"`
+}
+
+// List godoc
+// @Summary      List form shares
+// @Description  Get the creator of a form and the users it is shared with
+// @Tags         Sharing
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Success      200 {object} FormSharesResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/shares [get]
+func (h *ShareHandler) List(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	var shares []models.FormShare
+	if result := database.DB.Where("form_id = ?", form.ID).Order("created_at").Find(&shares); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch shares"})
+		return
+	}
+
+	userIDs := []string{form.UserID}
+	for _, s := range shares {
+		userIDs = append(userIDs, s.UserID)
+	}
+	var users []models.User
+	database.DB.Where("id IN ?", userIDs).Find(&users)
+	byID := make(map[string]models.User, len(users))
+	for _, u := range users {
+		byID[u.ID] = u
+	}
+
+	owner := byID[form.UserID]
+	response := FormSharesResponse{
+		Owner: FormShareResponse{
+			FormShare: models.FormShare{FormID: form.ID, UserID: form.UserID, Role: models.FormRoleOwner, CreatedAt: form.CreatedAt},
+			Email:     owner.Email,
+			Name:      owner.Name,
+		},
+		Shares: make([]FormShareResponse, len(shares)),
+	}
+	for i, s := range shares {
+		u := byID[s.UserID]
+		response.Shares[i] = FormShareResponse{FormShare: s, Email: u.Email, Name: u.Name}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Create godoc
+// @Summary      Share form
+// @Description  Give a user access to a form as owner, editor or viewer (owners only). Editors change fields and settings and manage submissions; viewers read submissions, analytics and exports.
+// @Tags         Sharing
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        request body CreateShareRequest true "User and role"
+// @Success      201 {object} FormShareResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse "Form or user not found"
+// @Failure      409 {object} ErrorResponse "Already shared with the user"
+// @Security     BearerAuth
+// @Router       /forms/{id}/shares [post]
+func (h *ShareHandler) Create(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleOwner) {
+		return
+	}
+
+	var req CreateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role (expected owner, editor or viewer)"})
+		return
+	}
+
+	var user models.User
+	if result := database.DB.Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(req.Email))).First(&user); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.ID == form.UserID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The form belongs to this user"})
+		return
+	}
+	var count int64
+	database.DB.Model(&models.FormShare{}).Where("form_id = ? AND user_id = ?", form.ID, user.ID).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "The form is already shared with this user"})
+		return
+	}
+
+	share := &models.FormShare{FormID: form.ID, UserID: user.ID, Role: req.Role, CreatedBy: c.GetString("user_id")}
+	if result := database.DB.Create(share); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to share form"})
+		return
+	}
+	logger.Info().Str("form_id", form.ID).Str("user_id", user.ID).Str("role", string(share.Role)).Msg("Form shared")
+
+	c.JSON(http.StatusCreated, FormShareResponse{FormShare: *share, Email: user.Email, Name: user.Name})
+}
+
+// Update godoc
+// @Summary      Change share role
+// @Description  Change the role of a user on a form (owners only)
+// @Tags         Sharing
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        shareId path string true "Share ID"
+// @Param        request body UpdateShareRequest true "New role"
+// @Success      200 {object} FormShareResponse
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/shares/{shareId} [put]
+func (h *ShareHandler) Update(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleOwner) {
+		return
+	}
+	share, ok := findShare(c, form.ID)
+	if !ok {
+		return
+	}
+
+	var req UpdateShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role (expected owner, editor or viewer)"})
+		return
+	}
+
+	if result := database.DB.Model(share).Update("role", req.Role); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update share"})
+		return
+	}
+
+	var user models.User
+	database.DB.First(&user, "id = ?", share.UserID)
+	c.JSON(http.StatusOK, FormShareResponse{FormShare: *share, Email: user.Email, Name: user.Name})
+}
+
+// Delete godoc
+// @Summary      Stop sharing form
+// @Description  Remove the access of a user to a form. Owners can remove anyone; other users can remove their own access.
+// @Tags         Sharing
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        shareId path string true "Share ID"
+// @Success      200 {object} MessageResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/shares/{shareId} [delete]
+func (h *ShareHandler) Delete(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+	share, ok := findShare(c, form.ID)
+	if !ok {
+		return
+	}
+	if share.UserID != c.GetString("user_id") && !form.Role.Allows(models.FormRoleOwner) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions for this form"})
+		return
+	}
+
+	if result := database.DB.Delete(share); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete share"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share deleted successfully"})
+}
+
+// findShare loads a share of a form, or responds with 404
+func findShare(c *gin.Context, formID string) (*models.FormShare, bool) {
+	var share models.FormShare
+	if result := database.DB.Where("id = ? AND form_id = ?", c.Param("shareId"), formID).First(&share); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share not found"})
+		return nil, false
+	}
+	return &share, true
+}
+
+// authorizeForm loads the form of the id parameter into form if the current
+// user has at least the required role on it, and sets the form's Role.
+// Responds with 404 if the user has no access and 403 if the role is
+// insufficient.
+func authorizeForm(c *gin.Context, form *models.Form, required models.FormRole) bool {
+	if result := database.DB.First(form, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+		return false
+	}
+	role, err := access.Role(database.DB, form, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
+		return false
+	}
+	if role == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+		return false
+	}
+	if !role.Allows(required) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions for this form"})
+		return false
+	}
+	form.Role = role
+	return true
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/pagination"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestShareHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	owner := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleUser)
+	editor := testutil.CreateTestUser(t, db, "editor@example.com", "password123", models.RoleUser)
+	viewer := testutil.CreateTestUser(t, db, "viewer@example.com", "password123", models.RoleUser)
+	stranger := testutil.CreateTestUser(t, db, "stranger@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: owner.ID,
+		Title:  "Team survey",
+		Status: models.FormStatusPublished,
+		Fields: models.FormFields{{ID: "name", Type: models.FieldTypeText, Label: "Name"}},
+	}
+	db.Create(form)
+	submission := &models.Submission{FormID: form.ID, Data: models.SubmissionData{"name": "Ada"}}
+	db.Create(submission)
+
+	shareHandler := NewShareHandler()
+	formHandler := NewFormHandler(nil)
+	submissionHandler := NewSubmissionHandler(nil, nil, nil)
+	router := func(userID string) *gin.Engine {
+		r := gin.New()
+		setUser := func(c *gin.Context) { c.Set("user_id", userID) }
+		r.GET("/forms", setUser, formHandler.List)
+		r.PUT("/forms/:id", setUser, formHandler.Update)
+		r.DELETE("/forms/:id", setUser, formHandler.Delete)
+		r.GET("/forms/:id/submissions", setUser, submissionHandler.List)
+		r.DELETE("/forms/:id/submissions/:submissionId", setUser, submissionHandler.Delete)
+		r.GET("/forms/:id/export/csv", setUser, submissionHandler.ExportCSV)
+		r.GET("/forms/:id/shares", setUser, shareHandler.List)
+		r.POST("/forms/:id/shares", setUser, shareHandler.Create)
+		r.PUT("/forms/:id/shares/:shareId", setUser, shareHandler.Update)
+		r.DELETE("/forms/:id/shares/:shareId", setUser, shareHandler.Delete)
+		return r
+	}
+	do := func(userID, method, url string, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, url, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router(userID).ServeHTTP(w, req)
+		return w
+	}
+	share := func(userID, email string, role models.FormRole) *httptest.ResponseRecorder {
+		return do(userID, http.MethodPost, "/forms/"+form.ID+"/shares", CreateShareRequest{Email: email, Role: role})
+	}
+
+	// Nobody but the owner has access yet
+	if w := do(viewer.ID, http.MethodGet, "/forms/"+form.ID+"/submissions", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("expected status %d before sharing, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w := share(owner.ID, "Editor@Example.com", models.FormRoleEditor)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var editorShare FormShareResponse
+	json.Unmarshal(w.Body.Bytes(), &editorShare)
+	if editorShare.UserID != editor.ID || editorShare.Email != editor.Email {
+		t.Errorf("unexpected share %+v", editorShare)
+	}
+	if w := share(owner.ID, viewer.Email, models.FormRoleViewer); w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	for _, tt := range []struct {
+		name   string
+		userID string
+		email  string
+		role   models.FormRole
+		status int
+	}{
+		{"duplicate", owner.ID, viewer.Email, models.FormRoleViewer, http.StatusConflict},
+		{"unknown user", owner.ID, "nobody@example.com", models.FormRoleViewer, http.StatusNotFound},
+		{"creator", owner.ID, owner.Email, models.FormRoleEditor, http.StatusBadRequest},
+		{"invalid role", owner.ID, stranger.Email, "admin", http.StatusBadRequest},
+		{"editor shares", editor.ID, stranger.Email, models.FormRoleViewer, http.StatusForbidden},
+		{"stranger shares", stranger.ID, stranger.Email, models.FormRoleOwner, http.StatusNotFound},
+	} {
+		if w := share(tt.userID, tt.email, tt.role); w.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+		}
+	}
+
+	// Viewers read submissions and exports but change nothing
+	if w := do(viewer.ID, http.MethodGet, "/forms/"+form.ID+"/submissions", nil); w.Code != http.StatusOK {
+		t.Errorf("viewer: expected status %d for submissions, got %d", http.StatusOK, w.Code)
+	}
+	if w := do(viewer.ID, http.MethodGet, "/forms/"+form.ID+"/export/csv", nil); w.Code != http.StatusOK {
+		t.Errorf("viewer: expected status %d for the export, got %d", http.StatusOK, w.Code)
+	}
+	if w := do(viewer.ID, http.MethodPut, "/forms/"+form.ID, UpdateFormRequest{Title: "Hijacked"}); w.Code != http.StatusForbidden {
+		t.Errorf("viewer: expected status %d for an update, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := do(viewer.ID, http.MethodDelete, "/forms/"+form.ID+"/submissions/"+submission.ID, nil); w.Code != http.StatusForbidden {
+		t.Errorf("viewer: expected status %d for deleting a submission, got %d", http.StatusForbidden, w.Code)
+	}
+
+	// Editors change fields and settings but cannot delete the form
+	w = do(editor.ID, http.MethodPut, "/forms/"+form.ID, UpdateFormRequest{Title: "Team survey 2025"})
+	if w.Code != http.StatusOK {
+		t.Errorf("editor: expected status %d for an update, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var updated models.Form
+	json.Unmarshal(w.Body.Bytes(), &updated)
+	if updated.UserID != owner.ID || updated.Role != models.FormRoleEditor {
+		t.Errorf("editor: expected the form to keep its owner, got %+v", updated)
+	}
+	if w := do(editor.ID, http.MethodDelete, "/forms/"+form.ID, nil); w.Code != http.StatusForbidden {
+		t.Errorf("editor: expected status %d for deleting the form, got %d", http.StatusForbidden, w.Code)
+	}
+
+	// Shared forms are listed with the user's role
+	w = do(viewer.ID, http.MethodGet, "/forms", nil)
+	var list struct {
+		Data []models.Form `json:"data"`
+		pagination.Result
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Data) != 1 || list.Data[0].ID != form.ID || list.Data[0].Role != models.FormRoleViewer {
+		t.Errorf("viewer: expected the shared form in the list, got %+v", list.Data)
+	}
+	if w := do(stranger.ID, http.MethodGet, "/forms", nil); bytes.Contains(w.Body.Bytes(), []byte(form.ID)) {
+		t.Errorf("stranger: expected no shared forms, got %s", w.Body.String())
+	}
+
+	w = do(viewer.ID, http.MethodGet, "/forms/"+form.ID+"/shares", nil)
+	var shares FormSharesResponse
+	json.Unmarshal(w.Body.Bytes(), &shares)
+	if shares.Owner.UserID != owner.ID || shares.Owner.Email != owner.Email || len(shares.Shares) != 2 {
+		t.Fatalf("unexpected shares %+v", shares)
+	}
+
+	// Promoted to editor, the viewer can delete submissions
+	if w := do(owner.ID, http.MethodPut, "/forms/"+form.ID+"/shares/"+shares.Shares[1].ID, UpdateShareRequest{Role: models.FormRoleEditor}); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := do(viewer.ID, http.MethodDelete, "/forms/"+form.ID+"/submissions/"+submission.ID, nil); w.Code != http.StatusOK {
+		t.Errorf("expected status %d for deleting a submission, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	// Users may leave, but not remove others unless they are owners
+	if w := do(viewer.ID, http.MethodDelete, "/forms/"+form.ID+"/shares/"+editorShare.ID, nil); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for removing another user, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := do(viewer.ID, http.MethodDelete, "/forms/"+form.ID+"/shares/"+shares.Shares[1].ID, nil); w.Code != http.StatusOK {
+		t.Errorf("expected status %d for leaving, got %d", http.StatusOK, w.Code)
+	}
+	if w := do(viewer.ID, http.MethodGet, "/forms/"+form.ID+"/submissions", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d after leaving, got %d", http.StatusNotFound, w.Code)
+	}
+
+	if w := do(owner.ID, http.MethodDelete, "/forms/"+form.ID, nil); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var count int64
+	db.Model(&models.FormShare{}).Where("form_id = ?", form.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected the shares to be deleted with the form, got %d", count)
+	}
+}
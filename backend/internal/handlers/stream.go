@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"formera/internal/database"
+	"formera/internal/events"
+	"formera/internal/middleware"
+	"formera/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamRetry tells clients how long to wait before reconnecting (ms)
+const streamRetry = 3000
+
+type StreamHandler struct {
+	broker    *events.Broker
+	jwtSecret string
+	heartbeat time.Duration
+}
+
+func NewStreamHandler(broker *events.Broker, jwtSecret string) *StreamHandler {
+	return &StreamHandler{broker: broker, jwtSecret: jwtSecret, heartbeat: 15 * time.Second}
+}
+
+// StreamTicketResponse is a short-lived ticket for opening the live stream of a form
+type StreamTicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// LiveStats are the counters pushed to live streams after each change
+type LiveStats struct {
+	TotalSubmissions int64      `json:"total_submissions" example:"150"`
+	SubmissionsToday int64      `json:"submissions_today" example:"12"`
+	LastSubmissionAt *time.Time `json:"last_submission_at,omitempty"`
+}
+
+// Stream godoc
+// @Summary      Live submission stream
+// @Description  Server-Sent Events stream of a form. Sends a "stats" event on connect and
+// @Description  "submission" and "stats" events as submissions arrive, plus a comment heartbeat.
+// @Description  Clients that fall behind receive a "resync" event and are disconnected; they
+// @Description  should reconnect and refetch. As EventSource cannot send headers, a ticket
+// @Description  from POST /forms/{id}/stream/ticket may be passed as ticket query parameter.
+// @Tags         Submissions
+// @Produce      text/event-stream
+// @Param        id path string true "Form ID"
+// @Param        ticket query string false "Stream ticket, if no Authorization header is sent"
+// @Success      200 {string} string "Event stream"
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/stream [get]
+func (h *StreamHandler) Stream(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	sub := h.broker.Subscribe(form.ID, events.DefaultBuffer)
+	defer sub.Close()
+
+	// The stream outlives the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry)
+	if err := writeEvent(c.Writer, events.TypeStats, liveStats(form.ID)); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind, or the server is shutting down
+				if sub.Lagged() {
+					writeEvent(c.Writer, "resync", gin.H{})
+					c.Writer.Flush()
+				}
+				return
+			}
+			if err := writeEvent(c.Writer, event.Type, event.Data); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// Ticket godoc
+// @Summary      Create stream ticket
+// @Description  Issues a ticket for opening the live stream of a form with EventSource. The ticket
+// @Description  is only valid for this form's stream and expires after a few minutes, so it can
+// @Description  be passed in the URL instead of the session token. Request a new one to reconnect.
+// @Tags         Submissions
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Success      200 {object} StreamTicketResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/stream/ticket [post]
+func (h *StreamHandler) Ticket(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	ticket, expiresAt, err := middleware.NewStreamTicket(h.jwtSecret, c.GetString("user_id"), c.GetString("user_role"), form.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket"})
+		return
+	}
+
+	c.JSON(http.StatusOK, StreamTicketResponse{Ticket: ticket, ExpiresAt: expiresAt})
+}
+
+func writeEvent(w io.Writer, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, payload)
+	return err
+}
+
+// liveStats computes the counters sent to live streams
+func liveStats(formID string) LiveStats {
+	var stats LiveStats
+	database.DB.Model(&models.Submission{}).Where("form_id = ?", formID).Count(&stats.TotalSubmissions)
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	database.DB.Model(&models.Submission{}).Where("form_id = ? AND created_at >= ?", formID, today).Count(&stats.SubmissionsToday)
+
+	var last models.Submission
+	if result := database.DB.Select("created_at").Where("form_id = ?", formID).Order("created_at DESC").Limit(1).Find(&last); result.RowsAffected > 0 {
+		stats.LastSubmissionAt = &last.CreatedAt
+	}
+	return stats
+}
+
+// publishLive pushes a new submission (if any) and updated counters to open streams of the form
+func publishLive(broker *events.Broker, formID string, submission *models.Submission) {
+	if !broker.HasSubscribers(formID) {
+		return
+	}
+	if submission != nil {
+		broker.Publish(events.Event{Type: events.TypeSubmission, FormID: formID, Data: submission})
+	}
+	broker.Publish(events.Event{Type: events.TypeStats, FormID: formID, Data: liveStats(formID)})
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"formera/internal/events"
+	"formera/internal/middleware"
+	"formera/internal/models"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readEvent reads the next named event from an SSE stream, skipping comments
+func readEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+	var name, data string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("stream ended: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && name != "":
+			return name, data
+		}
+	}
+}
+
+func TestStreamHandler_Stream(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Live",
+		Status: models.FormStatusPublished,
+		Fields: models.FormFields{{ID: "field1", Label: "Message", Type: "text"}},
+	}
+	db.Create(form)
+
+	broker := events.NewBroker()
+	streamHandler := NewStreamHandler(broker, "test-secret")
+	submissionHandler := NewSubmissionHandler(nil, nil, broker)
+
+	router := gin.New()
+	router.GET("/forms/:id/stream", func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		streamHandler.Stream(c)
+	})
+	router.POST("/public/forms/:id/submit", submissionHandler.Submit)
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/forms/" + form.ID + "/stream")
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	name, data := readEvent(t, reader)
+	if name != events.TypeStats || !strings.Contains(data, `"total_submissions":0`) {
+		t.Fatalf("expected initial stats, got %s %s", name, data)
+	}
+
+	jsonBody, _ := json.Marshal(SubmitRequest{Data: map[string]interface{}{"field1": "Hi"}})
+	submitResp, err := http.Post(server.URL+"/public/forms/"+form.ID+"/submit", "application/json", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		t.Fatalf("submit failed: %v", err)
+	}
+	submitResp.Body.Close()
+
+	name, data = readEvent(t, reader)
+	if name != events.TypeSubmission || !strings.Contains(data, `"field1":"Hi"`) {
+		t.Errorf("expected submission event, got %s %s", name, data)
+	}
+	name, data = readEvent(t, reader)
+	if name != events.TypeStats || !strings.Contains(data, `"total_submissions":1`) {
+		t.Errorf("expected updated stats, got %s %s", name, data)
+	}
+
+	// Shutdown ends the stream
+	broker.Close()
+	done := make(chan struct{})
+	go func() {
+		reader.ReadString(0)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("stream did not end after broker shutdown")
+	}
+}
+
+func TestStreamHandler_Stream_WrongUser(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	owner := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{UserID: owner.ID, Title: "Live"}
+	db.Create(form)
+
+	handler := NewStreamHandler(events.NewBroker(), "test-secret")
+	router := gin.New()
+	router.GET("/forms/:id/stream", func(c *gin.Context) {
+		c.Set("user_id", other.ID)
+		handler.Stream(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/forms/"+form.ID+"/stream", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestStreamHandler_Ticket(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	owner := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{UserID: owner.ID, Title: "Live"}
+	db.Create(form)
+
+	handler := NewStreamHandler(events.NewBroker(), "test-secret")
+	router := gin.New()
+	router.POST("/forms/:id/stream/ticket", func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-User"))
+		handler.Ticket(c)
+	})
+	router.GET("/forms/:id/stream", middleware.StreamAuthMiddleware("test-secret"), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_id"))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/forms/"+form.ID+"/stream/ticket", nil)
+	req.Header.Set("X-User", other.ID)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for a foreign form, got %d", http.StatusNotFound, w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/forms/"+form.ID+"/stream/ticket", nil)
+	req.Header.Set("X-User", owner.ID)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response StreamTicketResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if time.Until(response.ExpiresAt) > middleware.StreamTicketTTL {
+		t.Errorf("expected a short-lived ticket, expires at %s", response.ExpiresAt)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/forms/"+form.ID+"/stream?ticket="+response.Ticket, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != owner.ID {
+		t.Errorf("expected the ticket to open the stream as the owner, got %d %s", w.Code, w.Body.String())
+	}
+}
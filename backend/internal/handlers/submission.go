@@ -1,29 +1,51 @@
 package handlers
 
 import (
-	"encoding/csv"
 	"fmt"
 	"net/http"
-	"sort"
+	"net/mail"
+	"strings"
 	"time"
 
 	"formera/internal/database"
+	"formera/internal/events"
+	"formera/internal/export"
+	"formera/internal/logger"
+	"formera/internal/mailer"
 	"formera/internal/models"
 	"formera/internal/pagination"
+	"formera/internal/privacy"
 	"formera/internal/sanitizer"
+	"formera/internal/versions"
+	"formera/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 )
 
-type SubmissionHandler struct{}
+type SubmissionHandler struct {
+	mailer   *mailer.Mailer
+	webhooks *webhooks.Dispatcher
+	events   *events.Broker
+}
 
-func NewSubmissionHandler() *SubmissionHandler {
-	return &SubmissionHandler{}
+// NewSubmissionHandler creates a new submission handler. The mailer, webhook
+// dispatcher and event broker may be nil if email notifications, webhooks or
+// live streams are not available.
+func NewSubmissionHandler(mail *mailer.Mailer, hooks *webhooks.Dispatcher, broker *events.Broker) *SubmissionHandler {
+	return &SubmissionHandler{mailer: mail, webhooks: hooks, events: broker}
 }
 
 type SubmitRequest struct {
 	Data     models.SubmissionData `json:"data" binding:"required"`
 	Metadata map[string]string     `json:"metadata,omitempty"`
+	// StartedAt is when the respondent first interacted with the form
+	StartedAt *time.Time `json:"started_at,omitempty"`
+}
+
+// SubmissionResponse is a submission with the fields of the form version it answered
+type SubmissionResponse struct {
+	models.Submission
+	Fields models.FormFields `json:"fields"`
 }
 
 // Submit godoc
@@ -120,10 +142,14 @@ func (h *SubmissionHandler) Submit(c *gin.Context) {
 		if v, ok := req.Metadata["utm_content"]; ok {
 			metadata.UTMContent = v
 		}
+		// The page referrer reported by the client; the request's Referer is the form itself
+		if v, ok := req.Metadata["referrer"]; ok {
+			metadata.Referrer = v
+		}
 
 		customTracking := make(map[string]string)
 		for k, v := range req.Metadata {
-			if k != "utm_source" && k != "utm_medium" && k != "utm_campaign" && k != "utm_term" && k != "utm_content" {
+			if k != "utm_source" && k != "utm_medium" && k != "utm_campaign" && k != "utm_term" && k != "utm_content" && k != "referrer" {
 				customTracking[k] = v
 			}
 		}
@@ -132,13 +158,20 @@ func (h *SubmissionHandler) Submit(c *gin.Context) {
 		}
 	}
 
+	// Minimize metadata according to instance and form privacy settings
+	var settings models.Settings
+	database.DB.First(&settings)
+	privacy.Resolve(&settings, &form.Settings).Apply(&metadata, settings.IPHashSalt)
+
 	// Sanitize submission data to prevent XSS
 	sanitizedData := sanitizer.SanitizeSubmissionData(req.Data)
 
 	submission := &models.Submission{
-		FormID:   formID,
-		Data:     sanitizedData,
-		Metadata: metadata,
+		FormID:            formID,
+		FormVersion:       form.CurrentVersion,
+		Data:              sanitizedData,
+		Metadata:          metadata,
+		CompletionSeconds: completionSeconds(req.StartedAt, now),
 	}
 
 	if result := database.DB.Create(submission); result.Error != nil {
@@ -146,41 +179,109 @@ func (h *SubmissionHandler) Submit(c *gin.Context) {
 		return
 	}
 
+	h.notifyOwner(&form, submission)
+	h.sendConfirmation(&form, submission)
+	h.webhooks.Dispatch(models.WebhookEventSubmissionCreated, &form, submission)
+	publishLive(h.events, form.ID, submission)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message":    form.Settings.SuccessMessage,
 		"submission": submission,
 	})
 }
 
+// notifyOwner queues the notification email for a new submission if enabled on the form.
+// Falls back to the form owner's address when no notification email is set.
+func (h *SubmissionHandler) notifyOwner(form *models.Form, submission *models.Submission) {
+	if !form.Settings.NotifyOnSubmission || !h.mailer.Enabled() {
+		return
+	}
+
+	recipients := parseEmailList(form.Settings.NotificationEmail)
+	if len(recipients) == 0 {
+		var owner models.User
+		if result := database.DB.Select("email").First(&owner, "id = ?", form.UserID); result.Error != nil {
+			return
+		}
+		recipients = []string{owner.Email}
+	}
+
+	msg, err := mailer.NewSubmissionNotification(form, submission, recipients, h.mailer.BaseURL())
+	if err != nil {
+		logger.Error().Err(err).Str("form_id", form.ID).Msg("Failed to render notification email")
+		return
+	}
+	h.mailer.SendAsync(msg)
+}
+
+// sendConfirmation queues the receipt to the address the respondent entered in the configured email field
+func (h *SubmissionHandler) sendConfirmation(form *models.Form, submission *models.Submission) {
+	if !form.Settings.SendConfirmation || form.Settings.ConfirmationEmailField == "" || !h.mailer.Enabled() {
+		return
+	}
+
+	value, _ := submission.Data[form.Settings.ConfirmationEmailField].(string)
+	addr, err := mail.ParseAddress(strings.TrimSpace(value))
+	if err != nil {
+		return
+	}
+
+	h.mailer.SendAsync(mailer.NewConfirmation(form, submission, addr.Address))
+}
+
+// parseEmailList splits a comma or semicolon separated list and drops invalid addresses
+func parseEmailList(value string) []string {
+	var emails []string
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		addr, err := mail.ParseAddress(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		emails = append(emails, addr.Address)
+	}
+	return emails
+}
+
 // List godoc
 // @Summary      List submissions
-// @Description  Get paginated list of form submissions
+// @Description  Get paginated list of form submissions, optionally filtered by date range and answers
 // @Tags         Submissions
 // @Produce      json
 // @Param        id path string true "Form ID"
 // @Param        page query int false "Page number" default(1)
 // @Param        page_size query int false "Items per page" default(20)
+// @Param        from query string false "Start date (YYYY-MM-DD or RFC 3339)"
+// @Param        to query string false "End date, inclusive for YYYY-MM-DD"
+// @Param        last query string false "Relative range ending now, such as 24h, 7d or 4w"
+// @Param        tz query string false "IANA time zone of dates" default(UTC)
+// @Param        filter[field_id] query string false "Only submissions whose answer to the field equals or contains the value"
 // @Success      200 {object} SubmissionListResponse
+// @Failure      400 {object} ErrorResponse
 // @Failure      401 {object} ErrorResponse
 // @Failure      404 {object} ErrorResponse
 // @Security     BearerAuth
 // @Router       /forms/{id}/submissions [get]
 func (h *SubmissionHandler) List(c *gin.Context) {
-	userID := c.GetString("user_id")
 	formID := c.Param("id")
 	params := pagination.GetParams(c)
 
 	var form models.Form
-	if result := database.DB.Where("id = ? AND user_id = ?", formID, userID).First(&form); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
 		return
 	}
 
+	filter, msg := parseSubmissionFilter(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	f := filter.export()
+
 	var totalItems int64
-	database.DB.Model(&models.Submission{}).Where("form_id = ?", formID).Count(&totalItems)
+	f.Apply(database.DB.Model(&models.Submission{}).Where("form_id = ?", formID)).Count(&totalItems)
 
 	var submissions []models.Submission
-	if result := database.DB.Where("form_id = ?", formID).
+	if result := f.Apply(database.DB.Where("form_id = ?", formID)).
 		Order("created_at DESC").
 		Scopes(pagination.Paginate(params)).
 		Find(&submissions); result.Error != nil {
@@ -188,32 +289,46 @@ func (h *SubmissionHandler) List(c *gin.Context) {
 		return
 	}
 
+	// Fields of the older versions answered by submissions on this page
+	resolver := versions.NewResolver(database.DB, &form)
+	fieldsByVersion := make(map[int]models.FormFields)
+	for _, sub := range submissions {
+		if _, ok := fieldsByVersion[sub.FormVersion]; ok || sub.FormVersion == 0 || sub.FormVersion == form.CurrentVersion {
+			continue
+		}
+		fields, err := resolver.Fields(sub.FormVersion)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load form versions"})
+			return
+		}
+		fieldsByVersion[sub.FormVersion] = fields
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"form":        form,
+		"versions":    fieldsByVersion,
 		"submissions": pagination.CreateResult(submissions, params, totalItems),
 	})
 }
 
 // Get godoc
 // @Summary      Get submission
-// @Description  Get a specific submission by ID
+// @Description  Get a specific submission by ID with the fields of the form version it answered
 // @Tags         Submissions
 // @Produce      json
 // @Param        id path string true "Form ID"
 // @Param        submissionId path string true "Submission ID"
-// @Success      200 {object} models.Submission
+// @Success      200 {object} SubmissionResponse
 // @Failure      401 {object} ErrorResponse
 // @Failure      404 {object} ErrorResponse
 // @Security     BearerAuth
 // @Router       /forms/{id}/submissions/{submissionId} [get]
 func (h *SubmissionHandler) Get(c *gin.Context) {
-	userID := c.GetString("user_id")
 	formID := c.Param("id")
 	submissionID := c.Param("submissionId")
 
 	var form models.Form
-	if result := database.DB.Where("id = ? AND user_id = ?", formID, userID).First(&form); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
 		return
 	}
 
@@ -223,7 +338,13 @@ func (h *SubmissionHandler) Get(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, submission)
+	fields, err := versions.NewResolver(database.DB, &form).Fields(submission.FormVersion)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load form version"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SubmissionResponse{Submission: submission, Fields: fields})
 }
 
 // Delete godoc
@@ -239,221 +360,425 @@ func (h *SubmissionHandler) Get(c *gin.Context) {
 // @Security     BearerAuth
 // @Router       /forms/{id}/submissions/{submissionId} [delete]
 func (h *SubmissionHandler) Delete(c *gin.Context) {
-	userID := c.GetString("user_id")
 	formID := c.Param("id")
 	submissionID := c.Param("submissionId")
 
 	var form models.Form
-	if result := database.DB.Where("id = ? AND user_id = ?", formID, userID).First(&form); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+	if !authorizeForm(c, &form, models.FormRoleEditor) {
 		return
 	}
 
-	if result := database.DB.Where("id = ? AND form_id = ?", submissionID, formID).Delete(&models.Submission{}); result.Error != nil {
+	var submission models.Submission
+	if result := database.DB.Where("id = ? AND form_id = ?", submissionID, formID).First(&submission); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+
+	if result := database.DB.Delete(&submission); result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete submission"})
 		return
 	}
 
+	h.webhooks.Dispatch(models.WebhookEventSubmissionDeleted, &form, &submission)
+	publishLive(h.events, form.ID, nil)
+
 	c.JSON(http.StatusOK, gin.H{"message": "Submission deleted successfully"})
 }
 
 // Stats godoc
 // @Summary      Get form statistics
-// @Description  Get submission statistics for a form
+// @Description  Get submission statistics for a form, optionally limited to a date range and answers
 // @Tags         Submissions
 // @Produce      json
 // @Param        id path string true "Form ID"
+// @Param        from query string false "Start date (YYYY-MM-DD or RFC 3339)"
+// @Param        to query string false "End date, inclusive for YYYY-MM-DD"
+// @Param        tz query string false "IANA time zone of dates" default(UTC)
+// @Param        filter[field_id] query string false "Only count submissions whose answer to the field equals or contains the value"
 // @Success      200 {object} FormStatsResponse
+// @Failure      400 {object} ErrorResponse
 // @Failure      401 {object} ErrorResponse
 // @Failure      404 {object} ErrorResponse
 // @Security     BearerAuth
 // @Router       /forms/{id}/stats [get]
 func (h *SubmissionHandler) Stats(c *gin.Context) {
-	userID := c.GetString("user_id")
 	formID := c.Param("id")
 
 	var form models.Form
-	if result := database.DB.Where("id = ? AND user_id = ?", formID, userID).First(&form); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
 		return
 	}
 
-	var submissions []models.Submission
-	database.DB.Where("form_id = ?", formID).Find(&submissions)
+	filter, msg := parseSubmissionFilter(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
 
-	fieldStats := make(map[string]interface{})
-	for _, field := range form.Fields {
-		stats := make(map[string]int)
-		for _, sub := range submissions {
-			if val, ok := sub.Data[field.ID]; ok {
-				switch v := val.(type) {
-				case string:
-					stats[v]++
-				case []interface{}:
-					for _, item := range v {
-						if str, ok := item.(string); ok {
-							stats[str]++
-						}
-					}
-				}
-			}
-		}
-		if len(stats) > 0 {
-			fieldStats[field.ID] = stats
-		}
+	var total int64
+	cond, args := filter.sql()
+	database.DB.Model(&models.Submission{}).Where("form_id = ?", formID).Where(cond, args...).Count(&total)
+
+	fieldIDs := make([]string, len(form.Fields))
+	for i, field := range form.Fields {
+		fieldIDs[i] = field.ID
+	}
+	counts, err := countFieldValues(formID, fieldIDs, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute statistics"})
+		return
+	}
+
+	fieldStats := make(map[string]interface{}, len(counts))
+	for fieldID, stats := range counts {
+		fieldStats[fieldID] = stats
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"total_submissions": len(submissions),
+		"total_submissions": total,
 		"field_stats":       fieldStats,
 	})
 }
 
+// countFieldValues counts how often each string answer was given per field.
+// Answers of multi-value fields (e.g. checkboxes) count once per selected option;
+// non-string values are ignored. Only submissions matching the filter are counted.
+func countFieldValues(formID string, fieldIDs []string, filter *submissionFilter) (map[string]map[string]int, error) {
+	counts := make(map[string]map[string]int)
+	if len(fieldIDs) == 0 {
+		return counts, nil
+	}
+
+	cond, condArgs := filter.sql()
+	args := append([]interface{}{formID, fieldIDs}, condArgs...)
+	args = append(append(args, formID, fieldIDs), condArgs...)
+
+	var rows []struct {
+		FieldID string
+		Value   string
+		Count   int
+	}
+	if err := database.DB.Raw(`
+		SELECT d.key AS field_id, d.value AS value, COUNT(*) AS count
+		FROM submissions, json_each(submissions.data) d
+		WHERE submissions.form_id = ? AND d.key IN ? AND d.type = 'text' AND `+cond+`
+		GROUP BY d.key, d.value
+		UNION ALL
+		SELECT d.key AS field_id, item.value AS value, COUNT(*) AS count
+		FROM submissions, json_each(submissions.data) d, json_each(d.value) item
+		WHERE submissions.form_id = ? AND d.key IN ? AND d.type = 'array' AND item.type = 'text' AND `+cond+`
+		GROUP BY d.key, item.value`,
+		args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if counts[row.FieldID] == nil {
+			counts[row.FieldID] = make(map[string]int)
+		}
+		counts[row.FieldID][row.Value] += row.Count
+	}
+	return counts, nil
+}
+
 // ExportCSV godoc
 // @Summary      Export submissions as CSV
-// @Description  Download all submissions as a CSV file
+// @Description  Stream all submissions as a CSV file. Layout fields are not exported.
 // @Tags         Submissions
 // @Produce      text/csv
 // @Param        id path string true "Form ID"
+// @Param        delimiter query string false "comma, semicolon, tab or pipe" default(comma)
+// @Param        bom query bool false "Prefix a UTF-8 byte order mark for Excel" default(false)
+// @Param        separator query string false "Separator of multi-value answers" default(; )
+// @Param        headers query string false "labels or ids" default(labels)
+// @Param        metadata query bool false "Include IP, user agent, referrer and completion time" default(false)
+// @Param        utm query bool false "Include UTM parameters" default(false)
+// @Param        columns query string false "Comma-separated column keys: id, submitted_at, field IDs, metadata and UTM keys"
+// @Param        from query string false "Start date (YYYY-MM-DD or RFC 3339)"
+// @Param        to query string false "End date, inclusive for YYYY-MM-DD"
+// @Param        last query string false "Relative range ending now, such as 24h, 7d or 4w"
+// @Param        tz query string false "IANA time zone of dates" default(UTC)
+// @Param        filter[field_id] query string false "Only submissions whose answer to the field equals or contains the value"
+// @Param        preset query string false "Export preset ID whose parameters apply unless given explicitly"
 // @Success      200 {file} file "CSV file"
+// @Failure      400 {object} ErrorResponse
 // @Failure      401 {object} ErrorResponse
 // @Failure      404 {object} ErrorResponse
 // @Security     BearerAuth
 // @Router       /forms/{id}/export/csv [get]
 func (h *SubmissionHandler) ExportCSV(c *gin.Context) {
-	userID := c.GetString("user_id")
 	formID := c.Param("id")
 
 	var form models.Form
-	if result := database.DB.Where("id = ? AND user_id = ?", formID, userID).First(&form); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+	if !applyExportPreset(c, &form) {
+		return
+	}
+	if !withFieldHistory(c, &form) {
 		return
 	}
 
-	var submissions []models.Submission
-	database.DB.Where("form_id = ?", formID).Order("created_at ASC").Find(&submissions)
+	opts, msg := parseCSVOptions(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	query, _, msg := exportQuery(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
 
-	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Type", "text/csv; charset=utf-8")
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-submissions.csv", form.ID))
 
-	writer := csv.NewWriter(c.Writer)
-	defer writer.Flush()
+	if err := export.WriteCSV(c.Writer, &form, query, opts); err != nil {
+		// Headers are already sent, so the truncated download is all the client sees
+		logger.Error().Err(err).Str("form_id", formID).Msg("CSV export failed")
+	}
+}
 
-	headers := []string{"ID", "Submitted At"}
-	for _, field := range form.Fields {
-		headers = append(headers, field.Label)
+// ExportXLSX godoc
+// @Summary      Export submissions as XLSX
+// @Description  Download all submissions as an Excel spreadsheet. Numbers and dates are typed cells and the header row is frozen.
+// @Tags         Submissions
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param        id path string true "Form ID"
+// @Param        separator query string false "Separator of multi-value answers" default(; )
+// @Param        headers query string false "labels or ids" default(labels)
+// @Param        metadata query bool false "Include IP, user agent, referrer and completion time" default(false)
+// @Param        utm query bool false "Include UTM parameters" default(false)
+// @Param        metadata_sheet query bool false "Add a sheet with metadata and UTM parameters per submission" default(false)
+// @Param        summary_sheet query bool false "Add a sheet with answer counts and statistics per field" default(false)
+// @Param        columns query string false "Comma-separated column keys: id, submitted_at, field IDs, metadata and UTM keys"
+// @Param        from query string false "Start date (YYYY-MM-DD or RFC 3339)"
+// @Param        to query string false "End date, inclusive for YYYY-MM-DD"
+// @Param        last query string false "Relative range ending now, such as 24h, 7d or 4w"
+// @Param        tz query string false "IANA time zone of dates" default(UTC)
+// @Param        filter[field_id] query string false "Only submissions whose answer to the field equals or contains the value"
+// @Param        preset query string false "Export preset ID whose parameters apply unless given explicitly"
+// @Success      200 {file} file "XLSX file"
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/export/xlsx [get]
+func (h *SubmissionHandler) ExportXLSX(c *gin.Context) {
+	formID := c.Param("id")
+
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+	if !applyExportPreset(c, &form) {
+		return
+	}
+	if !withFieldHistory(c, &form) {
+		return
 	}
-	_ = writer.Write(headers)
 
-	for _, sub := range submissions {
-		row := []string{sub.ID, sub.CreatedAt.Format(time.RFC3339)}
-		for _, field := range form.Fields {
-			val := ""
-			if v, ok := sub.Data[field.ID]; ok {
-				switch typed := v.(type) {
-				case string:
-					val = typed
-				case []interface{}:
-					strs := make([]string, len(typed))
-					for i, item := range typed {
-						strs[i] = fmt.Sprintf("%v", item)
-					}
-					val = fmt.Sprintf("%v", strs)
-				default:
-					val = fmt.Sprintf("%v", typed)
-				}
-			}
-			row = append(row, val)
-		}
-		_ = writer.Write(row)
+	opts, msg := parseXLSXOptions(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	query, _, msg := exportQuery(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-submissions.xlsx", form.ID))
+
+	if err := export.WriteXLSX(c.Writer, &form, query, opts); err != nil {
+		logger.Error().Err(err).Str("form_id", formID).Msg("XLSX export failed")
 	}
 }
 
 // ExportJSON godoc
 // @Summary      Export submissions as JSON
-// @Description  Download all submissions as a JSON file
+// @Description  Stream all submissions as a JSON file. Answers keep their JSON types.
 // @Tags         Submissions
 // @Produce      json
 // @Param        id path string true "Form ID"
+// @Param        headers query string false "Key answers by field labels or ids" default(labels)
+// @Param        metadata query bool false "Include IP, user agent, referrer and completion time" default(false)
+// @Param        utm query bool false "Include UTM parameters" default(false)
+// @Param        columns query string false "Comma-separated column keys: id, submitted_at, field IDs, metadata and UTM keys"
+// @Param        from query string false "Start date (YYYY-MM-DD or RFC 3339)"
+// @Param        to query string false "End date, inclusive for YYYY-MM-DD"
+// @Param        last query string false "Relative range ending now, such as 24h, 7d or 4w"
+// @Param        tz query string false "IANA time zone of dates" default(UTC)
+// @Param        filter[field_id] query string false "Only submissions whose answer to the field equals or contains the value"
+// @Param        preset query string false "Export preset ID whose parameters apply unless given explicitly"
 // @Success      200 {array} map[string]interface{} "JSON array of submissions"
+// @Failure      400 {object} ErrorResponse
 // @Failure      401 {object} ErrorResponse
 // @Failure      404 {object} ErrorResponse
 // @Security     BearerAuth
 // @Router       /forms/{id}/export/json [get]
 func (h *SubmissionHandler) ExportJSON(c *gin.Context) {
-	userID := c.GetString("user_id")
 	formID := c.Param("id")
 
 	var form models.Form
-	if result := database.DB.Where("id = ? AND user_id = ?", formID, userID).First(&form); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+	if !applyExportPreset(c, &form) {
+		return
+	}
+	if !withFieldHistory(c, &form) {
 		return
 	}
 
-	var submissions []models.Submission
-	database.DB.Where("form_id = ?", formID).Order("created_at ASC").Find(&submissions)
-
-	exportData := make([]map[string]interface{}, len(submissions))
-	for i, sub := range submissions {
-		record := map[string]interface{}{
-			"id":           sub.ID,
-			"submitted_at": sub.CreatedAt,
-		}
-		for _, field := range form.Fields {
-			if val, ok := sub.Data[field.ID]; ok {
-				record[field.Label] = val
-			} else {
-				record[field.Label] = nil
-			}
-		}
-		exportData[i] = record
+	opts, msg := parseExportOptions(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	query, _, msg := exportQuery(c, &form)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
 	}
 
+	c.Header("Content-Type", "application/json; charset=utf-8")
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-submissions.json", form.ID))
-	c.JSON(http.StatusOK, exportData)
+
+	if err := export.WriteJSON(c.Writer, &form, query, opts); err != nil {
+		logger.Error().Err(err).Str("form_id", formID).Msg("JSON export failed")
+	}
 }
 
 // SubmissionsByDate godoc
 // @Summary      Get submissions by date
-// @Description  Get submission counts grouped by date
+// @Description  Get zero-filled submission counts per hour, day, week or month in a time zone,
+// @Description  optionally broken down by UTM source or by the answer to a choice field
 // @Tags         Submissions
 // @Produce      json
 // @Param        id path string true "Form ID"
+// @Param        from query string false "Start date (YYYY-MM-DD or RFC 3339), defaults to the first submission"
+// @Param        to query string false "End date, inclusive for YYYY-MM-DD (defaults to now)"
+// @Param        granularity query string false "hour, day, week or month" default(day)
+// @Param        tz query string false "IANA time zone, e.g. Europe/Berlin" default(UTC)
+// @Param        breakdown query string false "utm_source or field"
+// @Param        field_id query string false "Choice field to break down by (breakdown=field)"
 // @Success      200 {array} SubmissionsByDateResponse
+// @Failure      400 {object} ErrorResponse
 // @Failure      401 {object} ErrorResponse
 // @Failure      404 {object} ErrorResponse
 // @Security     BearerAuth
 // @Router       /forms/{id}/submissions/by-date [get]
 func (h *SubmissionHandler) SubmissionsByDate(c *gin.Context) {
-	userID := c.GetString("user_id")
 	formID := c.Param("id")
 
 	var form models.Form
-	if result := database.DB.Where("id = ? AND user_id = ?", formID, userID).First(&form); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
 		return
 	}
 
-	var submissions []models.Submission
-	database.DB.Where("form_id = ?", formID).Find(&submissions)
+	r, msg := parseTimeRange(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
 
-	byDate := make(map[string]int)
-	for _, sub := range submissions {
-		date := sub.CreatedAt.Format("2006-01-02")
-		byDate[date]++
+	// Breakdown key expression and the join it needs
+	var keyExpr, join string
+	var joinArgs []interface{}
+	switch c.Query("breakdown") {
+	case "":
+	case "utm_source":
+		keyExpr = "COALESCE(json_extract(submissions.metadata, '$.utm_source'), '')"
+	case "field":
+		field := form.Fields.Find(c.Query("field_id"))
+		if field == nil || !field.Type.IsChoice() || strings.Contains(field.ID, `"`) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "field_id must reference a choice field"})
+			return
+		}
+		// Yields one row per selected option for checkboxes, none if unanswered
+		join = "LEFT JOIN json_each(submissions.data, ?) AS answer"
+		joinArgs = []interface{}{export.AnswerPath(field.ID)}
+		keyExpr = "COALESCE(CAST(answer.value AS TEXT), '')"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid breakdown (expected utm_source or field)"})
+		return
+	}
+
+	filter, filterArgs := r.sqlFilter()
+
+	var slots []struct {
+		Slot  int64
+		Count int
+	}
+	if err := database.DB.Model(&models.Submission{}).
+		Select(slotExpr+" AS slot, COUNT(*) AS count").
+		Where("form_id = ?", formID).
+		Where(filter, filterArgs...).
+		Group("slot").
+		Scan(&slots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute statistics"})
+		return
 	}
 
-	type DateCount struct {
-		Date  string `json:"date"`
-		Count int    `json:"count"`
+	counts := make(map[string]int)
+	var first time.Time
+	for _, s := range slots {
+		t := slotTime(s.Slot)
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		counts[r.bucketLabel(r.bucketStart(t))] += s.Count
 	}
-	result := make([]DateCount, 0, len(byDate))
-	for date, count := range byDate {
-		result = append(result, DateCount{Date: date, Count: count})
+
+	breakdowns := make(map[string]map[string]int)
+	if keyExpr != "" {
+		var rows []struct {
+			Slot      int64
+			BucketKey string
+			Count     int
+		}
+		args := append(append(joinArgs, formID), filterArgs...)
+		if err := database.DB.Raw(
+			"SELECT "+slotExpr+" AS slot, "+keyExpr+" AS bucket_key, COUNT(*) AS count "+
+				"FROM submissions "+join+" WHERE submissions.form_id = ? AND "+filter+
+				" GROUP BY slot, bucket_key", args...).
+			Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute statistics"})
+			return
+		}
+		for _, row := range rows {
+			label := r.bucketLabel(r.bucketStart(slotTime(row.Slot)))
+			key := labelAnswer(row.BucketKey)
+			if breakdowns[label] == nil {
+				breakdowns[label] = make(map[string]int)
+			}
+			breakdowns[label][key] += row.Count
+		}
+	}
+
+	result := make([]SubmissionsByDateResponse, 0)
+	if first.IsZero() && r.From == nil {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	buckets, ok := r.buckets(first, time.Now())
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date range too large for granularity"})
+		return
+	}
+	for _, b := range buckets {
+		label := r.bucketLabel(b)
+		result = append(result, SubmissionsByDateResponse{
+			Date:      label,
+			Count:     counts[label],
+			Breakdown: breakdowns[label],
+		})
 	}
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].Date < result[j].Date
-	})
 
 	c.JSON(http.StatusOK, result)
 }
@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"formera/internal/mailer"
 	"formera/internal/models"
 	"formera/internal/testutil"
 
@@ -30,7 +34,7 @@ func TestSubmissionHandler_Submit(t *testing.T) {
 	}
 	db.Create(form)
 
-	handler := NewSubmissionHandler()
+	handler := NewSubmissionHandler(nil, nil, nil)
 	router := gin.New()
 	router.POST("/public/forms/:id/submit", handler.Submit)
 
@@ -75,7 +79,7 @@ func TestSubmissionHandler_Submit_RequiredFieldMissing(t *testing.T) {
 	}
 	db.Create(form)
 
-	handler := NewSubmissionHandler()
+	handler := NewSubmissionHandler(nil, nil, nil)
 	router := gin.New()
 	router.POST("/public/forms/:id/submit", handler.Submit)
 
@@ -106,7 +110,7 @@ func TestSubmissionHandler_Submit_FormNotPublished(t *testing.T) {
 	}
 	db.Create(form)
 
-	handler := NewSubmissionHandler()
+	handler := NewSubmissionHandler(nil, nil, nil)
 	router := gin.New()
 	router.POST("/public/forms/:id/submit", handler.Submit)
 
@@ -143,7 +147,7 @@ func TestSubmissionHandler_Submit_MaxSubmissionsReached(t *testing.T) {
 	// Create existing submission
 	db.Create(&models.Submission{FormID: form.ID, Data: map[string]interface{}{}})
 
-	handler := NewSubmissionHandler()
+	handler := NewSubmissionHandler(nil, nil, nil)
 	router := gin.New()
 	router.POST("/public/forms/:id/submit", handler.Submit)
 
@@ -177,7 +181,7 @@ func TestSubmissionHandler_Submit_SanitizesXSS(t *testing.T) {
 	}
 	db.Create(form)
 
-	handler := NewSubmissionHandler()
+	handler := NewSubmissionHandler(nil, nil, nil)
 	router := gin.New()
 	router.POST("/public/forms/:id/submit", handler.Submit)
 
@@ -220,7 +224,7 @@ func TestSubmissionHandler_List(t *testing.T) {
 	db.Create(&models.Submission{FormID: form.ID, Data: map[string]interface{}{"field1": "value1"}})
 	db.Create(&models.Submission{FormID: form.ID, Data: map[string]interface{}{"field1": "value2"}})
 
-	handler := NewSubmissionHandler()
+	handler := NewSubmissionHandler(nil, nil, nil)
 	router := gin.New()
 	router.GET("/forms/:id/submissions", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -237,6 +241,60 @@ func TestSubmissionHandler_List(t *testing.T) {
 	}
 }
 
+func TestSubmissionHandler_List_Filter(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Test Form",
+		Fields: models.FormFields{{ID: "plan", Type: models.FieldTypeSelect, Label: "Plan"}},
+	}
+	db.Create(form)
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"plan": "pro"}, CreatedAt: time.Now().Add(-48 * time.Hour)})
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"plan": "pro"}})
+	db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"plan": "free"}})
+
+	handler := NewSubmissionHandler(nil, nil, nil)
+	router := gin.New()
+	router.GET("/forms/:id/submissions", func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		handler.List(c)
+	})
+	list := func(query string) (int, int64) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/forms/"+form.ID+"/submissions"+query, nil))
+		var resp struct {
+			Submissions struct {
+				TotalItems int64 `json:"total_items"`
+			} `json:"submissions"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Submissions.TotalItems
+	}
+
+	tests := []struct {
+		query string
+		want  int64
+	}{
+		{"", 3},
+		{"?filter[plan]=pro", 2},
+		{"?last=24h", 2},
+		{"?last=24h&filter[plan]=pro", 1},
+	}
+	for _, tt := range tests {
+		code, total := list(tt.query)
+		if code != http.StatusOK || total != tt.want {
+			t.Errorf("%q: expected %d submissions, got %d (status %d)", tt.query, tt.want, total, code)
+		}
+	}
+	for _, query := range []string{"?filter[missing]=x", "?last=soon", "?last=7d&from=2025-01-01"} {
+		if code, _ := list(query); code != http.StatusBadRequest {
+			t.Errorf("%q: expected status %d, got %d", query, http.StatusBadRequest, code)
+		}
+	}
+}
+
 func TestSubmissionHandler_List_WrongUser(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	owner := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleUser)
@@ -245,7 +303,7 @@ func TestSubmissionHandler_List_WrongUser(t *testing.T) {
 	form := &models.Form{UserID: owner.ID, Title: "Test Form", Status: models.FormStatusPublished}
 	db.Create(form)
 
-	handler := NewSubmissionHandler()
+	handler := NewSubmissionHandler(nil, nil, nil)
 	router := gin.New()
 	router.GET("/forms/:id/submissions", func(c *gin.Context) {
 		c.Set("user_id", otherUser.ID) // Different user
@@ -272,7 +330,7 @@ func TestSubmissionHandler_Delete(t *testing.T) {
 	submission := &models.Submission{FormID: form.ID, Data: map[string]interface{}{"field1": "value1"}}
 	db.Create(submission)
 
-	handler := NewSubmissionHandler()
+	handler := NewSubmissionHandler(nil, nil, nil)
 	router := gin.New()
 	router.DELETE("/forms/:id/submissions/:submissionId", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -315,7 +373,7 @@ func TestSubmissionHandler_Stats(t *testing.T) {
 	db.Create(&models.Submission{FormID: form.ID, Data: map[string]interface{}{"rating": "good"}})
 	db.Create(&models.Submission{FormID: form.ID, Data: map[string]interface{}{"rating": "bad"}})
 
-	handler := NewSubmissionHandler()
+	handler := NewSubmissionHandler(nil, nil, nil)
 	router := gin.New()
 	router.GET("/forms/:id/stats", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -340,3 +398,464 @@ func TestSubmissionHandler_Stats(t *testing.T) {
 		t.Errorf("expected 3 total submissions, got %v", response["total_submissions"])
 	}
 }
+
+func TestSubmissionHandler_Submit_AnonymousForm(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Anonymous Survey",
+		Status: models.FormStatusPublished,
+		Settings: models.FormSettings{
+			Privacy: &models.FormPrivacy{Anonymous: true},
+		},
+	}
+	db.Create(form)
+
+	handler := NewSubmissionHandler(nil, nil, nil)
+	router := gin.New()
+	router.POST("/public/forms/:id/submit", handler.Submit)
+
+	body := SubmitRequest{
+		Data:     map[string]interface{}{"field1": "value"},
+		Metadata: map[string]string{"utm_source": "newsletter", "member_id": "42"},
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/public/forms/"+form.ID+"/submit", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	req.Header.Set("Referer", "https://example.com/")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var submission models.Submission
+	db.First(&submission, "form_id = ?", form.ID)
+
+	meta := submission.Metadata
+	if meta.IP != "" || meta.UserAgent != "" || meta.Referrer != "" || len(meta.Tracking) > 0 {
+		t.Errorf("anonymous submission stored identifying metadata: %+v", meta)
+	}
+	if meta.UTMSource != "newsletter" {
+		t.Errorf("expected utm_source to be kept, got %q", meta.UTMSource)
+	}
+}
+
+func TestSubmissionHandler_Submit_TruncatesIP(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	db.Model(&models.Settings{}).Where("id = ?", 1).Update("ip_storage", models.IPStorageTruncated)
+
+	form := &models.Form{UserID: user.ID, Title: "Test Form", Status: models.FormStatusPublished}
+	db.Create(form)
+
+	handler := NewSubmissionHandler(nil, nil, nil)
+	router := gin.New()
+	router.POST("/public/forms/:id/submit", handler.Submit)
+
+	jsonBody, _ := json.Marshal(SubmitRequest{Data: map[string]interface{}{"field1": "value"}})
+	req := httptest.NewRequest(http.MethodPost, "/public/forms/"+form.ID+"/submit", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "203.0.113.42:12345"
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var submission models.Submission
+	db.First(&submission, "form_id = ?", form.ID)
+
+	if submission.Metadata.IP != "203.0.113.0" {
+		t.Errorf("expected truncated IP 203.0.113.0, got %q", submission.Metadata.IP)
+	}
+}
+
+func TestSubmissionHandler_Submit_SendsNotification(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleUser)
+	sink := testutil.StartSMTPSink(t)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Contact",
+		Status: models.FormStatusPublished,
+		Fields: models.FormFields{
+			{ID: "field1", Label: "Message", Type: "text"},
+		},
+		Settings: models.FormSettings{NotifyOnSubmission: true},
+	}
+	db.Create(form)
+
+	mail := mailer.New(mailer.Config{
+		Host:       sink.Host(),
+		Port:       sink.Port(),
+		Encryption: mailer.EncryptionNone,
+		From:       "forms@example.com",
+	})
+	mail.Start()
+	defer mail.Stop()
+
+	handler := NewSubmissionHandler(mail, nil, nil)
+	router := gin.New()
+	router.POST("/public/forms/:id/submit", handler.Submit)
+
+	jsonBody, _ := json.Marshal(SubmitRequest{Data: map[string]interface{}{"field1": "Hello there"}})
+	req := httptest.NewRequest(http.MethodPost, "/public/forms/"+form.ID+"/submit", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	msgs := sink.WaitForMessages(t, 1, 5*time.Second)
+	if msgs[0].To[0] != "owner@example.com" {
+		t.Errorf("expected notification to form owner, got %v", msgs[0].To)
+	}
+	if !strings.Contains(msgs[0].Data, "Message: Hello there") {
+		t.Errorf("expected labelled answer in notification, got:\n%s", msgs[0].Data)
+	}
+}
+
+func TestSubmissionHandler_Submit_SendsConfirmation(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleUser)
+	sink := testutil.StartSMTPSink(t)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Registration",
+		Status: models.FormStatusPublished,
+		Fields: models.FormFields{
+			{ID: "name", Label: "Name", Type: models.FieldTypeText},
+			{ID: "email", Label: "Email", Type: models.FieldTypeEmail},
+		},
+		Settings: models.FormSettings{
+			SendConfirmation:       true,
+			ConfirmationEmailField: "email",
+			ConfirmationSubject:    "Thanks {{name}}",
+		},
+	}
+	db.Create(form)
+
+	mail := mailer.New(mailer.Config{
+		Host:       sink.Host(),
+		Port:       sink.Port(),
+		Encryption: mailer.EncryptionNone,
+		From:       "forms@example.com",
+	})
+	mail.Start()
+	defer mail.Stop()
+
+	handler := NewSubmissionHandler(mail, nil, nil)
+	router := gin.New()
+	router.POST("/public/forms/:id/submit", handler.Submit)
+
+	jsonBody, _ := json.Marshal(SubmitRequest{Data: map[string]interface{}{"name": "Jane", "email": "jane@example.com"}})
+	req := httptest.NewRequest(http.MethodPost, "/public/forms/"+form.ID+"/submit", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	msgs := sink.WaitForMessages(t, 1, 5*time.Second)
+	if msgs[0].To[0] != "jane@example.com" {
+		t.Errorf("expected confirmation to respondent, got %v", msgs[0].To)
+	}
+	if !strings.Contains(msgs[0].Data, "Subject: Thanks Jane") {
+		t.Errorf("expected rendered subject, got:\n%s", msgs[0].Data)
+	}
+}
+
+// statsInMemory is the reference implementation the SQL aggregation must match
+func statsInMemory(fields models.FormFields, submissions []models.Submission) map[string]map[string]int {
+	result := make(map[string]map[string]int)
+	for _, field := range fields {
+		stats := make(map[string]int)
+		for _, sub := range submissions {
+			switch v := sub.Data[field.ID].(type) {
+			case string:
+				stats[v]++
+			case []interface{}:
+				for _, item := range v {
+					if str, ok := item.(string); ok {
+						stats[str]++
+					}
+				}
+			}
+		}
+		if len(stats) > 0 {
+			result[field.ID] = stats
+		}
+	}
+	return result
+}
+
+func TestSubmissionHandler_Stats_MatchesInMemory(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Test Form",
+		Fields: models.FormFields{
+			{ID: "color", Label: "Color", Type: models.FieldTypeSelect},
+			{ID: "topics", Label: "Topics", Type: models.FieldTypeCheckbox},
+			{ID: "age", Label: "Age", Type: models.FieldTypeNumber},
+			{ID: "field.with \"quotes\"", Label: "Odd", Type: models.FieldTypeText},
+		},
+	}
+	db.Create(form)
+
+	data := []models.SubmissionData{
+		{"color": "red", "topics": []interface{}{"a", "b"}, "age": float64(30)},
+		{"color": "red", "topics": []interface{}{"a", float64(1), "a"}, "field.with \"quotes\"": "x"},
+		{"color": "", "topics": []interface{}{}, "removed": "ignored"},
+		{"color": "Rot ✓"},
+		{},
+	}
+	for _, d := range data {
+		db.Create(&models.Submission{FormID: form.ID, Data: d})
+	}
+	db.Create(&models.Submission{FormID: "other-form", Data: models.SubmissionData{"color": "red"}})
+
+	var submissions []models.Submission
+	db.Where("form_id = ?", form.ID).Find(&submissions)
+	expected := statsInMemory(form.Fields, submissions)
+
+	handler := NewSubmissionHandler(nil, nil, nil)
+	router := gin.New()
+	router.GET("/forms/:id/stats", func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		handler.Stats(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/forms/"+form.ID+"/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response struct {
+		TotalSubmissions int                       `json:"total_submissions"`
+		FieldStats       map[string]map[string]int `json:"field_stats"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+
+	if response.TotalSubmissions != len(data) {
+		t.Errorf("expected %d submissions, got %d", len(data), response.TotalSubmissions)
+	}
+	if !reflect.DeepEqual(response.FieldStats, expected) {
+		t.Errorf("field stats differ from in-memory result:\ngot      %v\nexpected %v", response.FieldStats, expected)
+	}
+}
+
+func getSubmissionsByDate(t *testing.T, userID, formID, query string) (int, []SubmissionsByDateResponse) {
+	t.Helper()
+	handler := NewSubmissionHandler(nil, nil, nil)
+	router := gin.New()
+	router.GET("/forms/:id/submissions/by-date", func(c *gin.Context) {
+		c.Set("user_id", userID)
+		handler.SubmissionsByDate(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/forms/"+formID+"/submissions/by-date"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response []SubmissionsByDateResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+	}
+	return w.Code, response
+}
+
+func TestSubmissionHandler_SubmissionsByDate(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{UserID: user.ID, Title: "Test Form"}
+	db.Create(form)
+
+	cet := time.FixedZone("CET", 3600)
+	for _, ts := range []time.Time{
+		time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 1, 15, 23, 30, 0, 0, time.UTC),
+		time.Date(2025, 1, 16, 0, 30, 0, 0, cet), // 2025-01-15 23:30 UTC
+		time.Date(2025, 1, 17, 8, 0, 0, 0, time.UTC),
+	} {
+		db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{}, CreatedAt: ts})
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []SubmissionsByDateResponse
+	}{
+		{
+			name:  "UTC days, zero-filled",
+			query: "?from=2025-01-14&to=2025-01-17",
+			expected: []SubmissionsByDateResponse{
+				{Date: "2025-01-14"}, {Date: "2025-01-15", Count: 3}, {Date: "2025-01-16"}, {Date: "2025-01-17", Count: 1},
+			},
+		},
+		{
+			name:  "time zone shifts late submissions to the next day",
+			query: "?from=2025-01-15&to=2025-01-17&tz=Europe/Berlin",
+			expected: []SubmissionsByDateResponse{
+				{Date: "2025-01-15", Count: 1}, {Date: "2025-01-16", Count: 2}, {Date: "2025-01-17", Count: 1},
+			},
+		},
+		{
+			name:     "weeks start on Monday",
+			query:    "?from=2025-01-13&to=2025-01-19&granularity=week",
+			expected: []SubmissionsByDateResponse{{Date: "2025-01-13", Count: 4}},
+		},
+		{
+			name:     "months",
+			query:    "?from=2025-01-01&to=2025-02-28&granularity=month",
+			expected: []SubmissionsByDateResponse{{Date: "2025-01", Count: 4}, {Date: "2025-02"}},
+		},
+		{
+			name:  "hours in range",
+			query: "?from=2025-01-15T22:00:00Z&to=2025-01-16T01:00:00Z&granularity=hour",
+			expected: []SubmissionsByDateResponse{
+				{Date: "2025-01-15T22:00:00Z"}, {Date: "2025-01-15T23:00:00Z", Count: 2}, {Date: "2025-01-16T00:00:00Z"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, response := getSubmissionsByDate(t, user.ID, form.ID, tt.query)
+			if code != http.StatusOK {
+				t.Fatalf("expected status %d, got %d", http.StatusOK, code)
+			}
+			if !reflect.DeepEqual(response, tt.expected) {
+				t.Errorf("got %+v, expected %+v", response, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSubmissionHandler_SubmissionsByDate_Breakdown(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Test Form",
+		Fields: models.FormFields{
+			{ID: "topics", Label: "Topics", Type: models.FieldTypeCheckbox},
+			{ID: "name", Label: "Name", Type: models.FieldTypeText},
+		},
+	}
+	db.Create(form)
+
+	day := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	db.Create(&models.Submission{FormID: form.ID, CreatedAt: day,
+		Data:     models.SubmissionData{"topics": []interface{}{"a", "b"}},
+		Metadata: models.SubmissionMetadata{UTMSource: "newsletter"}})
+	db.Create(&models.Submission{FormID: form.ID, CreatedAt: day,
+		Data:     models.SubmissionData{"topics": []interface{}{"a"}},
+		Metadata: models.SubmissionMetadata{UTMSource: "newsletter"}})
+	db.Create(&models.Submission{FormID: form.ID, CreatedAt: day, Data: models.SubmissionData{}})
+
+	_, response := getSubmissionsByDate(t, user.ID, form.ID, "?from=2025-03-01&to=2025-03-01&breakdown=utm_source")
+	expected := []SubmissionsByDateResponse{{Date: "2025-03-01", Count: 3, Breakdown: map[string]int{"newsletter": 2, "(none)": 1}}}
+	if !reflect.DeepEqual(response, expected) {
+		t.Errorf("utm breakdown: got %+v, expected %+v", response, expected)
+	}
+
+	_, response = getSubmissionsByDate(t, user.ID, form.ID, "?from=2025-03-01&to=2025-03-01&breakdown=field&field_id=topics")
+	expected = []SubmissionsByDateResponse{{Date: "2025-03-01", Count: 3, Breakdown: map[string]int{"a": 2, "b": 1, "(none)": 1}}}
+	if !reflect.DeepEqual(response, expected) {
+		t.Errorf("field breakdown: got %+v, expected %+v", response, expected)
+	}
+
+	for _, query := range []string{
+		"?breakdown=field&field_id=name",
+		"?breakdown=browser",
+		"?granularity=year",
+		"?tz=Mars/Olympus",
+		"?from=2025-03-02&to=2025-03-01",
+		"?from=2000-01-01&granularity=hour",
+	} {
+		if code, _ := getSubmissionsByDate(t, user.ID, form.ID, query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status %d, got %d", query, http.StatusBadRequest, code)
+		}
+	}
+}
+
+func BenchmarkSubmissionHandler_Stats(b *testing.B) {
+	db := testutil.SetupTestDB(b)
+	user := testutil.CreateTestUser(b, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Benchmark",
+		Fields: models.FormFields{
+			{ID: "choice", Label: "Choice", Type: models.FieldTypeRadio},
+			{ID: "topics", Label: "Topics", Type: models.FieldTypeCheckbox},
+			{ID: "comment", Label: "Comment", Type: models.FieldTypeTextarea},
+		},
+	}
+	db.Create(form)
+
+	options := []string{"a", "b", "c", "d"}
+	batch := make([]models.Submission, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		batch = append(batch, models.Submission{
+			FormID: form.ID,
+			Data: models.SubmissionData{
+				"choice":  options[i%4],
+				"topics":  []interface{}{options[i%3], options[(i+1)%4]},
+				"comment": "Lorem ipsum dolor sit amet",
+			},
+			CreatedAt: time.Now().Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	db.CreateInBatches(batch, 500)
+
+	handler := NewSubmissionHandler(nil, nil, nil)
+	router := gin.New()
+	router.GET("/forms/:id/stats", func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		handler.Stats(c)
+	})
+	router.GET("/forms/:id/submissions/by-date", func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		handler.SubmissionsByDate(c)
+	})
+
+	for name, path := range map[string]string{"stats": "/stats", "by-date": "/submissions/by-date"} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/forms/"+form.ID+path, nil)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != http.StatusOK {
+					b.Fatalf("unexpected status %d", w.Code)
+				}
+			}
+		})
+	}
+}
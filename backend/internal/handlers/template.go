@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"formera/internal/database"
+	"formera/internal/models"
+	"formera/internal/sanitizer"
+	"formera/internal/templates"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTemplateCategoryLength limits the category of a template
+const maxTemplateCategoryLength = 50
+
+// maxTemplateDescriptionLength limits the description of a template
+const maxTemplateDescriptionLength = 500
+
+type TemplateHandler struct{}
+
+func NewTemplateHandler() *TemplateHandler {
+	return &TemplateHandler{}
+}
+
+// MarkTemplateRequest marks a form as instance-wide template
+type MarkTemplateRequest struct {
+	Category    string `json:"category" binding:"required"`
+	Description string `json:"description"`
+}
+
+// UseTemplateRequest creates a form from a template
+type UseTemplateRequest struct {
+	Title string `json:"title"` // Defaults to the title of the template
+}
+
+// List godoc
+// @Summary      List templates
+// @Description  Get the built-in templates followed by the forms marked as templates by admins, ordered by category and title
+// @Tags         Templates
+// @Produce      json
+// @Param        category query string false "Only templates of this category"
+// @Success      200 {array} templates.Template
+// @Failure      401 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /templates [get]
+func (h *TemplateHandler) List(c *gin.Context) {
+	category := c.Query("category")
+
+	list := []templates.Template{}
+	for _, t := range templates.BuiltIn() {
+		if category == "" || t.Category == category {
+			list = append(list, t)
+		}
+	}
+
+	query := database.DB.Where("is_template = ?", true)
+	if category != "" {
+		query = query.Where("template_category = ?", category)
+	}
+	var forms []models.Form
+	if result := query.Order("template_category, title").Find(&forms); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch templates"})
+		return
+	}
+	for i := range forms {
+		list = append(list, templates.FromForm(&forms[i]))
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// Get godoc
+// @Summary      Get template
+// @Description  Get a template with its fields and settings
+// @Tags         Templates
+// @Produce      json
+// @Param        id path string true "Template ID"
+// @Success      200 {object} templates.Template
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /templates/{id} [get]
+func (h *TemplateHandler) Get(c *gin.Context) {
+	t, ok := findTemplate(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// Use godoc
+// @Summary      Create form from template
+// @Description  Create a new draft form with the fields and settings of a template
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Template ID"
+// @Param        request body UseTemplateRequest false "Form title"
+// @Success      201 {object} models.Form
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /templates/{id}/use [post]
+func (h *TemplateHandler) Use(c *gin.Context) {
+	t, ok := findTemplate(c)
+	if !ok {
+		return
+	}
+
+	var req UseTemplateRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	form := t.NewForm(c.GetString("user_id"), sanitizer.StripHTML(strings.TrimSpace(req.Title)))
+	if result := database.DB.Create(form); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create form"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, form)
+}
+
+// Mark godoc
+// @Summary      Mark form as template
+// @Description  Offer a form to all users as template with a category and description, or update them (admin only)
+// @Tags         Templates
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        request body MarkTemplateRequest true "Template details"
+// @Success      200 {object} templates.Template
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/template [put]
+func (h *TemplateHandler) Mark(c *gin.Context) {
+	var form models.Form
+	if result := database.DB.First(&form, "id = ?", c.Param("id")); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Form not found"})
+		return
+	}
+
+	var req MarkTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	category := strings.ToLower(strings.TrimSpace(req.Category))
+	if category == "" || utf8.RuneCountInString(category) > maxTemplateCategoryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category must be 1 to 50 characters"})
+		return
+	}
+	description := sanitizer.StripHTML(strings.TrimSpace(req.Description))
+	if utf8.RuneCountInString(description) > maxTemplateDescriptionLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "description must be at most 500 characters"})
+		return
+	}
+
+	updates := map[string]interface{}{"is_template": true, "template_category": category, "template_description": description}
+	if result := database.DB.Model(&form).Updates(updates); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates.FromForm(&form))
+}
+
+// Unmark godoc
+// @Summary      Remove form from templates
+// @Description  Stop offering a form as template; the form itself is kept (admin only)
+// @Tags         Templates
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Success      200 {object} MessageResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      403 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/template [delete]
+func (h *TemplateHandler) Unmark(c *gin.Context) {
+	var form models.Form
+	if result := database.DB.First(&form, "id = ? AND is_template = ?", c.Param("id"), true); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	updates := map[string]interface{}{"is_template": false, "template_category": "", "template_description": ""}
+	if result := database.DB.Model(&form).Updates(updates); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template removed successfully"})
+}
+
+// findTemplate loads a built-in template or a form marked as template, or responds with 404
+func findTemplate(c *gin.Context) (*templates.Template, bool) {
+	id := c.Param("id")
+	if templates.IsBuiltIn(id) {
+		if t, ok := templates.FindBuiltIn(id); ok {
+			return t, true
+		}
+	} else {
+		var form models.Form
+		if result := database.DB.First(&form, "id = ? AND is_template = ?", id, true); result.Error == nil {
+			t := templates.FromForm(&form)
+			return &t, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+	return nil, false
+}
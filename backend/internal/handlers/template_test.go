@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/templates"
+	"formera/internal/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTemplateHandler(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	admin := testutil.CreateTestUser(t, db, "admin@example.com", "password123", models.RoleAdmin)
+	user := testutil.CreateTestUser(t, db, "user@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID:   admin.ID,
+		Title:    "Job application",
+		Status:   models.FormStatusPublished,
+		Fields:   models.FormFields{{ID: "cv", Type: models.FieldTypeFile, Label: "CV"}},
+		Settings: models.FormSettings{NotificationEmail: "hr@example.com"},
+	}
+	db.Create(form)
+
+	handler := NewTemplateHandler()
+	router := func(userID string) *gin.Engine {
+		r := gin.New()
+		setUser := func(c *gin.Context) { c.Set("user_id", userID) }
+		r.GET("/templates", setUser, handler.List)
+		r.GET("/templates/:id", setUser, handler.Get)
+		r.POST("/templates/:id/use", setUser, handler.Use)
+		r.PUT("/forms/:id/template", setUser, handler.Mark)
+		r.DELETE("/forms/:id/template", setUser, handler.Unmark)
+		return r
+	}
+	do := func(userID, method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router(userID).ServeHTTP(w, req)
+		return w
+	}
+	list := func(url string) []templates.Template {
+		w := do(user.ID, http.MethodGet, url, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var result []templates.Template
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return result
+	}
+
+	if got := list("/templates"); len(got) != len(templates.BuiltIn()) {
+		t.Fatalf("expected only the built-in templates, got %d", len(got))
+	}
+	if w := do(user.ID, http.MethodGet, "/templates/"+form.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for a form that is no template, got %d", http.StatusNotFound, w.Code)
+	}
+
+	if w := do(admin.ID, http.MethodPut, "/forms/"+form.ID+"/template", `{"category": "  "}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an empty category, got %d", http.StatusBadRequest, w.Code)
+	}
+	w := do(admin.ID, http.MethodPut, "/forms/"+form.ID+"/template", `{"category": "HR", "description": "<b>Apply</b> for a job"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	got := list("/templates?category=hr")
+	if len(got) != 1 || got[0].ID != form.ID || got[0].Description != "Apply for a job" || got[0].Settings.NotificationEmail != "" {
+		t.Fatalf("unexpected templates %+v", got)
+	}
+	if got := list("/templates"); len(got) != len(templates.BuiltIn())+1 || !got[0].BuiltIn {
+		t.Errorf("expected built-in templates first, got %+v", got)
+	}
+
+	w = do(user.ID, http.MethodPost, "/templates/"+form.ID+"/use", `{"title": "Developer position"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Form
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.UserID != user.ID || created.Title != "Developer position" || created.Status != models.FormStatusDraft || len(created.Fields) != 1 {
+		t.Errorf("unexpected form %+v", created)
+	}
+
+	w = do(user.ID, http.MethodPost, "/templates/builtin-feedback/use", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Title != "Feedback" || created.Settings.Privacy == nil || !created.Settings.Privacy.Anonymous {
+		t.Errorf("unexpected form from built-in template %+v", created)
+	}
+
+	if w := do(admin.ID, http.MethodDelete, "/forms/"+form.ID+"/template", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if got := list("/templates?category=hr"); len(got) != 0 {
+		t.Errorf("expected the template to be removed, got %+v", got)
+	}
+	if w := do(admin.ID, http.MethodDelete, "/forms/"+form.ID+"/template", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Granularities for time series endpoints
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityWeek  = "week" // Weeks start on Monday
+	GranularityMonth = "month"
+)
+
+// maxBuckets limits the size of zero-filled time series
+const maxBuckets = 5000
+
+// slotSeconds is the resolution at which SQL pre-aggregates timestamps. 15 minutes
+// divides every UTC offset in use, so slots can be re-bucketed in any time zone.
+const slotSeconds = 15 * 60
+
+// slotExpr converts created_at to its 15 minute UTC slot in SQL
+const slotExpr = "CAST(strftime('%s', created_at) AS INTEGER) / 900"
+
+// timeRange holds the parsed from, to, granularity and tz query parameters
+type timeRange struct {
+	From        *time.Time // Inclusive, nil if open
+	To          *time.Time // Exclusive, nil if open
+	Granularity string
+	Location    *time.Location
+}
+
+// parseTimeRange reads the time series query parameters. Dates without time
+// are interpreted in tz, and a date-only "to" includes the whole day.
+// Returns an error message for invalid input.
+func parseTimeRange(c *gin.Context) (*timeRange, string) {
+	r := &timeRange{Granularity: GranularityDay, Location: time.UTC}
+
+	if tz := c.Query("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, "Invalid time zone"
+		}
+		r.Location = loc
+	}
+
+	if g := c.Query("granularity"); g != "" {
+		switch g {
+		case GranularityHour, GranularityDay, GranularityWeek, GranularityMonth:
+			r.Granularity = g
+		default:
+			return nil, "Invalid granularity (expected hour, day, week or month)"
+		}
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, _, ok := parseRangeTime(from, r.Location)
+		if !ok {
+			return nil, "Invalid from date"
+		}
+		r.From = &t
+	}
+	if to := c.Query("to"); to != "" {
+		t, dateOnly, ok := parseRangeTime(to, r.Location)
+		if !ok {
+			return nil, "Invalid to date"
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		r.To = &t
+	}
+	if r.From != nil && r.To != nil && !r.From.Before(*r.To) {
+		return nil, "from must be before to"
+	}
+
+	return r, ""
+}
+
+func parseRangeTime(value string, loc *time.Location) (time.Time, bool, bool) {
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, true, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04", value, loc); err == nil {
+		return t, false, true
+	}
+	return time.Time{}, false, false
+}
+
+// bucketStart returns the start of the bucket containing t in the range's time zone
+func (r *timeRange) bucketStart(t time.Time) time.Time {
+	t = t.In(r.Location)
+	switch r.Granularity {
+	case GranularityHour:
+		// Works for half-hour offsets, unlike Truncate
+		return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	case GranularityWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, r.Location)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, r.Location)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, r.Location)
+	}
+}
+
+// nextBucket returns the start of the bucket following start
+func (r *timeRange) nextBucket(start time.Time) time.Time {
+	switch r.Granularity {
+	case GranularityHour:
+		return start.Add(time.Hour)
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// bucketLabel formats a bucket start: RFC 3339 for hours, the date for days
+// and weeks, and year-month for months
+func (r *timeRange) bucketLabel(start time.Time) string {
+	switch r.Granularity {
+	case GranularityHour:
+		return start.Format(time.RFC3339)
+	case GranularityMonth:
+		return start.Format("2006-01")
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+// sqlFilter returns the SQL condition and arguments restricting created_at to the range
+func (r *timeRange) sqlFilter() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if r.From != nil {
+		conds = append(conds, "CAST(strftime('%s', created_at) AS INTEGER) >= ?")
+		args = append(args, r.From.Unix())
+	}
+	if r.To != nil {
+		conds = append(conds, "CAST(strftime('%s', created_at) AS INTEGER) < ?")
+		args = append(args, r.To.Unix())
+	}
+	if len(conds) == 0 {
+		return "1 = 1", nil
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// buckets lists the zero-filled bucket starts covering the range. An open start
+// defaults to first (the earliest data point), an open end to last.
+// Returns false if the range has more than maxBuckets buckets.
+func (r *timeRange) buckets(first, last time.Time) ([]time.Time, bool) {
+	from, to := first, last
+	if r.From != nil {
+		from = *r.From
+	}
+	if r.To != nil {
+		to = r.To.Add(-time.Nanosecond)
+	}
+	if to.Before(from) {
+		return nil, true
+	}
+
+	end := r.bucketStart(to)
+	var result []time.Time
+	for b := r.bucketStart(from); !b.After(end); b = r.nextBucket(b) {
+		if len(result) == maxBuckets {
+			return nil, false
+		}
+		result = append(result, b)
+	}
+	return result, true
+}
+
+// slotTime converts a 15 minute UTC slot number back to a time
+func slotTime(slot int64) time.Time {
+	return time.Unix(slot*slotSeconds, 0).UTC()
+}
@@ -8,6 +8,12 @@ type ErrorResponse struct {
 	Error string `json:"error" example:"Invalid request"`
 }
 
+// StaleFormResponse is returned when a form was changed since the revision given in If-Match
+type StaleFormResponse struct {
+	Error string      `json:"error" example:"Das Formular wurde inzwischen geändert. Bitte lade die aktuelle Version."`
+	Form  interface{} `json:"form,omitempty"` // Current state of the form
+}
+
 // MessageResponse represents a simple message response
 type MessageResponse struct {
 	Message string `json:"message" example:"Operation successful"`
@@ -23,6 +29,7 @@ type SlugCheckResponse struct {
 // SubmissionListResponse represents paginated submissions
 type SubmissionListResponse struct {
 	Form        interface{} `json:"form"`
+	Versions    interface{} `json:"versions"` // Fields of older form versions answered by the listed submissions, by version
 	Submissions interface{} `json:"submissions"`
 }
 
@@ -34,6 +41,7 @@ type FormStatsResponse struct {
 
 // SubmissionsByDateResponse represents submissions grouped by date
 type SubmissionsByDateResponse struct {
-	Date  string `json:"date" example:"2025-01-15"`
-	Count int    `json:"count" example:"25"`
+	Date      string         `json:"date" example:"2025-01-15"`
+	Count     int            `json:"count" example:"25"`
+	Breakdown map[string]int `json:"breakdown,omitempty"` // Counts per UTM source or answer, "(none)" if missing
 }
@@ -6,14 +6,19 @@ import (
 	"formera/internal/database"
 	"formera/internal/models"
 	"formera/internal/pagination"
+	"formera/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 )
 
-type UserHandler struct{}
+type UserHandler struct {
+	webhooks *webhooks.Dispatcher
+}
 
-func NewUserHandler() *UserHandler {
-	return &UserHandler{}
+// NewUserHandler creates a new user handler. The webhook dispatcher may be nil
+// if deleted events should not be sent when a user's forms are deleted.
+func NewUserHandler(hooks *webhooks.Dispatcher) *UserHandler {
+	return &UserHandler{webhooks: hooks}
 }
 
 type CreateUserRequest struct {
@@ -190,16 +195,52 @@ func (h *UserHandler) Delete(c *gin.Context) {
 	}
 
 	// Get all forms by this user
-	var formIDs []string
-	tx.Model(&models.Form{}).Where("user_id = ?", id).Pluck("id", &formIDs)
+	var forms []models.Form
+	tx.Where("user_id = ?", id).Find(&forms)
+	formIDs := make([]string, len(forms))
+	for i, form := range forms {
+		formIDs[i] = form.ID
+	}
 
-	// Delete all submissions for user's forms
+	// Delete all submissions for user's forms, keeping what the deleted events need
+	var submissions []models.Submission
 	if len(formIDs) > 0 {
+		tx.Select("id", "form_id", "created_at").Where("form_id IN ?", formIDs).Find(&submissions)
 		if result := tx.Where("form_id IN ?", formIDs).Delete(&models.Submission{}); result.Error != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete submissions"})
 			return
 		}
+		if err := deleteFormAnalytics(tx, formIDs); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete analytics"})
+			return
+		}
+		if result := tx.Where("form_id IN ?", formIDs).Delete(&models.Export{}); result.Error != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete exports"})
+			return
+		}
+		if result := tx.Where("form_id IN ?", formIDs).Delete(&models.ExportPreset{}); result.Error != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete export presets"})
+			return
+		}
+		if result := tx.Where("form_id IN ?", formIDs).Delete(&models.ExportSchedule{}); result.Error != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete export schedules"})
+			return
+		}
+		if result := tx.Where("form_id IN ?", formIDs).Delete(&models.FormVersion{}); result.Error != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete form versions"})
+			return
+		}
+		if result := tx.Where("form_id IN ?", formIDs).Delete(&models.FormShare{}); result.Error != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete form shares"})
+			return
+		}
 	}
 
 	// Delete all forms by this user
@@ -209,6 +250,13 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	// Access to forms of other users
+	if result := tx.Where("user_id = ?", id).Delete(&models.FormShare{}); result.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete form shares"})
+		return
+	}
+
 	// Delete the user
 	if result := tx.Delete(&user); result.Error != nil {
 		tx.Rollback()
@@ -221,5 +269,9 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	if len(forms) > 0 {
+		dispatchFormsDeleted(h.webhooks, forms, submissions)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
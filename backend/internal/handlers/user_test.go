@@ -19,7 +19,7 @@ func TestUserHandler_List(t *testing.T) {
 	testutil.CreateTestUser(t, db, "user1@example.com", "password123", models.RoleUser)
 	testutil.CreateTestUser(t, db, "user2@example.com", "password123", models.RoleAdmin)
 
-	handler := NewUserHandler()
+	handler := NewUserHandler(nil)
 	router := gin.New()
 	router.GET("/users", handler.List)
 
@@ -46,7 +46,7 @@ func TestUserHandler_Get(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
 
-	handler := NewUserHandler()
+	handler := NewUserHandler(nil)
 	router := gin.New()
 	router.GET("/users/:id", handler.Get)
 
@@ -72,7 +72,7 @@ func TestUserHandler_Get(t *testing.T) {
 func TestUserHandler_Get_NotFound(t *testing.T) {
 	testutil.SetupTestDB(t)
 
-	handler := NewUserHandler()
+	handler := NewUserHandler(nil)
 	router := gin.New()
 	router.GET("/users/:id", handler.Get)
 
@@ -89,7 +89,7 @@ func TestUserHandler_Get_NotFound(t *testing.T) {
 func TestUserHandler_Create(t *testing.T) {
 	testutil.SetupTestDB(t)
 
-	handler := NewUserHandler()
+	handler := NewUserHandler(nil)
 	router := gin.New()
 	router.POST("/users", handler.Create)
 
@@ -125,7 +125,7 @@ func TestUserHandler_Create_DuplicateEmail(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	testutil.CreateTestUser(t, db, "existing@example.com", "password123", models.RoleUser)
 
-	handler := NewUserHandler()
+	handler := NewUserHandler(nil)
 	router := gin.New()
 	router.POST("/users", handler.Create)
 
@@ -152,7 +152,7 @@ func TestUserHandler_Update(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
 
-	handler := NewUserHandler()
+	handler := NewUserHandler(nil)
 	router := gin.New()
 	router.PUT("/users/:id", handler.Update)
 
@@ -186,7 +186,7 @@ func TestUserHandler_Delete(t *testing.T) {
 	admin := testutil.CreateTestUser(t, db, "admin@example.com", "password123", models.RoleAdmin)
 	user := testutil.CreateTestUser(t, db, "user@example.com", "password123", models.RoleUser)
 
-	handler := NewUserHandler()
+	handler := NewUserHandler(nil)
 	router := gin.New()
 	router.DELETE("/users/:id", func(c *gin.Context) {
 		c.Set("user_id", admin.ID)
@@ -207,7 +207,7 @@ func TestUserHandler_Delete_SelfDelete(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	user := testutil.CreateTestUser(t, db, "user@example.com", "password123", models.RoleUser)
 
-	handler := NewUserHandler()
+	handler := NewUserHandler(nil)
 	router := gin.New()
 	router.DELETE("/users/:id", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -229,7 +229,7 @@ func TestUserHandler_Delete_LastAdmin(t *testing.T) {
 	admin := testutil.CreateTestUser(t, db, "admin@example.com", "password123", models.RoleAdmin)
 	user := testutil.CreateTestUser(t, db, "user@example.com", "password123", models.RoleUser)
 
-	handler := NewUserHandler()
+	handler := NewUserHandler(nil)
 	router := gin.New()
 	router.DELETE("/users/:id", func(c *gin.Context) {
 		c.Set("user_id", user.ID)
@@ -250,7 +250,7 @@ func TestUserHandler_Delete_LastUser(t *testing.T) {
 	db := testutil.SetupTestDB(t)
 	user := testutil.CreateTestUser(t, db, "user@example.com", "password123", models.RoleUser)
 
-	handler := NewUserHandler()
+	handler := NewUserHandler(nil)
 	router := gin.New()
 	router.DELETE("/users/:id", func(c *gin.Context) {
 		c.Set("user_id", "some-other-id")
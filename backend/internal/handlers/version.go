@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"formera/internal/database"
+	"formera/internal/logger"
+	"formera/internal/models"
+	"formera/internal/versions"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionHandler serves the versions of a form's fields
+type VersionHandler struct{}
+
+// NewVersionHandler creates a new form version handler
+func NewVersionHandler() *VersionHandler {
+	return &VersionHandler{}
+}
+
+// FormVersionSummary is a version without its fields
+type FormVersionSummary struct {
+	Version     int       `json:"version"`
+	Title       string    `json:"title"`
+	Fields      int       `json:"fields"`
+	Submissions int64     `json:"submissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// List godoc
+// @Summary      List form versions
+// @Description  Get the versions of a form's fields, newest first, with the number of submissions made against each. A version is created when the form is published and on each field change while it is not a draft.
+// @Tags         Forms
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Success      200 {array} FormVersionSummary
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/versions [get]
+func (h *VersionHandler) List(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	list, err := versions.List(database.DB, form.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch versions"})
+		return
+	}
+
+	var counts []struct {
+		FormVersion int
+		Count       int64
+	}
+	if err := database.DB.Model(&models.Submission{}).
+		Select("form_version, COUNT(*) AS count").
+		Where("form_id = ?", form.ID).
+		Group("form_version").
+		Scan(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch versions"})
+		return
+	}
+	byVersion := make(map[int]int64, len(counts))
+	for _, count := range counts {
+		byVersion[count.FormVersion] = count.Count
+	}
+
+	summaries := make([]FormVersionSummary, len(list))
+	for i, v := range list {
+		summaries[i] = FormVersionSummary{
+			Version:     v.Version,
+			Title:       v.Title,
+			Fields:      len(v.Fields),
+			Submissions: byVersion[v.Version],
+			CreatedAt:   v.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, summaries)
+}
+
+// Get godoc
+// @Summary      Get form version
+// @Description  Get the fields of a version of a form
+// @Tags         Forms
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        version path int true "Version number"
+// @Success      200 {object} models.FormVersion
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/versions/{version} [get]
+func (h *VersionHandler) Get(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+	version, ok := findVersion(c, form.ID, number)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// Diff godoc
+// @Summary      Compare form versions
+// @Description  List the fields added, removed and changed between two versions of a form. Fields are matched by ID; changed fields list the JSON names of their changed properties.
+// @Tags         Forms
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        from query int true "Version to compare from"
+// @Param        to query int false "Version to compare to (default: current version)"
+// @Success      200 {object} versions.Diff
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/versions/diff [get]
+func (h *VersionHandler) Diff(c *gin.Context) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleViewer) {
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from (expected a version number)"})
+		return
+	}
+	to := form.CurrentVersion
+	if value := c.Query("to"); value != "" {
+		if to, err = strconv.Atoi(value); err != nil || to < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to (expected a version number)"})
+			return
+		}
+	}
+
+	fromVersion, ok := findVersion(c, form.ID, from)
+	if !ok {
+		return
+	}
+	toVersion, ok := findVersion(c, form.ID, to)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, versions.Compare(fromVersion, toVersion))
+}
+
+// findVersion loads a version of a form, or responds with 404
+func findVersion(c *gin.Context, formID string, number int) (*models.FormVersion, bool) {
+	var version models.FormVersion
+	if result := database.DB.Where("form_id = ? AND version = ?", formID, number).First(&version); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Version not found"})
+		return nil, false
+	}
+	return &version, true
+}
+
+// withFieldHistory adds the fields of older versions that are no longer part
+// of the form, so exports keep their answers. Responds with 500 and returns
+// false on failure.
+func withFieldHistory(c *gin.Context, form *models.Form) bool {
+	if err := versions.WithHistory(database.DB, form); err != nil {
+		logger.Error().Err(err).Str("form_id", form.ID).Msg("Failed to load form versions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load form versions"})
+		return false
+	}
+	return true
+}
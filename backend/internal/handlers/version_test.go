@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+	"formera/internal/versions"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFormVersions(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Signup",
+		Status: models.FormStatusDraft,
+		Fields: models.FormFields{{ID: "name", Type: models.FieldTypeText, Label: "Name"}},
+	}
+	db.Create(form)
+
+	formHandler := NewFormHandler(nil)
+	submissionHandler := NewSubmissionHandler(nil, nil, nil)
+	versionHandler := NewVersionHandler()
+	router := func(userID string) *gin.Engine {
+		r := gin.New()
+		setUser := func(c *gin.Context) { c.Set("user_id", userID) }
+		r.PUT("/forms/:id", setUser, formHandler.Update)
+		r.POST("/public/forms/:id/submit", submissionHandler.Submit)
+		r.GET("/forms/:id/submissions", setUser, submissionHandler.List)
+		r.GET("/forms/:id/submissions/:submissionId", setUser, submissionHandler.Get)
+		r.GET("/forms/:id/export/csv", setUser, submissionHandler.ExportCSV)
+		r.GET("/forms/:id/versions", setUser, versionHandler.List)
+		r.GET("/forms/:id/versions/diff", setUser, versionHandler.Diff)
+		r.GET("/forms/:id/versions/:version", setUser, versionHandler.Get)
+		return r
+	}
+	do := func(userID, method, url string, body interface{}) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&buf).Encode(body)
+		}
+		req := httptest.NewRequest(method, url, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router(userID).ServeHTTP(w, req)
+		return w
+	}
+	update := func(status models.FormStatus, fields models.FormFields) models.Form {
+		w := do(user.ID, http.MethodPut, "/forms/"+form.ID, UpdateFormRequest{Status: status, Fields: fields})
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var updated models.Form
+		json.Unmarshal(w.Body.Bytes(), &updated)
+		return updated
+	}
+	submit := func(data map[string]interface{}) string {
+		w := do("", http.MethodPost, "/public/forms/"+form.ID+"/submit", map[string]interface{}{"data": data})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Submission models.Submission `json:"submission"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Submission.ID
+	}
+
+	// Draft edits create no version
+	if updated := update(models.FormStatusDraft, form.Fields); updated.CurrentVersion != 0 {
+		t.Errorf("expected no version for a draft, got %d", updated.CurrentVersion)
+	}
+	if updated := update(models.FormStatusPublished, nil); updated.CurrentVersion != 1 {
+		t.Fatalf("expected version 1 on publish, got %d", updated.CurrentVersion)
+	}
+	first := submit(map[string]interface{}{"name": "Ada"})
+
+	// The field ID changes, like when the field is deleted and added again
+	if updated := update("", models.FormFields{{ID: "full_name", Type: models.FieldTypeText, Label: "Full name"}}); updated.CurrentVersion != 2 {
+		t.Fatalf("expected version 2 after a field change, got %d", updated.CurrentVersion)
+	}
+	submit(map[string]interface{}{"full_name": "Grace Hopper"})
+
+	w := do(user.ID, http.MethodGet, "/forms/"+form.ID+"/submissions/"+first, nil)
+	var sub SubmissionResponse
+	json.Unmarshal(w.Body.Bytes(), &sub)
+	if sub.FormVersion != 1 || len(sub.Fields) != 1 || sub.Fields[0].Label != "Name" {
+		t.Errorf("expected the submission with the fields of version 1, got %+v", sub)
+	}
+
+	w = do(user.ID, http.MethodGet, "/forms/"+form.ID+"/submissions", nil)
+	var list struct {
+		Versions map[string]models.FormFields `json:"versions"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.Versions) != 1 || list.Versions["1"][0].ID != "name" {
+		t.Errorf("expected the fields of version 1 in the list, got %+v", list.Versions)
+	}
+
+	w = do(user.ID, http.MethodGet, "/forms/"+form.ID+"/export/csv", nil)
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if !strings.HasSuffix(strings.TrimSpace(lines[0]), "Full name,Name") || len(lines) != 3 {
+		t.Errorf("expected columns of both versions, got %q", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), ",,Ada") {
+		t.Errorf("expected the answer to the removed field, got %q", w.Body.String())
+	}
+
+	w = do(user.ID, http.MethodGet, "/forms/"+form.ID+"/versions", nil)
+	var summaries []FormVersionSummary
+	json.Unmarshal(w.Body.Bytes(), &summaries)
+	if len(summaries) != 2 || summaries[0].Version != 2 || summaries[0].Submissions != 1 || summaries[1].Submissions != 1 {
+		t.Errorf("unexpected versions %+v", summaries)
+	}
+
+	w = do(user.ID, http.MethodGet, "/forms/"+form.ID+"/versions/1", nil)
+	var version models.FormVersion
+	json.Unmarshal(w.Body.Bytes(), &version)
+	if w.Code != http.StatusOK || version.Version != 1 || version.Fields[0].ID != "name" {
+		t.Errorf("unexpected version %d: %s", w.Code, w.Body.String())
+	}
+
+	w = do(user.ID, http.MethodGet, "/forms/"+form.ID+"/versions/diff?from=1", nil)
+	var diff versions.Diff
+	json.Unmarshal(w.Body.Bytes(), &diff)
+	if w.Code != http.StatusOK || diff.To != 2 || len(diff.Added) != 1 || len(diff.Removed) != 1 {
+		t.Errorf("unexpected diff %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(user.ID, http.MethodGet, "/forms/"+form.ID+"/versions/diff?from=1&to=5", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for an unknown version, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := do(user.ID, http.MethodGet, "/forms/"+form.ID+"/versions/diff", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d without from, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := do(other.ID, http.MethodGet, "/forms/"+form.ID+"/versions", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for other user, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+
+	"formera/internal/database"
+	"formera/internal/logger"
+	"formera/internal/models"
+	"formera/internal/pagination"
+	"formera/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	dispatcher *webhooks.Dispatcher
+}
+
+func NewWebhookHandler(dispatcher *webhooks.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{dispatcher: dispatcher}
+}
+
+type WebhookRequest struct {
+	URL    string                `json:"url" binding:"required"`
+	Secret string                `json:"secret"` // Generated when empty
+	Events []models.WebhookEvent `json:"events" binding:"required"`
+	Active *bool                 `json:"active"`
+}
+
+// List godoc
+// @Summary      List webhooks
+// @Description  Get all webhooks of a form
+// @Tags         Webhooks
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Success      200 {array} models.Webhook
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/webhooks [get]
+func (h *WebhookHandler) List(c *gin.Context) {
+	if _, ok := h.loadForm(c); !ok {
+		return
+	}
+
+	var hooks []models.Webhook
+	if result := database.DB.Where("form_id = ?", c.Param("id")).Order("created_at ASC").Find(&hooks); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hooks)
+}
+
+// Create godoc
+// @Summary      Create webhook
+// @Description  Subscribe a URL to submission events of a form: submission.created and submission.deleted.
+// @Description  Submissions cannot be edited after they were received, so no submission.updated event is offered.
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        request body WebhookRequest true "Webhook"
+// @Success      201 {object} models.Webhook
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	form, ok := h.loadForm(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateWebhookRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	hook := &models.Webhook{
+		FormID: form.ID,
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+		Active: req.Active == nil || *req.Active,
+	}
+	if hook.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret"})
+			return
+		}
+		hook.Secret = secret
+	}
+
+	if result := database.DB.Create(hook); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hook)
+}
+
+// Update godoc
+// @Summary      Update webhook
+// @Description  Update URL, secret, events or active state of a webhook
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        webhookId path string true "Webhook ID"
+// @Param        request body WebhookRequest true "Webhook"
+// @Success      200 {object} models.Webhook
+// @Failure      400 {object} ErrorResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/webhooks/{webhookId} [put]
+func (h *WebhookHandler) Update(c *gin.Context) {
+	hook, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if msg := validateWebhookRequest(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	hook.URL = req.URL
+	hook.Events = req.Events
+	if req.Secret != "" {
+		hook.Secret = req.Secret
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+
+	if result := database.DB.Save(hook); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// Delete godoc
+// @Summary      Delete webhook
+// @Description  Delete a webhook and its delivery log
+// @Tags         Webhooks
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        webhookId path string true "Webhook ID"
+// @Success      200 {object} MessageResponse
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/webhooks/{webhookId} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	hook, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	database.DB.Where("webhook_id = ?", hook.ID).Delete(&models.WebhookDelivery{})
+	if result := database.DB.Delete(hook); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// Deliveries godoc
+// @Summary      List webhook deliveries
+// @Description  Get the paginated delivery log of a webhook, newest first
+// @Tags         Webhooks
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        webhookId path string true "Webhook ID"
+// @Param        status query string false "Filter by status (pending, succeeded, failed)"
+// @Param        page query int false "Page number" default(1)
+// @Param        page_size query int false "Items per page" default(20)
+// @Success      200 {object} pagination.Result
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/webhooks/{webhookId}/deliveries [get]
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	hook, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+	params := pagination.GetParams(c)
+
+	query := database.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", hook.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var totalItems int64
+	query.Count(&totalItems)
+
+	var deliveries []models.WebhookDelivery
+	if result := query.Order("created_at DESC").
+		Scopes(pagination.Paginate(params)).
+		Find(&deliveries); result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.CreateResult(deliveries, params, totalItems))
+}
+
+// Redeliver godoc
+// @Summary      Redeliver webhook
+// @Description  Send the payload of an earlier delivery again as a new delivery
+// @Tags         Webhooks
+// @Produce      json
+// @Param        id path string true "Form ID"
+// @Param        webhookId path string true "Webhook ID"
+// @Param        deliveryId path string true "Delivery ID"
+// @Success      202 {object} models.WebhookDelivery
+// @Failure      401 {object} ErrorResponse
+// @Failure      404 {object} ErrorResponse
+// @Security     BearerAuth
+// @Router       /forms/{id}/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	hook, ok := h.loadWebhook(c)
+	if !ok {
+		return
+	}
+
+	var original models.WebhookDelivery
+	if result := database.DB.Where("id = ? AND webhook_id = ?", c.Param("deliveryId"), hook.ID).First(&original); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+
+	delivery, err := h.dispatcher.Redeliver(&original)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule redelivery"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// loadForm loads the form from the path if the current user may edit it
+func (h *WebhookHandler) loadForm(c *gin.Context) (*models.Form, bool) {
+	var form models.Form
+	if !authorizeForm(c, &form, models.FormRoleEditor) {
+		return nil, false
+	}
+	return &form, true
+}
+
+// loadWebhook loads the webhook from the path if the current user may edit its form
+func (h *WebhookHandler) loadWebhook(c *gin.Context) (*models.Webhook, bool) {
+	form, ok := h.loadForm(c)
+	if !ok {
+		return nil, false
+	}
+
+	var hook models.Webhook
+	if result := database.DB.Where("id = ? AND form_id = ?", c.Param("webhookId"), form.ID).First(&hook); result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return nil, false
+	}
+	return &hook, true
+}
+
+// dispatchDeleted sends deleted events for submissions of the given forms
+func dispatchDeleted(hooks *webhooks.Dispatcher, forms []models.Form, submissions []models.Submission) {
+	byForm := make(map[string][]*models.Submission, len(forms))
+	for i := range submissions {
+		byForm[submissions[i].FormID] = append(byForm[submissions[i].FormID], &submissions[i])
+	}
+	for i := range forms {
+		hooks.Dispatch(models.WebhookEventSubmissionDeleted, &forms[i], byForm[forms[i].ID]...)
+	}
+}
+
+// dispatchFormsDeleted sends deleted events for the submissions of deleted
+// forms and removes their webhooks, those with pending deliveries after the
+// last attempt. Must be called once the forms are deleted.
+func dispatchFormsDeleted(hooks *webhooks.Dispatcher, forms []models.Form, submissions []models.Submission) {
+	dispatchDeleted(hooks, forms, submissions)
+
+	formIDs := make([]string, len(forms))
+	for i, form := range forms {
+		formIDs[i] = form.ID
+	}
+	if err := webhooks.RemoveIdle(database.DB, formIDs); err != nil {
+		logger.Error().Err(err).Strs("form_ids", formIDs).Msg("Failed to delete webhooks of deleted forms")
+	}
+}
+
+func validateWebhookRequest(req *WebhookRequest) string {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "Webhook URL must be an absolute http or https URL"
+	}
+	if len(req.Events) == 0 {
+		return "At least one event is required"
+	}
+	for _, event := range req.Events {
+		if !event.IsValid() {
+			return "Invalid webhook event: " + string(event) + " (supported: submission.created, submission.deleted)"
+		}
+	}
+	return ""
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+	"formera/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWebhookHandler_Create(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "test@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{UserID: user.ID, Title: "Test Form"}
+	db.Create(form)
+
+	handler := NewWebhookHandler(webhooks.NewDispatcher(db, webhooks.Config{}))
+	router := gin.New()
+	router.POST("/forms/:id/webhooks", func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		handler.Create(c)
+	})
+
+	tests := []struct {
+		name   string
+		body   WebhookRequest
+		status int
+	}{
+		{"valid", WebhookRequest{URL: "https://example.com/hook", Events: []models.WebhookEvent{models.WebhookEventSubmissionCreated}}, http.StatusCreated},
+		{"invalid url", WebhookRequest{URL: "ftp://example.com", Events: []models.WebhookEvent{models.WebhookEventSubmissionCreated}}, http.StatusBadRequest},
+		{"invalid event", WebhookRequest{URL: "https://example.com/hook", Events: []models.WebhookEvent{"form.created"}}, http.StatusBadRequest},
+		{"updated event", WebhookRequest{URL: "https://example.com/hook", Events: []models.WebhookEvent{"submission.updated"}}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonBody, _ := json.Marshal(tt.body)
+			req := httptest.NewRequest(http.MethodPost, "/forms/"+form.ID+"/webhooks", bytes.NewBuffer(jsonBody))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if tt.status != http.StatusCreated {
+				return
+			}
+
+			var hook models.Webhook
+			json.Unmarshal(w.Body.Bytes(), &hook)
+			if !hook.Active || !strings.HasPrefix(hook.Secret, "whsec_") {
+				t.Errorf("expected active webhook with generated secret, got %+v", hook)
+			}
+		})
+	}
+}
+
+func TestWebhookHandler_List_WrongUser(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	owner := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleUser)
+	other := testutil.CreateTestUser(t, db, "other@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{UserID: owner.ID, Title: "Test Form"}
+	db.Create(form)
+
+	handler := NewWebhookHandler(webhooks.NewDispatcher(db, webhooks.Config{}))
+	router := gin.New()
+	router.GET("/forms/:id/webhooks", func(c *gin.Context) {
+		c.Set("user_id", other.ID)
+		handler.List(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/forms/"+form.ID+"/webhooks", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestSubmissionHandler_Submit_DispatchesWebhook(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Contact",
+		Status: models.FormStatusPublished,
+		Fields: models.FormFields{{ID: "field1", Label: "Message", Type: "text"}},
+	}
+	db.Create(form)
+	hook := &models.Webhook{
+		FormID: form.ID,
+		URL:    "https://example.com/hook",
+		Events: models.WebhookEvents{models.WebhookEventSubmissionCreated},
+		Active: true,
+	}
+	db.Create(hook)
+
+	// Without a queue the delivery stays pending and can be inspected
+	handler := NewSubmissionHandler(nil, webhooks.NewDispatcher(db, webhooks.Config{}), nil)
+	router := gin.New()
+	router.POST("/public/forms/:id/submit", handler.Submit)
+
+	jsonBody, _ := json.Marshal(SubmitRequest{Data: map[string]interface{}{"field1": "Hello"}})
+	req := httptest.NewRequest(http.MethodPost, "/public/forms/"+form.ID+"/submit", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var deliveries []models.WebhookDelivery
+	db.Where("webhook_id = ?", hook.ID).Find(&deliveries)
+	if len(deliveries) != 1 || deliveries[0].Status != models.WebhookDeliveryPending {
+		t.Fatalf("expected one pending delivery, got %+v", deliveries)
+	}
+	if !strings.Contains(deliveries[0].Payload, `"label":"Message"`) {
+		t.Errorf("expected labelled answers in payload, got %s", deliveries[0].Payload)
+	}
+}
+
+func TestFormHandler_Delete_DispatchesDeletedEvents(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{UserID: user.ID, Title: "Contact", Fields: models.FormFields{{ID: "field1", Label: "Message", Type: "text"}}}
+	db.Create(form)
+	for _, message := range []string{"Hello", "Bye"} {
+		db.Create(&models.Submission{FormID: form.ID, Data: models.SubmissionData{"field1": message}})
+	}
+	subscribed := &models.Webhook{FormID: form.ID, URL: "https://example.com/hook", Events: models.WebhookEvents{models.WebhookEventSubmissionDeleted}, Active: true}
+	createdOnly := &models.Webhook{FormID: form.ID, URL: "https://example.com/other", Events: models.WebhookEvents{models.WebhookEventSubmissionCreated}, Active: true}
+	db.Create(subscribed)
+	db.Create(createdOnly)
+
+	handler := NewFormHandler(webhooks.NewDispatcher(db, webhooks.Config{}))
+	router := gin.New()
+	router.DELETE("/forms/:id", func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		handler.Delete(c)
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/forms/"+form.ID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var deliveries []models.WebhookDelivery
+	db.Where("webhook_id = ?", subscribed.ID).Find(&deliveries)
+	if len(deliveries) != 2 || deliveries[0].Event != models.WebhookEventSubmissionDeleted {
+		t.Fatalf("expected a deleted event per submission, got %+v", deliveries)
+	}
+	if strings.Contains(deliveries[0].Payload, "Hello") || strings.Contains(deliveries[0].Payload, "Bye") {
+		t.Errorf("deleted events should not contain answers, got %s", deliveries[0].Payload)
+	}
+
+	// Kept until its deliveries are done; webhooks without deliveries are removed right away
+	var hooks []models.Webhook
+	db.Where("form_id = ?", form.ID).Find(&hooks)
+	if len(hooks) != 1 || hooks[0].ID != subscribed.ID {
+		t.Errorf("expected only the webhook with pending deliveries to remain, got %+v", hooks)
+	}
+}
@@ -0,0 +1,183 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"formera/internal/export"
+	"formera/internal/models"
+)
+
+// Default ranges of rating and scale fields, as in the form renderer
+const (
+	defaultRatingMax = 5
+	defaultScaleMax  = 10
+)
+
+// Convert checks a value of an import file against the type of a field and
+// returns it as stored by the form renderer: numbers for number, rating and
+// scale fields, lists of strings for checkbox and file fields and strings
+// otherwise. Multi-value answers given as text are split at sep.
+func Convert(field *models.FormField, v interface{}, sep string) (interface{}, error) {
+	switch field.Type {
+	case models.FieldTypeNumber:
+		return toNumber(v)
+
+	case models.FieldTypeRating, models.FieldTypeScale:
+		n, err := toNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		lo, hi := field.MinValue, field.MaxValue
+		if hi == 0 {
+			hi = defaultScaleMax
+			if field.Type == models.FieldTypeRating {
+				hi = defaultRatingMax
+			}
+		}
+		if n != math.Trunc(n) || n < float64(lo) || n > float64(hi) {
+			return nil, fmt.Errorf("expected a whole number from %d to %d", lo, hi)
+		}
+		return n, nil
+
+	case models.FieldTypeEmail:
+		s, err := toText(v)
+		if err != nil {
+			return nil, err
+		}
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			return nil, errors.New("invalid email address")
+		}
+		return s, nil
+
+	case models.FieldTypeURL:
+		s, err := toText(v)
+		if err != nil {
+			return nil, err
+		}
+		if u, err := url.Parse(s); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errors.New("invalid URL (expected http or https)")
+		}
+		return s, nil
+
+	case models.FieldTypeDate:
+		s, err := toText(v)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return nil, errors.New("invalid date (expected YYYY-MM-DD)")
+		}
+		return s, nil
+
+	case models.FieldTypeTime:
+		s, err := toText(v)
+		if err != nil {
+			return nil, err
+		}
+		t, err := time.Parse("15:04", s)
+		if err != nil {
+			if t, err = time.Parse("15:04:05", s); err != nil {
+				return nil, errors.New("invalid time (expected HH:MM)")
+			}
+		}
+		return t.Format("15:04"), nil
+
+	case models.FieldTypeSelect, models.FieldTypeRadio, models.FieldTypeDropdown:
+		s, err := toText(v)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkOption(field, s); err != nil {
+			return nil, err
+		}
+		return s, nil
+
+	case models.FieldTypeCheckbox:
+		items, err := toList(v, sep)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if err := checkOption(field, item.(string)); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+
+	case models.FieldTypeFile:
+		return toList(v, sep)
+	}
+
+	return toText(v)
+}
+
+// toText accepts strings and other scalar values as text
+func toText(v interface{}) (string, error) {
+	switch typed := v.(type) {
+	case string:
+		return strings.TrimSpace(typed), nil
+	case float64, bool:
+		return export.FormatValue(typed, ""), nil
+	}
+	return "", errors.New("expected text")
+}
+
+func toNumber(v interface{}) (float64, error) {
+	switch typed := v.(type) {
+	case float64:
+		return typed, nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		if err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+			return n, nil
+		}
+	}
+	return 0, errors.New("expected a number")
+}
+
+// toList accepts a list of strings or text split at sep, skipping empty items
+func toList(v interface{}, sep string) ([]interface{}, error) {
+	var parts []string
+	switch typed := v.(type) {
+	case string:
+		parts = strings.Split(typed, sep)
+	case []interface{}:
+		for _, item := range typed {
+			s, ok := item.(string)
+			if !ok {
+				return nil, errors.New("expected a list of text")
+			}
+			parts = append(parts, s)
+		}
+	default:
+		return nil, errors.New("expected a list of text")
+	}
+
+	items := make([]interface{}, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			items = append(items, p)
+		}
+	}
+	return items, nil
+}
+
+// checkOption checks that an answer is one of the field's options, if it has any
+func checkOption(field *models.FormField, s string) error {
+	if len(field.Options) == 0 {
+		return nil
+	}
+	for _, o := range field.Options {
+		if o == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not an option", s)
+}
@@ -0,0 +1,271 @@
+// Package importer loads submissions of a form from CSV and JSON files
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"formera/internal/export"
+	"formera/internal/models"
+	"formera/internal/sanitizer"
+
+	"gorm.io/gorm"
+)
+
+// MaxRows limits the number of rows of an import file
+const MaxRows = 50000
+
+// MaxErrors limits the row errors listed in a result
+const MaxErrors = 100
+
+// SubmittedAt is the mapping target of the submission time. Rows without a
+// time are stored with the time of the import.
+const SubmittedAt = "submitted_at"
+
+// Options configure an import
+type Options struct {
+	// Mapping maps columns or JSON keys to field IDs or SubmittedAt
+	Mapping map[string]string
+	// MultiValueSeparator splits checkbox and file answers given as text
+	MultiValueSeparator string
+	// DryRun validates the rows without storing them
+	DryRun bool
+}
+
+// RowError is a problem with a row of an import file
+type RowError struct {
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+// Result reports the outcome of an import
+type Result struct {
+	Rows     int               `json:"rows"`
+	Imported int               `json:"imported"`
+	Invalid  int               `json:"invalid"` // Rows with at least one error
+	Errors   []RowError        `json:"errors"`  // The first MaxErrors errors
+	Mapping  map[string]string `json:"mapping"`
+	Ignored  []string          `json:"ignored,omitempty"` // Columns that are not imported
+	DryRun   bool              `json:"dry_run"`
+}
+
+// timeLayouts are the accepted formats of submission times, without a zone in UTC
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ResolveMapping checks the mapping of an import file, or guesses it from the
+// column names if empty. Columns are matched to field IDs, field labels as used
+// in export headers (ignoring case) and the submission time column of exports.
+// Every required field must be mapped.
+func ResolveMapping(form *models.Form, columns []string, mapping map[string]string) (map[string]string, error) {
+	fields := make(map[string]*models.FormField)
+	for i := range form.Fields {
+		if !form.Fields[i].Type.IsLayout() {
+			fields[form.Fields[i].ID] = &form.Fields[i]
+		}
+	}
+
+	if len(mapping) == 0 {
+		mapping = guessMapping(form, columns)
+	} else {
+		known := make(map[string]bool, len(columns))
+		for _, col := range columns {
+			known[col] = true
+		}
+		targets := make(map[string]string, len(mapping))
+		for col, target := range mapping {
+			if !known[col] || col == "" {
+				return nil, fmt.Errorf("column %q not found in file", col)
+			}
+			if _, ok := fields[target]; !ok && target != SubmittedAt {
+				return nil, fmt.Errorf("column %q: unknown field %q", col, target)
+			}
+			if other, ok := targets[target]; ok {
+				return nil, fmt.Errorf("columns %q and %q are both mapped to %q", other, col, target)
+			}
+			targets[target] = col
+		}
+	}
+
+	mapped := make(map[string]bool, len(mapping))
+	for _, target := range mapping {
+		mapped[target] = true
+	}
+	for _, field := range form.Fields {
+		if field.Required && !field.Type.IsLayout() && !mapped[field.ID] {
+			return nil, fmt.Errorf("required field %q is not mapped", fieldName(&field))
+		}
+	}
+	return mapping, nil
+}
+
+// guessMapping maps columns named like a field ID, an export header or the
+// submission time. Other columns, such as the submission ID, are ignored.
+func guessMapping(form *models.Form, columns []string) map[string]string {
+	byName := make(map[string]string)
+	for _, headers := range []export.HeaderMode{export.HeaderLabels, export.HeaderIDs} {
+		for _, col := range export.Columns(form, export.Options{Headers: headers}) {
+			target := col.Key
+			switch col.Kind {
+			case export.ColumnID:
+				continue
+			case export.ColumnSubmittedAt:
+				target = SubmittedAt
+			}
+			byName[strings.ToLower(col.Header)] = target
+		}
+	}
+
+	mapping := make(map[string]string)
+	used := make(map[string]bool)
+	for _, col := range columns {
+		target, ok := byName[strings.ToLower(col)]
+		if !ok || col == "" || used[target] {
+			continue
+		}
+		used[target] = true
+		mapping[col] = target
+	}
+	return mapping
+}
+
+// Import validates the records of a file against the form and stores them as
+// submissions in one transaction. Nothing is stored if any row is invalid, so
+// a corrected file can be imported again without duplicates. Imported
+// submissions trigger no notifications or webhooks.
+func Import(db *gorm.DB, form *models.Form, file *File, opts Options) (*Result, error) {
+	sep := opts.MultiValueSeparator
+	if sep == "" {
+		sep = strings.TrimSpace(export.DefaultMultiValueSeparator)
+	}
+
+	result := &Result{Rows: len(file.Records), Mapping: opts.Mapping, DryRun: opts.DryRun, Errors: []RowError{}}
+	for _, col := range file.Columns {
+		if _, ok := opts.Mapping[col]; !ok && col != "" {
+			result.Ignored = append(result.Ignored, col)
+		}
+	}
+
+	now := time.Now()
+	submissions := make([]models.Submission, 0, len(file.Records))
+	for _, record := range file.Records {
+		sub, errs := convertRecord(form, record, opts.Mapping, sep, now)
+		if len(errs) > 0 {
+			result.Invalid++
+			for _, e := range errs {
+				if len(result.Errors) < MaxErrors {
+					result.Errors = append(result.Errors, e)
+				}
+			}
+			continue
+		}
+		submissions = append(submissions, *sub)
+	}
+	if opts.DryRun || result.Invalid > 0 || len(submissions) == 0 {
+		return result, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(submissions, export.BatchSize).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Imported = len(submissions)
+	return result, nil
+}
+
+// convertRecord converts a record to a submission, or returns its errors
+func convertRecord(form *models.Form, record Record, mapping map[string]string, sep string, now time.Time) (*models.Submission, []RowError) {
+	var errs []RowError
+	if record.Extra > 0 {
+		errs = append(errs, RowError{Row: record.Row, Error: fmt.Sprintf("%d more values than columns", record.Extra)})
+	}
+
+	sub := &models.Submission{FormID: form.ID, FormVersion: form.CurrentVersion, Data: models.SubmissionData{}, CreatedAt: now}
+	for col, target := range mapping {
+		v := record.Values[col]
+		if target == SubmittedAt {
+			if blank(v) {
+				continue
+			}
+			t, err := parseTime(v, now)
+			if err != nil {
+				errs = append(errs, RowError{Row: record.Row, Column: col, Error: err.Error()})
+				continue
+			}
+			sub.CreatedAt = t
+			continue
+		}
+
+		field := form.Fields.Find(target)
+		if blank(v) {
+			if field.Required {
+				errs = append(errs, RowError{Row: record.Row, Column: col, Error: fmt.Sprintf("%s is required", fieldName(field))})
+			}
+			continue
+		}
+		value, err := Convert(field, v, sep)
+		if err != nil {
+			errs = append(errs, RowError{Row: record.Row, Column: col, Error: err.Error()})
+			continue
+		}
+		sub.Data[field.ID] = value
+	}
+	if len(errs) > 0 {
+		// Mappings are unordered, so errors are listed by column
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Column < errs[j].Column })
+		return nil, errs
+	}
+
+	sub.Data = sanitizer.SanitizeSubmissionData(sub.Data)
+	return sub, nil
+}
+
+func parseTime(v interface{}, now time.Time) (time.Time, error) {
+	s, ok := v.(string)
+	if ok {
+		s = strings.TrimSpace(s)
+		for _, layout := range timeLayouts {
+			t, err := time.Parse(layout, s)
+			if err != nil {
+				continue
+			}
+			if t.After(now) {
+				return t, errors.New("submission time is in the future")
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("invalid submission time (expected RFC 3339, e.g. 2025-01-31T14:30:00Z)")
+}
+
+// blank reports whether a value is missing
+func blank(v interface{}) bool {
+	switch typed := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(typed) == ""
+	case []interface{}:
+		return len(typed) == 0
+	}
+	return false
+}
+
+func fieldName(field *models.FormField) string {
+	if field.Label != "" {
+		return field.Label
+	}
+	return field.ID
+}
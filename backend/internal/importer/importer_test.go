@@ -0,0 +1,196 @@
+package importer
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"formera/internal/export"
+	"formera/internal/models"
+	"formera/internal/testutil"
+)
+
+func testForm() *models.Form {
+	return &models.Form{
+		Title: "Registration",
+		Fields: models.FormFields{
+			{ID: "h1", Type: models.FieldTypeHeading, Label: "About you"},
+			{ID: "name", Type: models.FieldTypeText, Label: "Name", Required: true},
+			{ID: "email", Type: models.FieldTypeEmail, Label: "E-Mail"},
+			{ID: "guests", Type: models.FieldTypeNumber, Label: "Guests"},
+			{ID: "rating", Type: models.FieldTypeRating, Label: "Rating"},
+			{ID: "day", Type: models.FieldTypeDate, Label: "Day"},
+			{ID: "meals", Type: models.FieldTypeCheckbox, Label: "Meals", Options: []string{"Lunch", "Dinner"}},
+		},
+	}
+}
+
+func TestConvert(t *testing.T) {
+	form := testForm()
+	tests := []struct {
+		field string
+		value interface{}
+		want  interface{}
+		err   bool
+	}{
+		{"name", " Ada ", "Ada", false},
+		{"name", 42.0, "42", false},
+		{"name", []interface{}{"a"}, nil, true},
+		{"email", "ada@example.com", "ada@example.com", false},
+		{"email", "Ada <ada@example.com>", nil, true},
+		{"guests", "2.5", 2.5, false},
+		{"guests", 3.0, 3.0, false},
+		{"guests", "three", nil, true},
+		{"rating", "4", 4.0, false},
+		{"rating", "6", nil, true},
+		{"rating", "4.5", nil, true},
+		{"day", "2025-02-28", "2025-02-28", false},
+		{"day", "28.02.2025", nil, true},
+		{"meals", "Lunch; Dinner", []interface{}{"Lunch", "Dinner"}, false},
+		{"meals", []interface{}{"Dinner"}, []interface{}{"Dinner"}, false},
+		{"meals", "Breakfast", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := Convert(form.Fields.Find(tt.field), tt.value, ";")
+		if (err != nil) != tt.err {
+			t.Errorf("%s %v: unexpected error %v", tt.field, tt.value, err)
+			continue
+		}
+		if !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %v: expected %#v, got %#v", tt.field, tt.value, tt.want, got)
+		}
+	}
+}
+
+func TestResolveMapping(t *testing.T) {
+	form := testForm()
+
+	// Columns of a CSV export are mapped by label, the submission ID is ignored
+	got, err := ResolveMapping(form, []string{"ID", "Submitted At", "name", "e-mail", "Notes"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{"Submitted At": SubmittedAt, "name": "name", "e-mail": "email"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	invalid := []map[string]string{
+		{"Full name": "name", "Missing": "email"},
+		{"Full name": "name", "Mail": "h1"},
+		{"Full name": "name", "Mail": "name"},
+		{"Mail": "email"},
+	}
+	for _, mapping := range invalid {
+		if _, err := ResolveMapping(form, []string{"Full name", "Mail"}, mapping); err == nil {
+			t.Errorf("%v: expected an error", mapping)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	form := testForm()
+	db.Create(form)
+
+	input := "\uFEFFSubmitted At,Name,E-Mail,Meals\n" +
+		"2024-05-01T09:30:00Z,Ada,ada@example.com,Lunch; Dinner\n" +
+		",,,\n" +
+		"2024-05-02 10:00:00,Bob,,\n"
+	file, err := ReadCSV(strings.NewReader(input), ',')
+	if err != nil {
+		t.Fatalf("failed to read CSV: %v", err)
+	}
+	if len(file.Records) != 2 {
+		t.Fatalf("expected empty rows to be skipped, got %d records", len(file.Records))
+	}
+	mapping, err := ResolveMapping(form, file.Columns, nil)
+	if err != nil {
+		t.Fatalf("unexpected mapping error: %v", err)
+	}
+
+	result, err := Import(db, form, file, Options{Mapping: mapping, DryRun: true})
+	if err != nil || result.Rows != 2 || result.Imported != 0 || result.Invalid != 0 {
+		t.Fatalf("unexpected dry run %+v: %v", result, err)
+	}
+	var count int64
+	db.Model(&models.Submission{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("dry run stored %d submissions", count)
+	}
+
+	result, err = Import(db, form, file, Options{Mapping: mapping})
+	if err != nil || result.Imported != 2 {
+		t.Fatalf("unexpected import %+v: %v", result, err)
+	}
+	var subs []models.Submission
+	db.Order("created_at").Find(&subs)
+	if len(subs) != 2 || !subs[0].CreatedAt.Equal(time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)) {
+		t.Fatalf("expected submission times to be preserved, got %+v", subs)
+	}
+	if got := subs[0].Data["meals"]; !reflect.DeepEqual(got, []interface{}{"Lunch", "Dinner"}) {
+		t.Errorf("unexpected meals %#v", got)
+	}
+	if _, ok := subs[1].Data["email"]; ok {
+		t.Errorf("expected blank answers to be omitted, got %v", subs[1].Data)
+	}
+
+	// A JSON export of the form can be imported again
+	var buf bytes.Buffer
+	if err := export.WriteJSON(&buf, form, db.Model(&models.Submission{}).Where("form_id = ?", form.ID), export.Options{}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	file, err = ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("failed to read JSON: %v", err)
+	}
+	if mapping, err = ResolveMapping(form, file.Columns, nil); err != nil {
+		t.Fatalf("unexpected mapping error: %v", err)
+	}
+	if result, err = Import(db, form, file, Options{Mapping: mapping, DryRun: true}); err != nil || result.Invalid != 0 || result.Rows != 2 {
+		t.Errorf("unexpected dry run of exported JSON %+v: %v", result, err)
+	}
+}
+
+func TestImport_InvalidRows(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	form := testForm()
+	db.Create(form)
+
+	input := "Name,E-Mail,Guests,Submitted At\n" +
+		"Ada,ada@example.com,2,2024-05-01\n" +
+		",not-an-email,2,2024-05-01\n" +
+		"Bob,bob@example.com,many,yesterday\n" +
+		"Eve,eve@example.com,1,2024-05-01,extra\n"
+	file, err := ReadCSV(strings.NewReader(input), ',')
+	if err != nil {
+		t.Fatalf("failed to read CSV: %v", err)
+	}
+	mapping, _ := ResolveMapping(form, file.Columns, nil)
+
+	result, err := Import(db, form, file, Options{Mapping: mapping})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Invalid != 3 || result.Imported != 0 {
+		t.Errorf("expected three invalid rows and nothing imported, got %+v", result)
+	}
+	want := []RowError{
+		{Row: 3, Column: "E-Mail", Error: "invalid email address"},
+		{Row: 3, Column: "Name", Error: "Name is required"},
+		{Row: 4, Column: "Guests", Error: "expected a number"},
+		{Row: 4, Column: "Submitted At", Error: "invalid submission time (expected RFC 3339, e.g. 2025-01-31T14:30:00Z)"},
+		{Row: 5, Error: "1 more values than columns"},
+	}
+	if !reflect.DeepEqual(result.Errors, want) {
+		t.Errorf("expected errors %+v, got %+v", want, result.Errors)
+	}
+
+	var count int64
+	db.Model(&models.Submission{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected nothing to be stored, got %d submissions", count)
+	}
+}
@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Record is a row of an import file, keyed by column
+type Record struct {
+	Row    int // Row of the file, counting the CSV header as row 1
+	Values map[string]interface{}
+	Extra  int // Values of a CSV row beyond its columns
+}
+
+// File is a parsed import file
+type File struct {
+	Columns []string // Columns or JSON keys in order of appearance
+	Records []Record
+}
+
+// ReadCSV parses a CSV file with a header row. A UTF-8 BOM is skipped, empty
+// rows are ignored and rows with more values than columns are kept so the
+// import can report them.
+func ReadCSV(r io.Reader, delimiter rune) (*File, error) {
+	reader := csv.NewReader(r)
+	if delimiter != 0 {
+		reader.Comma = delimiter
+	}
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\uFEFF")
+	}
+
+	file := &File{Columns: make([]string, len(header))}
+	seen := make(map[string]bool, len(header))
+	for i, col := range header {
+		col = strings.TrimSpace(col)
+		if col != "" && seen[col] {
+			return nil, fmt.Errorf("duplicate column %q", col)
+		}
+		seen[col] = true
+		file.Columns[i] = col
+	}
+
+	for row := 2; ; row++ {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(file.Records) >= MaxRows {
+			return nil, fmt.Errorf("file has more than %d rows", MaxRows)
+		}
+
+		record := Record{Row: row, Values: make(map[string]interface{}, len(values))}
+		empty := true
+		for i, v := range values {
+			if v != "" {
+				empty = false
+			}
+			if i < len(file.Columns) {
+				record.Values[file.Columns[i]] = v
+			}
+		}
+		if empty {
+			continue
+		}
+		if len(values) > len(file.Columns) {
+			record.Extra = len(values) - len(file.Columns)
+		}
+		file.Records = append(file.Records, record)
+	}
+	return file, nil
+}
+
+// ReadJSON parses a JSON array of objects, such as a JSON export of a form
+func ReadJSON(r io.Reader) (*File, error) {
+	var items []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, errors.New("expected a JSON array of objects")
+	}
+	if len(items) > MaxRows {
+		return nil, fmt.Errorf("file has more than %d rows", MaxRows)
+	}
+
+	file := &File{Records: make([]Record, 0, len(items))}
+	seen := make(map[string]bool)
+	for i, item := range items {
+		var values map[string]interface{}
+		if bytes.Equal(bytes.TrimSpace(item), []byte("null")) || json.Unmarshal(item, &values) != nil {
+			return nil, fmt.Errorf("row %d: expected an object", i+1)
+		}
+		// Keys in order of appearance, as maps do not keep it
+		dec := json.NewDecoder(bytes.NewReader(item))
+		dec.Token()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				break
+			}
+			if k, ok := key.(string); ok && !seen[k] {
+				seen[k] = true
+				file.Columns = append(file.Columns, k)
+			}
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				break
+			}
+		}
+		file.Records = append(file.Records, Record{Row: i + 1, Values: values})
+	}
+	return file, nil
+}
@@ -0,0 +1,468 @@
+// Package jobs implements a small durable job queue stored in the database.
+//
+// Jobs are claimed by a pool of workers, retried with exponential backoff and
+// moved to the dead state once all attempts are used up. Jobs that were running
+// when the process stopped are picked up again on the next start.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"formera/internal/logger"
+	"formera/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrUnknownType is recorded for jobs without a registered handler
+var ErrUnknownType = errors.New("no handler registered for job type")
+
+// purgeJobType deletes old succeeded jobs and reschedules itself
+const purgeJobType = "jobs.purge"
+
+// purgeInterval between runs of the purge job
+const purgeInterval = 24 * time.Hour
+
+// ErrNotRetryable is returned when retrying a job that is running or already succeeded
+var ErrNotRetryable = errors.New("only pending or dead jobs can be retried")
+
+// Handler processes a job. Returning an error schedules a retry. The context is
+// cancelled when the queue is stopped; a job interrupted this way is run again
+// on the next start without counting the attempt.
+type Handler func(ctx context.Context, job *models.Job) error
+
+// Config contains configuration for the job queue
+type Config struct {
+	// Workers is the number of jobs processed concurrently
+	Workers int
+	// PollInterval at which due jobs are picked up
+	PollInterval time.Duration
+	// RetryDelay before the first retry, doubled on each further attempt
+	RetryDelay time.Duration
+	// MaxRetryDelay caps the backoff
+	MaxRetryDelay time.Duration
+	// MaxAttempts used when a job is enqueued without its own limit
+	MaxAttempts int
+	// Retention of succeeded jobs, whose payloads may contain personal data
+	Retention time.Duration
+}
+
+// DefaultConfig returns sensible defaults
+func DefaultConfig() Config {
+	return Config{
+		Workers:       4,
+		PollInterval:  5 * time.Second,
+		RetryDelay:    30 * time.Second,
+		MaxRetryDelay: time.Hour,
+		MaxAttempts:   5,
+		Retention:     7 * 24 * time.Hour,
+	}
+}
+
+// Queue stores jobs in the database and runs them with a worker pool
+type Queue struct {
+	db       *gorm.DB
+	config   Config
+	handlers map[string]Handler
+	wakeCh   chan struct{}
+	stopCh   chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	claimMu  sync.Mutex
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	running  bool
+}
+
+// New creates a new job queue
+func New(db *gorm.DB, config Config) *Queue {
+	defaults := DefaultConfig()
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaults.RetryDelay
+	}
+	if config.MaxRetryDelay <= 0 {
+		config.MaxRetryDelay = defaults.MaxRetryDelay
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaults.Retention
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		db:       db,
+		config:   config,
+		handlers: make(map[string]Handler),
+		wakeCh:   make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Register sets the handler for a job type. Handlers must be registered before Start.
+func (q *Queue) Register(jobType string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+// Option customizes an enqueued job
+type Option func(*models.Job)
+
+// RunAt schedules the job for a later time
+func RunAt(t time.Time) Option {
+	return func(j *models.Job) { j.RunAt = t }
+}
+
+// MaxAttempts overrides the default number of attempts
+func MaxAttempts(n int) Option {
+	return func(j *models.Job) {
+		if n > 0 {
+			j.MaxAttempts = n
+		}
+	}
+}
+
+// ForSubmission links the job to the submission whose data its payload contains
+func ForSubmission(id string) Option {
+	return func(j *models.Job) { j.SubmissionID = id }
+}
+
+// Enqueue stores a new job. The payload is JSON encoded.
+func (q *Queue) Enqueue(jobType string, payload interface{}, opts ...Option) (*models.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	job := &models.Job{
+		Type:        jobType,
+		Payload:     string(data),
+		Status:      models.JobStatusPending,
+		MaxAttempts: q.config.MaxAttempts,
+		RunAt:       time.Now(),
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	if err := q.db.Create(job).Error; err != nil {
+		return nil, err
+	}
+	q.wake()
+	return job, nil
+}
+
+// EnqueueBatch stores a job of the given type for each payload in a single
+// transaction, for callers queueing many jobs at once
+func (q *Queue) EnqueueBatch(jobType string, payloads []interface{}, opts ...Option) error {
+	batch := make([]*models.Job, len(payloads))
+	for i, payload := range payloads {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode job payload: %w", err)
+		}
+		job := &models.Job{
+			Type:        jobType,
+			Payload:     string(data),
+			Status:      models.JobStatusPending,
+			MaxAttempts: q.config.MaxAttempts,
+			RunAt:       time.Now(),
+		}
+		for _, opt := range opts {
+			opt(job)
+		}
+		batch[i] = job
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := q.db.CreateInBatches(batch, 100).Error; err != nil {
+		return err
+	}
+	q.wake()
+	return nil
+}
+
+// Retry makes a dead or pending job run again immediately with a fresh set of attempts
+func (q *Queue) Retry(id string) (*models.Job, error) {
+	var job models.Job
+	if err := q.db.First(&job, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobStatusDead && job.Status != models.JobStatusPending {
+		return nil, ErrNotRetryable
+	}
+
+	job.Status = models.JobStatusPending
+	job.Attempts = 0
+	job.RunAt = time.Now()
+	job.FinishedAt = nil
+	if err := q.db.Save(&job).Error; err != nil {
+		return nil, err
+	}
+	q.wake()
+	return &job, nil
+}
+
+// Start recovers interrupted jobs and starts the worker pool
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.running {
+		return
+	}
+	q.running = true
+
+	// Jobs still marked as running were interrupted by a crash or restart
+	if result := q.db.Model(&models.Job{}).
+		Where("status = ?", models.JobStatusRunning).
+		Updates(map[string]interface{}{"status": models.JobStatusPending, "run_at": time.Now()}); result.RowsAffected > 0 {
+		logger.Warn().Int64("count", result.RowsAffected).Msg("Requeued interrupted jobs")
+	}
+
+	q.handlers[purgeJobType] = q.purge
+	var scheduled int64
+	q.db.Model(&models.Job{}).
+		Where("type = ? AND status IN ?", purgeJobType, []models.JobStatus{models.JobStatusPending, models.JobStatusRunning}).
+		Count(&scheduled)
+	if scheduled == 0 {
+		if _, err := q.Enqueue(purgeJobType, struct{}{}); err != nil {
+			logger.Error().Err(err).Msg("Failed to schedule job purge")
+		}
+	}
+
+	logger.Info().Int("workers", q.config.Workers).Msg("Job queue started")
+
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+}
+
+// Stop cancels running jobs, waits for the workers to exit and stops the queue
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	if !q.running {
+		q.mu.Unlock()
+		return
+	}
+	q.running = false
+	q.mu.Unlock()
+
+	close(q.stopCh)
+	q.cancel()
+	q.wg.Wait()
+	logger.Info().Msg("Job queue stopped")
+}
+
+func (q *Queue) wake() {
+	select {
+	case q.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// work is the loop of a single worker
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain all due jobs before waiting again
+		for {
+			select {
+			case <-q.stopCh:
+				return
+			default:
+			}
+			job := q.claim()
+			if job == nil {
+				break
+			}
+			q.run(job)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-q.wakeCh:
+		case <-q.stopCh:
+			return
+		}
+	}
+}
+
+// RunDue runs all due jobs synchronously and returns how many were processed.
+// Useful for tests and one-off processing without starting the workers.
+func (q *Queue) RunDue() int {
+	n := 0
+	for {
+		job := q.claim()
+		if job == nil {
+			return n
+		}
+		q.run(job)
+		n++
+	}
+}
+
+// claim marks the next due job as running and returns it, or nil if none is due
+func (q *Queue) claim() *models.Job {
+	q.claimMu.Lock()
+	defer q.claimMu.Unlock()
+
+	var job models.Job
+	result := q.db.Where("status = ? AND run_at <= ?", models.JobStatusPending, time.Now()).
+		Order("run_at ASC").
+		Limit(1).
+		Find(&job)
+	if result.Error != nil {
+		logger.Error().Err(result.Error).Msg("Failed to query jobs")
+		return nil
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	job.Status = models.JobStatusRunning
+	job.StartedAt = &now
+	job.Attempts++
+	if err := q.db.Model(&job).Updates(map[string]interface{}{
+		"status":     job.Status,
+		"started_at": now,
+		"attempts":   job.Attempts,
+	}).Error; err != nil {
+		logger.Error().Err(err).Str("job_id", job.ID).Msg("Failed to claim job")
+		return nil
+	}
+	return &job
+}
+
+// run executes a claimed job and records the outcome
+func (q *Queue) run(job *models.Job) {
+	q.mu.Lock()
+	handler, ok := q.handlers[job.Type]
+	q.mu.Unlock()
+
+	var err error
+	if ok {
+		err = q.execute(handler, job)
+	} else {
+		err = ErrUnknownType
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{}
+	switch {
+	case err == nil:
+		updates["status"] = models.JobStatusSucceeded
+		updates["last_error"] = ""
+		updates["finished_at"] = now
+	case q.ctx.Err() != nil:
+		// Interrupted by shutdown: run again on next start without counting the attempt
+		updates["status"] = models.JobStatusPending
+		updates["attempts"] = job.Attempts - 1
+		updates["run_at"] = now
+	case errors.Is(err, ErrUnknownType) || job.Attempts >= job.MaxAttempts:
+		updates["status"] = models.JobStatusDead
+		updates["last_error"] = err.Error()
+		updates["finished_at"] = now
+		logger.Error().Err(err).
+			Str("job_id", job.ID).
+			Str("type", job.Type).
+			Int("attempts", job.Attempts).
+			Msg("Job moved to dead letter")
+	default:
+		updates["status"] = models.JobStatusPending
+		updates["last_error"] = err.Error()
+		updates["run_at"] = now.Add(q.Backoff(job.Attempts))
+		logger.Warn().Err(err).
+			Str("job_id", job.ID).
+			Str("type", job.Type).
+			Int("attempt", job.Attempts).
+			Msg("Job failed, retrying")
+	}
+
+	if err := q.db.Model(job).Updates(updates).Error; err != nil {
+		logger.Error().Err(err).Str("job_id", job.ID).Msg("Failed to update job")
+	}
+}
+
+// execute calls the handler and turns panics into errors
+func (q *Queue) execute(handler Handler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(q.ctx, job)
+}
+
+// Backoff returns the delay before retrying a job that failed the given attempt
+func (q *Queue) Backoff(attempt int) time.Duration {
+	delay := q.config.RetryDelay
+	for i := 1; i < attempt && delay < q.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > q.config.MaxRetryDelay {
+		delay = q.config.MaxRetryDelay
+	}
+	return delay
+}
+
+// Purge deletes succeeded jobs that finished more than the retention period
+// ago and returns how many were deleted
+func (q *Queue) Purge() (int64, error) {
+	result := q.db.Where("status = ? AND finished_at < ?", models.JobStatusSucceeded, time.Now().Add(-q.config.Retention)).
+		Delete(&models.Job{})
+	return result.RowsAffected, result.Error
+}
+
+// purge is the handler of the purge job, which reschedules itself
+func (q *Queue) purge(ctx context.Context, job *models.Job) error {
+	n, err := q.Purge()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		logger.Info().Int64("count", n).Msg("Deleted old succeeded jobs")
+	}
+	_, err = q.Enqueue(purgeJobType, struct{}{}, RunAt(time.Now().Add(purgeInterval)))
+	return err
+}
+
+// RedactSubmissions removes the data of the given submissions from the job
+// table: jobs that have not run yet are deleted, the payloads and errors of
+// the others are cleared.
+func RedactSubmissions(db *gorm.DB, submissionIDs []string) error {
+	if err := db.Where("submission_id IN ? AND status = ?", submissionIDs, models.JobStatusPending).
+		Delete(&models.Job{}).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.Job{}).
+		Where("submission_id IN ?", submissionIDs).
+		Updates(map[string]interface{}{"payload": "{}", "last_error": ""}).Error
+}
+
+// Decode unmarshals the job payload into v
+func Decode(job *models.Job, v interface{}) error {
+	return json.Unmarshal([]byte(job.Payload), v)
+}
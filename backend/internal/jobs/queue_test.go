@@ -0,0 +1,198 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+)
+
+func TestQueue_RunsJob(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	q := New(db, Config{})
+
+	var got string
+	q.Register("greet", func(ctx context.Context, job *models.Job) error {
+		var payload struct{ Name string }
+		if err := Decode(job, &payload); err != nil {
+			return err
+		}
+		got = payload.Name
+		return nil
+	})
+
+	job, err := q.Enqueue("greet", map[string]string{"Name": "Jane"})
+	if err != nil {
+		t.Fatalf("Enqueue returned error: %v", err)
+	}
+
+	if n := q.RunDue(); n != 1 {
+		t.Fatalf("expected 1 job to run, got %d", n)
+	}
+	if got != "Jane" {
+		t.Errorf("expected payload to be decoded, got %q", got)
+	}
+
+	db.First(job, "id = ?", job.ID)
+	if job.Status != models.JobStatusSucceeded || job.Attempts != 1 || job.FinishedAt == nil {
+		t.Errorf("unexpected job state: %+v", job)
+	}
+}
+
+func TestQueue_RunAt(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	q := New(db, Config{})
+	q.Register("later", func(ctx context.Context, job *models.Job) error { return nil })
+
+	q.Enqueue("later", nil, RunAt(time.Now().Add(time.Hour)))
+
+	if n := q.RunDue(); n != 0 {
+		t.Errorf("scheduled job should not run yet, ran %d", n)
+	}
+}
+
+func TestQueue_RetriesAndDeadLetter(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	q := New(db, Config{RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond})
+
+	var calls int32
+	q.Register("flaky", func(ctx context.Context, job *models.Job) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("boom")
+	})
+
+	job, _ := q.Enqueue("flaky", nil, MaxAttempts(3))
+
+	for i := 0; i < 3; i++ {
+		q.RunDue()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	db.First(job, "id = ?", job.ID)
+	if job.Status != models.JobStatusDead || job.Attempts != 3 || job.LastError != "boom" {
+		t.Fatalf("expected dead job after 3 attempts, got %+v", job)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+
+	retried, err := q.Retry(job.ID)
+	if err != nil {
+		t.Fatalf("Retry returned error: %v", err)
+	}
+	if retried.Status != models.JobStatusPending || retried.Attempts != 0 {
+		t.Errorf("expected pending job with reset attempts, got %+v", retried)
+	}
+}
+
+func TestQueue_UnknownTypeAndPanic(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	q := New(db, Config{})
+	q.Register("panics", func(ctx context.Context, job *models.Job) error { panic("oops") })
+
+	unknown, _ := q.Enqueue("missing", nil)
+	panicking, _ := q.Enqueue("panics", nil, MaxAttempts(1))
+
+	q.RunDue()
+
+	db.First(unknown, "id = ?", unknown.ID)
+	if unknown.Status != models.JobStatusDead {
+		t.Errorf("job without handler should be dead, got %s", unknown.Status)
+	}
+	db.First(panicking, "id = ?", panicking.ID)
+	if panicking.Status != models.JobStatusDead || panicking.LastError != "job panicked: oops" {
+		t.Errorf("panicking job should be dead with error, got %+v", panicking)
+	}
+}
+
+func TestQueue_WorkersAndShutdown(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	q := New(db, Config{Workers: 2, PollInterval: time.Hour})
+
+	started := make(chan struct{})
+	q.Register("slow", func(ctx context.Context, job *models.Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// Left over from a previous crash
+	stale := &models.Job{Type: "slow", Status: models.JobStatusRunning, Attempts: 1, MaxAttempts: 5, RunAt: time.Now()}
+	db.Create(stale)
+
+	q.Start()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("interrupted job was not picked up again")
+	}
+
+	q.Stop()
+
+	db.First(stale, "id = ?", stale.ID)
+	if stale.Status != models.JobStatusPending || stale.Attempts != 1 {
+		t.Errorf("job interrupted by shutdown should be pending without using an attempt, got %+v", stale)
+	}
+}
+
+func TestQueue_PurgeAndRedact(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	q := New(db, Config{Retention: time.Hour})
+
+	old := time.Now().Add(-2 * time.Hour)
+	recent := time.Now()
+	expired := &models.Job{Type: "mail", Payload: `{"to":"a"}`, Status: models.JobStatusSucceeded, FinishedAt: &old, SubmissionID: "sub-1"}
+	kept := &models.Job{Type: "mail", Payload: `{"to":"b"}`, Status: models.JobStatusSucceeded, FinishedAt: &recent, SubmissionID: "sub-2"}
+	dead := &models.Job{Type: "mail", Payload: `{"to":"c"}`, Status: models.JobStatusDead, FinishedAt: &old, LastError: "550 c", SubmissionID: "sub-2"}
+	pending := &models.Job{Type: "mail", Payload: `{"to":"d"}`, Status: models.JobStatusPending, RunAt: recent, SubmissionID: "sub-2"}
+	for _, job := range []*models.Job{expired, kept, dead, pending} {
+		db.Create(job)
+	}
+
+	if n, err := q.Purge(); err != nil || n != 1 {
+		t.Fatalf("expected 1 job to be purged, got %d (err %v)", n, err)
+	}
+	if db.First(&models.Job{}, "id = ?", expired.ID).Error == nil {
+		t.Error("expected the old succeeded job to be deleted")
+	}
+
+	if err := RedactSubmissions(db, []string{"sub-2"}); err != nil {
+		t.Fatalf("RedactSubmissions returned error: %v", err)
+	}
+	if db.First(&models.Job{}, "id = ?", pending.ID).Error == nil {
+		t.Error("expected the pending job to be deleted")
+	}
+	for _, job := range []*models.Job{kept, dead} {
+		db.First(job, "id = ?", job.ID)
+		if job.Payload != "{}" || job.LastError != "" || job.Status == models.JobStatusPending {
+			t.Errorf("expected the payload to be redacted, got %+v", job)
+		}
+	}
+}
+
+func TestQueue_StartSchedulesPurge(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	for i := 0; i < 2; i++ {
+		// A restart does not schedule it twice
+		q := New(db, Config{PollInterval: time.Hour})
+		q.Start()
+		q.Stop()
+	}
+
+	var jobs []models.Job
+	db.Where("type = ?", purgeJobType).Find(&jobs)
+	pending := 0
+	for _, job := range jobs {
+		if job.Status == models.JobStatusPending {
+			pending++
+		}
+	}
+	if pending != 1 {
+		t.Errorf("expected one scheduled purge, got %+v", jobs)
+	}
+}
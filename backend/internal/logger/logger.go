@@ -2,6 +2,7 @@ package logger
 
 import (
 	"io"
+	"net/url"
 	"os"
 	"time"
 
@@ -59,7 +60,7 @@ func GinLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		query := redactQuery(c.Request.URL.RawQuery)
 
 		c.Next()
 
@@ -85,6 +86,31 @@ func GinLogger() gin.HandlerFunc {
 	}
 }
 
+// secretParams are query parameters whose values are never logged
+var secretParams = []string{"access_token", "ticket", "token"}
+
+// redactQuery replaces the values of secret query parameters
+func redactQuery(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return "[unparseable]"
+	}
+	redacted := false
+	for _, name := range secretParams {
+		if _, ok := values[name]; ok {
+			values[name] = []string{"[redacted]"}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return raw
+	}
+	return values.Encode()
+}
+
 // GinRecovery returns a gin middleware for panic recovery with logging
 func GinRecovery() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,22 @@
+package logger
+
+import "testing"
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"", ""},
+		{"page=2&search=a%20b", "page=2&search=a%20b"},
+		{"ticket=eyJhbGci&x=1", "ticket=%5Bredacted%5D&x=1"},
+		{"access_token=eyJhbGci", "access_token=%5Bredacted%5D"},
+		{"access_token=%zz", "[unparseable]"},
+	}
+
+	for _, tt := range tests {
+		if got := redactQuery(tt.raw); got != tt.want {
+			t.Errorf("redactQuery(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
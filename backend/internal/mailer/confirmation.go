@@ -0,0 +1,94 @@
+package mailer
+
+import (
+	"html"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"formera/internal/models"
+)
+
+// Default confirmation templates, used when the form does not define its own
+const (
+	DefaultConfirmationSubject = "Your submission: {{form_title}}"
+	DefaultConfirmationBody    = "Thank you for your submission.\n\nHere is a copy of your answers:\n\n{{answers}}"
+)
+
+var placeholderRegex = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// NewConfirmation builds the receipt sent to a respondent.
+//
+// Subject and body support placeholders: {{field_id}} for the answer to a field,
+// {{form_title}}, {{submitted_at}} and {{answers}} for all labelled answers.
+// Unknown placeholders are replaced with an empty string.
+func NewConfirmation(form *models.Form, submission *models.Submission, to string) *Message {
+	subjectTemplate := form.Settings.ConfirmationSubject
+	if strings.TrimSpace(subjectTemplate) == "" {
+		subjectTemplate = DefaultConfirmationSubject
+	}
+	bodyTemplate := form.Settings.ConfirmationBody
+	if strings.TrimSpace(bodyTemplate) == "" {
+		bodyTemplate = DefaultConfirmationBody
+	}
+
+	values := placeholderValues(form, submission)
+
+	// Newlines in the subject would break the header
+	subject := strings.Join(strings.Fields(renderPlaceholders(subjectTemplate, values)), " ")
+	text := renderPlaceholders(bodyTemplate, values)
+
+	return &Message{
+		To:           []string{to},
+		ReplyTo:      firstEmail(form.Settings.NotificationEmail),
+		Subject:      subject,
+		Text:         text,
+		HTML:         textToHTML(text),
+		SubmissionID: submission.ID,
+	}
+}
+
+func placeholderValues(form *models.Form, submission *models.Submission) map[string]string {
+	answers := SubmissionAnswers(form.Fields, submission.Data)
+	lines := make([]string, len(answers))
+	for i, a := range answers {
+		lines[i] = a.Label + ": " + a.Value
+	}
+
+	values := map[string]string{
+		"form_title":   form.Title,
+		"submitted_at": submission.CreatedAt.Format(time.RFC1123),
+		"answers":      strings.Join(lines, "\n"),
+	}
+	for _, field := range form.Fields {
+		if field.Type.IsLayout() {
+			continue
+		}
+		values[field.ID] = FormatValue(submission.Data[field.ID])
+	}
+	return values
+}
+
+func renderPlaceholders(template string, values map[string]string) string {
+	return placeholderRegex.ReplaceAllStringFunc(template, func(match string) string {
+		key := placeholderRegex.FindStringSubmatch(match)[1]
+		return values[key]
+	})
+}
+
+func textToHTML(text string) string {
+	escaped := html.EscapeString(text)
+	return "<!DOCTYPE html>\n<html>\n<body style=\"font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; color: #1f2937;\">\n<p>" +
+		strings.ReplaceAll(escaped, "\n", "<br>\n") +
+		"</p>\n</body>\n</html>\n"
+}
+
+func firstEmail(list string) string {
+	for _, part := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ';' }) {
+		if addr, err := mail.ParseAddress(strings.TrimSpace(part)); err == nil {
+			return addr.Address
+		}
+	}
+	return ""
+}
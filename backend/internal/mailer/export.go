@@ -0,0 +1,82 @@
+package mailer
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"formera/internal/models"
+)
+
+type exportData struct {
+	FormTitle    string
+	ScheduleName string
+	Submissions  int
+	Since        string
+	Until        string
+	ExpiresAt    string
+	DownloadURL  string
+}
+
+var exportText = texttemplate.Must(texttemplate.New("export").Parse(
+	`Your scheduled export "{{.ScheduleName}}" of "{{.FormTitle}}" is ready.
+
+Submissions: {{.Submissions}}
+{{if .Until}}Period: {{if .Since}}{{.Since}}{{else}}first submission{{end}} to {{.Until}}
+{{end}}{{if .DownloadURL}}
+Download: {{.DownloadURL}}
+{{end}}{{if .ExpiresAt}}
+The file is available until {{.ExpiresAt}}.
+{{end}}`))
+
+var exportHTML = htmltemplate.Must(htmltemplate.New("export").Parse(
+	`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; color: #1f2937;">
+<h2 style="margin-bottom: 4px;">Export ready</h2>
+<p style="margin-top: 0; color: #6b7280;">{{.FormTitle}} &middot; {{.ScheduleName}}</p>
+<p>{{.Submissions}} submissions{{if .Until}} from {{if .Since}}{{.Since}}{{else}}the first submission{{end}} to {{.Until}}{{end}}.</p>
+{{if .DownloadURL}}<p><a href="{{.DownloadURL}}">Download the export</a></p>{{end}}
+{{if .ExpiresAt}}<p style="color: #6b7280;">The file is available until {{.ExpiresAt}}.</p>{{end}}
+</body>
+</html>
+`))
+
+// NewExportReady builds the email linking to the export page of a scheduled
+// run in the frontend, which downloads the file after signing in
+func NewExportReady(form *models.Form, schedule *models.ExportSchedule, run *models.Export, to []string, baseURL string) (*Message, error) {
+	data := exportData{
+		FormTitle:    form.Title,
+		ScheduleName: schedule.Name,
+		Submissions:  run.Submissions,
+	}
+	if baseURL != "" {
+		data.DownloadURL = strings.TrimRight(baseURL, "/") + "/forms/" + form.ID + "/exports/" + run.ID
+	}
+	if run.Since != nil {
+		data.Since = run.Since.Format(time.RFC1123)
+	}
+	if run.Until != nil {
+		data.Until = run.Until.Format(time.RFC1123)
+	}
+	if run.ExpiresAt != nil {
+		data.ExpiresAt = run.ExpiresAt.Format(time.RFC1123)
+	}
+
+	var text, html bytes.Buffer
+	if err := exportText.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := exportHTML.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		To:      to,
+		Subject: "Export ready: " + form.Title,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
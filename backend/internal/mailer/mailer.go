@@ -0,0 +1,302 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"sync"
+	"time"
+
+	"formera/internal/jobs"
+	"formera/internal/logger"
+	"formera/internal/models"
+)
+
+// Encryption modes for the SMTP connection
+const (
+	EncryptionNone     = "none"
+	EncryptionSTARTTLS = "starttls"
+	EncryptionTLS      = "tls"
+)
+
+// JobType is the job queue type used for asynchronous deliveries
+const JobType = "mail.send"
+
+// ErrNotConfigured is returned when sending without an SMTP server configured
+var ErrNotConfigured = errors.New("mailer not configured")
+
+// Config contains configuration for the SMTP mailer
+type Config struct {
+	Host       string
+	Port       int
+	Username   string // Optional: SMTP auth is skipped if empty
+	Password   string
+	Encryption string // "none", "starttls" or "tls"
+	From       string
+	FromName   string
+	BaseURL    string        // Frontend URL used for links in emails
+	MaxRetries int           // Retries for failed asynchronous deliveries
+	RetryDelay time.Duration // Initial retry delay, doubled on each attempt
+	Timeout    time.Duration // Connection and command timeout
+	// InsecureSkipVerify disables certificate checks (for local testing only)
+	InsecureSkipVerify bool
+}
+
+// Mailer sends emails via SMTP. Asynchronous deliveries go through the job queue
+// when one is attached (see UseQueue), otherwise through an in-memory worker;
+// both retry with exponential backoff.
+type Mailer struct {
+	config  Config
+	jobs    *jobs.Queue
+	queue   chan *delivery
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running bool
+}
+
+type delivery struct {
+	msg     *Message
+	attempt int
+}
+
+// New creates a new mailer
+func New(cfg Config) *Mailer {
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	if cfg.Encryption == "" {
+		cfg.Encryption = EncryptionSTARTTLS
+	}
+	if cfg.RetryDelay == 0 {
+		cfg.RetryDelay = 30 * time.Second
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &Mailer{
+		config: cfg,
+		queue:  make(chan *delivery, 100),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Enabled returns true if the mailer has an SMTP server and sender configured
+func (m *Mailer) Enabled() bool {
+	return m != nil && m.config.Host != "" && m.config.From != ""
+}
+
+// BaseURL returns the frontend URL used for links in emails
+func (m *Mailer) BaseURL() string {
+	return m.config.BaseURL
+}
+
+// Start begins processing asynchronous deliveries
+func (m *Mailer) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running {
+		return
+	}
+	m.running = true
+
+	if !m.Enabled() {
+		logger.Info().Msg("Mailer is disabled (SMTP_HOST/SMTP_FROM not set)")
+		return
+	}
+
+	logger.Info().
+		Str("host", m.config.Host).
+		Int("port", m.config.Port).
+		Str("encryption", m.config.Encryption).
+		Msg("Mailer started")
+
+	m.wg.Add(1)
+	go m.run()
+}
+
+// Stop stops the background worker. Queued deliveries that have not been sent are dropped.
+func (m *Mailer) Stop() {
+	m.mu.Lock()
+	if !m.running {
+		m.mu.Unlock()
+		return
+	}
+	m.running = false
+	m.mu.Unlock()
+
+	close(m.stopCh)
+	m.wg.Wait()
+	logger.Info().Msg("Mailer stopped")
+}
+
+// UseQueue makes asynchronous deliveries durable by sending them through the
+// job queue instead of the in-memory worker. Must be called before the queue is started.
+func (m *Mailer) UseQueue(q *jobs.Queue) {
+	m.jobs = q
+	q.Register(JobType, func(ctx context.Context, job *models.Job) error {
+		var msg Message
+		if err := jobs.Decode(job, &msg); err != nil {
+			return err
+		}
+		return m.Send(&msg)
+	})
+}
+
+// SendAsync queues a message for delivery without blocking the caller
+func (m *Mailer) SendAsync(msg *Message) {
+	if !m.Enabled() {
+		return
+	}
+	if m.jobs != nil {
+		if _, err := m.jobs.Enqueue(JobType, msg, jobs.MaxAttempts(m.config.MaxRetries+1), jobs.ForSubmission(msg.SubmissionID)); err != nil {
+			logger.Error().Err(err).Str("subject", msg.Subject).Msg("Failed to queue mail")
+		}
+		return
+	}
+	m.enqueue(&delivery{msg: msg})
+}
+
+func (m *Mailer) enqueue(d *delivery) {
+	select {
+	case m.queue <- d:
+	case <-m.stopCh:
+	default:
+		logger.Warn().Str("subject", d.msg.Subject).Msg("Mail queue full, dropping message")
+	}
+}
+
+// run is the main delivery loop
+func (m *Mailer) run() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case d := <-m.queue:
+			m.deliver(d)
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+func (m *Mailer) deliver(d *delivery) {
+	err := m.Send(d.msg)
+	if err == nil {
+		return
+	}
+
+	d.attempt++
+	if d.attempt > m.config.MaxRetries {
+		logger.Error().Err(err).
+			Strs("to", d.msg.To).
+			Str("subject", d.msg.Subject).
+			Int("attempts", d.attempt).
+			Msg("Giving up on mail delivery")
+		return
+	}
+
+	delay := m.config.RetryDelay * time.Duration(1<<(d.attempt-1))
+	logger.Warn().Err(err).
+		Strs("to", d.msg.To).
+		Int("attempt", d.attempt).
+		Dur("retry_in", delay).
+		Msg("Mail delivery failed, retrying")
+
+	time.AfterFunc(delay, func() { m.enqueue(d) })
+}
+
+// Send delivers a message synchronously
+func (m *Mailer) Send(msg *Message) error {
+	if !m.Enabled() {
+		return ErrNotConfigured
+	}
+	if len(msg.To) == 0 {
+		return errors.New("message has no recipients")
+	}
+
+	body, err := msg.build(m.config.From, m.config.FromName)
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+
+	client, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.config.Username != "" {
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(m.config.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	for _, rcpt := range msg.To {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s failed: %w", rcpt, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// dial connects to the SMTP server using the configured encryption
+func (m *Mailer) dial() (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	tlsConfig := &tls.Config{
+		ServerName:         m.config.Host,
+		InsecureSkipVerify: m.config.InsecureSkipVerify,
+	}
+	dialer := &net.Dialer{Timeout: m.config.Timeout}
+
+	var conn net.Conn
+	var err error
+	if m.config.Encryption == EncryptionTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(2 * m.config.Timeout))
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("smtp handshake failed: %w", err)
+	}
+
+	if m.config.Encryption == EncryptionSTARTTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("smtp server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("starttls failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
@@ -0,0 +1,225 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"formera/internal/jobs"
+	"formera/internal/models"
+	"formera/internal/testutil"
+)
+
+func newTestMailer(sink *testutil.SMTPSink) *Mailer {
+	return New(Config{
+		Host:       sink.Host(),
+		Port:       sink.Port(),
+		Encryption: EncryptionNone,
+		From:       "forms@example.com",
+		FromName:   "Formera",
+		MaxRetries: 3,
+		RetryDelay: 10 * time.Millisecond,
+		Timeout:    5 * time.Second,
+	})
+}
+
+func TestMailer_Send(t *testing.T) {
+	sink := testutil.StartSMTPSink(t)
+	m := newTestMailer(sink)
+
+	err := m.Send(&Message{
+		To:      []string{"owner@example.com"},
+		Subject: "Grüße",
+		Text:    "Hello",
+		HTML:    "<p>Hello</p>",
+	})
+	if err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	msgs := sink.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+	if msgs[0].From != "forms@example.com" || msgs[0].To[0] != "owner@example.com" {
+		t.Errorf("unexpected envelope: %+v", msgs[0])
+	}
+	if !strings.Contains(msgs[0].Data, "Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=") {
+		t.Errorf("subject should be encoded, got:\n%s", msgs[0].Data)
+	}
+	if !strings.Contains(msgs[0].Data, "multipart/alternative") {
+		t.Error("expected multipart/alternative message")
+	}
+}
+
+func TestMailer_Send_NotConfigured(t *testing.T) {
+	m := New(Config{})
+	if err := m.Send(&Message{To: []string{"a@example.com"}}); err != ErrNotConfigured {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+
+	var nilMailer *Mailer
+	if nilMailer.Enabled() {
+		t.Error("nil mailer should not be enabled")
+	}
+}
+
+func TestMailer_SendAsync_RetriesOnFailure(t *testing.T) {
+	sink := testutil.StartSMTPSink(t)
+	sink.FailNext(2)
+
+	m := newTestMailer(sink)
+	m.Start()
+	defer m.Stop()
+
+	m.SendAsync(&Message{To: []string{"owner@example.com"}, Subject: "Retry", Text: "Hello"})
+
+	msgs := sink.WaitForMessages(t, 1, 5*time.Second)
+	if !strings.Contains(msgs[0].Data, "Subject: Retry") {
+		t.Errorf("unexpected message:\n%s", msgs[0].Data)
+	}
+}
+
+func TestNewSubmissionNotification(t *testing.T) {
+	form := &models.Form{
+		ID:    "form-1",
+		Title: "Contact",
+		Fields: models.FormFields{
+			{ID: "h1", Type: models.FieldTypeHeading, Label: "About you"},
+			{ID: "name", Type: models.FieldTypeText, Label: "Name"},
+			{ID: "topics", Type: models.FieldTypeCheckbox, Label: "Topics"},
+		},
+	}
+	submission := &models.Submission{
+		Data: models.SubmissionData{
+			"name":   "<b>Jane</b>",
+			"topics": []interface{}{"Sales", "Support"},
+		},
+		CreatedAt: time.Now(),
+	}
+
+	msg, err := NewSubmissionNotification(form, submission, []string{"owner@example.com"}, "https://forms.example.com/")
+	if err != nil {
+		t.Fatalf("NewSubmissionNotification returned error: %v", err)
+	}
+
+	if msg.Subject != "New submission: Contact" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "Topics: Sales, Support") {
+		t.Errorf("text body should contain labelled answers, got:\n%s", msg.Text)
+	}
+	if strings.Contains(msg.Text, "About you") {
+		t.Error("layout fields should not be listed")
+	}
+	if strings.Contains(msg.HTML, "<b>Jane</b>") {
+		t.Error("answers must be HTML-escaped")
+	}
+	if !strings.Contains(msg.Text, "https://forms.example.com/forms/form-1/responses") {
+		t.Errorf("expected responses link, got:\n%s", msg.Text)
+	}
+}
+
+func TestNewExportReady(t *testing.T) {
+	form := &models.Form{ID: "form-1", Title: "Registrations"}
+	schedule := &models.ExportSchedule{Name: "<Weekly>"}
+	until := time.Date(2025, 3, 10, 7, 0, 0, 0, time.UTC)
+	run := &models.Export{ID: "export-1", Submissions: 12, Until: &until}
+
+	msg, err := NewExportReady(form, schedule, run, []string{"finance@example.com"}, "https://forms.example.com/")
+	if err != nil {
+		t.Fatalf("NewExportReady returned error: %v", err)
+	}
+	if msg.Subject != "Export ready: Registrations" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "Download: https://forms.example.com/forms/form-1/exports/export-1") || !strings.Contains(msg.Text, "Submissions: 12") {
+		t.Errorf("unexpected text body:\n%s", msg.Text)
+	}
+	if !strings.Contains(msg.Text, "first submission to Mon, 10 Mar 2025 07:00:00 UTC") {
+		t.Errorf("expected the covered period, got:\n%s", msg.Text)
+	}
+	if strings.Contains(msg.HTML, "<Weekly>") {
+		t.Error("schedule name must be HTML-escaped")
+	}
+}
+
+func TestNewConfirmation_Placeholders(t *testing.T) {
+	form := &models.Form{
+		Title: "Event Registration",
+		Fields: models.FormFields{
+			{ID: "name", Type: models.FieldTypeText, Label: "Name"},
+			{ID: "email", Type: models.FieldTypeEmail, Label: "Email"},
+		},
+		Settings: models.FormSettings{
+			NotificationEmail:   "events@example.com",
+			ConfirmationSubject: "See you soon, {{ name }}!\r\nBcc: evil@example.com",
+			ConfirmationBody:    "Hi {{name}},\n\n{{answers}}\n{{unknown}}",
+		},
+	}
+	submission := &models.Submission{
+		Data:      models.SubmissionData{"name": "Jane <3", "email": "jane@example.com"},
+		CreatedAt: time.Now(),
+	}
+
+	msg := NewConfirmation(form, submission, "jane@example.com")
+
+	if msg.Subject != "See you soon, Jane <3! Bcc: evil@example.com" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	if !strings.HasPrefix(msg.Text, "Hi Jane <3,\n\nName: Jane <3\nEmail: jane@example.com\n") {
+		t.Errorf("unexpected body:\n%s", msg.Text)
+	}
+	if !strings.Contains(msg.HTML, "Jane &lt;3") {
+		t.Error("HTML body must escape answers")
+	}
+	if msg.ReplyTo != "events@example.com" {
+		t.Errorf("expected reply-to notification address, got %q", msg.ReplyTo)
+	}
+}
+
+func TestNewConfirmation_DefaultTemplate(t *testing.T) {
+	form := &models.Form{
+		Title:  "Feedback",
+		Fields: models.FormFields{{ID: "rating", Type: models.FieldTypeRating, Label: "Rating"}},
+	}
+	submission := &models.Submission{Data: models.SubmissionData{"rating": float64(4)}}
+
+	msg := NewConfirmation(form, submission, "jane@example.com")
+
+	if msg.Subject != "Your submission: Feedback" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "Rating: 4") {
+		t.Errorf("expected answers in default body, got:\n%s", msg.Text)
+	}
+}
+
+func TestMailer_SendAsync_UsesQueue(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	sink := testutil.StartSMTPSink(t)
+
+	m := newTestMailer(sink)
+	queue := jobs.New(db, jobs.Config{})
+	m.UseQueue(queue)
+
+	m.SendAsync(&Message{To: []string{"owner@example.com"}, Subject: "Queued", Text: "Hello", SubmissionID: "sub-1"})
+
+	var job models.Job
+	if err := db.First(&job, "type = ?", JobType).Error; err != nil {
+		t.Fatalf("expected a persisted mail job: %v", err)
+	}
+	if job.SubmissionID != "sub-1" || strings.Contains(job.Payload, "sub-1") {
+		t.Errorf("expected the job to be linked to the submission, got %+v", job)
+	}
+	if job.MaxAttempts != 4 {
+		t.Errorf("expected max attempts from retries + 1, got %d", job.MaxAttempts)
+	}
+
+	queue.RunDue()
+
+	msgs := sink.WaitForMessages(t, 1, 5*time.Second)
+	if !strings.Contains(msgs[0].Data, "Subject: Queued") {
+		t.Errorf("unexpected message:\n%s", msgs[0].Data)
+	}
+}
@@ -0,0 +1,130 @@
+package mailer
+
+import (
+	"bytes"
+	"encoding/json"
+	htmltemplate "html/template"
+	"path"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"formera/internal/models"
+)
+
+// Answer is a labelled submission value prepared for display in emails
+type Answer struct {
+	Label string
+	Value string
+}
+
+type notificationData struct {
+	FormTitle    string
+	SubmittedAt  string
+	Answers      []Answer
+	ResponsesURL string
+}
+
+var notificationText = texttemplate.Must(texttemplate.New("notification").Parse(
+	`New submission for "{{.FormTitle}}"
+
+{{range .Answers}}{{.Label}}: {{.Value}}
+{{end}}
+Submitted at: {{.SubmittedAt}}
+{{if .ResponsesURL}}
+View all responses: {{.ResponsesURL}}
+{{end}}`))
+
+var notificationHTML = htmltemplate.Must(htmltemplate.New("notification").Parse(
+	`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; color: #1f2937;">
+<h2 style="margin-bottom: 4px;">New submission</h2>
+<p style="margin-top: 0; color: #6b7280;">{{.FormTitle}} &middot; {{.SubmittedAt}}</p>
+<table cellpadding="8" cellspacing="0" style="border-collapse: collapse; width: 100%; max-width: 640px;">
+{{range .Answers}}<tr style="border-bottom: 1px solid #e5e7eb;">
+<td style="font-weight: 600; vertical-align: top; width: 35%;">{{.Label}}</td>
+<td style="white-space: pre-wrap;">{{.Value}}</td>
+</tr>
+{{end}}</table>
+{{if .ResponsesURL}}<p><a href="{{.ResponsesURL}}">View all responses</a></p>{{end}}
+</body>
+</html>
+`))
+
+// NewSubmissionNotification builds the owner notification for a new submission
+func NewSubmissionNotification(form *models.Form, submission *models.Submission, to []string, baseURL string) (*Message, error) {
+	data := notificationData{
+		FormTitle:   form.Title,
+		SubmittedAt: submission.CreatedAt.Format(time.RFC1123),
+		Answers:     SubmissionAnswers(form.Fields, submission.Data),
+	}
+	if baseURL != "" {
+		data.ResponsesURL = strings.TrimRight(baseURL, "/") + "/forms/" + form.ID + "/responses"
+	}
+
+	var text, html bytes.Buffer
+	if err := notificationText.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := notificationHTML.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		To:           to,
+		Subject:      "New submission: " + form.Title,
+		Text:         text.String(),
+		HTML:         html.String(),
+		SubmissionID: submission.ID,
+	}, nil
+}
+
+// SubmissionAnswers formats submission values in field order, skipping layout fields
+func SubmissionAnswers(fields models.FormFields, data models.SubmissionData) []Answer {
+	answers := make([]Answer, 0, len(fields))
+	for _, field := range fields {
+		if field.Type.IsLayout() {
+			continue
+		}
+		label := field.Label
+		if label == "" {
+			label = field.ID
+		}
+		answers = append(answers, Answer{Label: label, Value: FormatValue(data[field.ID])})
+	}
+	return answers
+}
+
+// FormatValue renders a submission value as human-readable text
+func FormatValue(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		if strings.HasPrefix(v, "data:image/") {
+			return "[Signature]"
+		}
+		if strings.HasPrefix(v, "files/") || strings.HasPrefix(v, "images/") {
+			return path.Base(v)
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "Yes"
+		}
+		return "No"
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, FormatValue(item))
+		}
+		return strings.Join(parts, ", ")
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"formera/internal/models"
 
@@ -40,7 +41,8 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return []byte(jwtSecret), nil
 		})
 
-		if err != nil || !token.Valid {
+		// Stream tickets are signed with the same secret but are no session tokens
+		if err != nil || !token.Valid || len(claims.Audience) > 0 {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
@@ -53,6 +55,70 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 	}
 }
 
+// streamAudience marks stream tickets, which are only valid for the stream of one form
+const streamAudience = "stream"
+
+// StreamTicketTTL is how long a stream ticket can be used to open a stream
+const StreamTicketTTL = 2 * time.Minute
+
+// StreamClaims are the claims of a stream ticket
+type StreamClaims struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+	FormID string `json:"form_id"`
+	jwt.RegisteredClaims
+}
+
+// NewStreamTicket issues a short-lived ticket for the live stream of a form.
+// EventSource cannot send headers, so the ticket is passed in the URL instead
+// of the session token.
+func NewStreamTicket(jwtSecret, userID, role, formID string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(StreamTicketTTL)
+	claims := &StreamClaims{
+		UserID: userID,
+		Role:   role,
+		FormID: formID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{streamAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(jwtSecret))
+	return signed, expiresAt, err
+}
+
+// StreamAuthMiddleware works like AuthMiddleware but also accepts a stream
+// ticket of the requested form in the ticket query parameter, because browsers
+// cannot set headers on EventSource
+func StreamAuthMiddleware(jwtSecret string) gin.HandlerFunc {
+	auth := AuthMiddleware(jwtSecret)
+	return func(c *gin.Context) {
+		ticket := c.Query("ticket")
+		if c.GetHeader("Authorization") != "" || ticket == "" {
+			auth(c)
+			return
+		}
+
+		claims := &StreamClaims{}
+		token, err := jwt.ParseWithClaims(ticket, claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte(jwtSecret), nil
+		}, jwt.WithAudience(streamAudience))
+
+		if err != nil || !token.Valid || claims.FormID != c.Param("id") {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired ticket"})
+			c.Abort()
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("user_role", claims.Role)
+		c.Next()
+	}
+}
+
 // AdminMiddleware checks if the authenticated user has admin role
 // Uses the role from JWT claims instead of querying the database
 func AdminMiddleware() gin.HandlerFunc {
@@ -150,6 +150,76 @@ func TestAuthMiddleware_InvalidSecret(t *testing.T) {
 	}
 }
 
+func TestStreamAuthMiddleware_Ticket(t *testing.T) {
+	secret := "test-secret"
+	session := generateTestToken(secret, "user-123", "test@example.com", "user", false)
+	ticket, _, err := NewStreamTicket(secret, "user-123", "user", "form-1")
+	if err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	otherForm, _, _ := NewStreamTicket(secret, "user-123", "user", "form-2")
+
+	router := gin.New()
+	router.GET("/forms/:id/stream", StreamAuthMiddleware(secret), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id")})
+	})
+
+	tests := []struct {
+		name   string
+		query  string
+		header string
+		status int
+	}{
+		{"valid ticket", "?ticket=" + ticket, "", http.StatusOK},
+		{"ticket of another form", "?ticket=" + otherForm, "", http.StatusUnauthorized},
+		{"session token as ticket", "?ticket=" + session, "", http.StatusUnauthorized},
+		{"session token as query parameter", "?access_token=" + session, "", http.StatusUnauthorized},
+		{"session token in header", "", "Bearer " + session, http.StatusOK},
+		{"ticket in header", "", "Bearer " + ticket, http.StatusUnauthorized},
+		{"no token", "", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/forms/form-1/stream"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
+
+func TestNewStreamTicket(t *testing.T) {
+	ticket, expiresAt, _ := NewStreamTicket("test-secret", "user-123", "user", "form-1")
+	if time.Until(expiresAt) > StreamTicketTTL {
+		t.Errorf("expected ticket to expire within %s, got %s", StreamTicketTTL, expiresAt)
+	}
+
+	// A ticket is no session token
+	router := gin.New()
+	router.Use(AuthMiddleware("test-secret"))
+	router.GET("/protected", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+ticket)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
 func TestAdminMiddleware_AdminUser(t *testing.T) {
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
@@ -0,0 +1,30 @@
+package models
+
+// FormAnalytics holds the daily view and start counts of a form.
+// Dates are UTC calendar days (YYYY-MM-DD); submissions are counted from the
+// submissions table.
+type FormAnalytics struct {
+	FormID string `json:"form_id" gorm:"primaryKey"`
+	Date   string `json:"date" gorm:"primaryKey"`
+	Views  int    `json:"views" gorm:"not null;default:0"`
+	Starts int    `json:"starts" gorm:"not null;default:0"`
+}
+
+// Funnel counters in FormAnalytics
+const (
+	AnalyticsViews  = "views"
+	AnalyticsStarts = "starts"
+)
+
+// AttributionView holds the daily views of a form per traffic source, so that
+// attribution reports can compute conversion. Empty values mean the parameter
+// was absent.
+type AttributionView struct {
+	FormID         string `json:"form_id" gorm:"primaryKey"`
+	Date           string `json:"date" gorm:"primaryKey"`
+	Source         string `json:"source" gorm:"primaryKey"`
+	Medium         string `json:"medium" gorm:"primaryKey"`
+	Campaign       string `json:"campaign" gorm:"primaryKey"`
+	ReferrerDomain string `json:"referrer_domain" gorm:"primaryKey"`
+	Views          int    `json:"views" gorm:"not null;default:0"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Audit actions
+const (
+	AuditActionDataSubjectErase = "data_subject.erase"
+)
+
+type AuditDetails map[string]interface{}
+
+func (d AuditDetails) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *AuditDetails) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+	return json.Unmarshal(bytes, d)
+}
+
+// AuditLog records administrative operations that must remain traceable
+type AuditLog struct {
+	ID        string       `json:"id" gorm:"primaryKey"`
+	UserID    string       `json:"user_id" gorm:"index"` // Acting user
+	Action    string       `json:"action" gorm:"index;not null"`
+	Details   AuditDetails `json:"details" gorm:"type:json"`
+	CreatedAt time.Time    `json:"created_at" gorm:"index"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	a.ID = uuid.New().String()
+	return nil
+}
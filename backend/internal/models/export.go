@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ExportStatus string
+
+const (
+	ExportStatusPending   ExportStatus = "pending"
+	ExportStatusRunning   ExportStatus = "running"
+	ExportStatusSucceeded ExportStatus = "succeeded"
+	ExportStatusFailed    ExportStatus = "failed"
+	ExportStatusExpired   ExportStatus = "expired" // File deleted after the retention period
+)
+
+// Export is an export generated in the background. The finished file is kept
+// in storage until ExpiresAt and downloaded through the API.
+type Export struct {
+	ID          string       `json:"id" gorm:"primaryKey"`
+	FormID      string       `json:"form_id" gorm:"index;not null"`
+	UserID      string       `json:"user_id" gorm:"index;not null"`
+	ScheduleID  string       `json:"schedule_id,omitempty" gorm:"index"` // Set for runs of an export schedule
+	Format      string       `json:"format"`
+	Options     string       `json:"-"` // JSON encoded export options
+	Status      ExportStatus `json:"status" gorm:"index;not null"`
+	Submissions int          `json:"submissions"`
+	Size        int64        `json:"size"`
+	Filename    string       `json:"filename,omitempty"`
+	FileID      string       `json:"-"`
+	Path        string       `json:"-"`
+	Error       string       `json:"error,omitempty"`
+	// Since and Until bound the submissions of incremental scheduled runs
+	Since      *time.Time `json:"since,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	// DownloadURL is set in API responses once the file is ready
+	DownloadURL string `json:"download_url,omitempty" gorm:"-"`
+}
+
+func (e *Export) BeforeCreate(tx *gorm.DB) error {
+	e.ID = uuid.New().String()
+	return nil
+}
+
+// ExportParams holds export query parameters by name, e.g. "columns",
+// "last" or "filter[<field ID>]"
+type ExportParams map[string]string
+
+func (p ExportParams) Value() (driver.Value, error) {
+	if p == nil {
+		return "{}", nil
+	}
+	return json.Marshal(p)
+}
+
+func (p *ExportParams) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		str, ok := value.(string)
+		if !ok {
+			return errors.New("type assertion to []byte failed")
+		}
+		bytes = []byte(str)
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+// ExportPreset is a saved export configuration of a form. Its parameters are
+// applied to an export request with ?preset=<id>; explicit parameters win.
+type ExportPreset struct {
+	ID        string       `json:"id" gorm:"primaryKey"`
+	FormID    string       `json:"form_id" gorm:"index;not null"`
+	UserID    string       `json:"user_id" gorm:"not null"`
+	Name      string       `json:"name" gorm:"not null"`
+	Format    string       `json:"format" gorm:"not null"` // csv, json, xlsx, zip or pdf
+	Params    ExportParams `json:"params" gorm:"type:text"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+func (p *ExportPreset) BeforeCreate(tx *gorm.DB) error {
+	p.ID = uuid.New().String()
+	return nil
+}
+
+// ExportSchedule exports the submissions of a form periodically. Each run is
+// recorded as an Export whose file is kept in storage until it expires.
+type ExportSchedule struct {
+	ID     string `json:"id" gorm:"primaryKey"`
+	FormID string `json:"form_id" gorm:"index;not null"`
+	UserID string `json:"user_id" gorm:"not null"`
+	Name   string `json:"name" gorm:"not null"`
+	// Spec is a cron expression (minute hour day-of-month month day-of-week)
+	// evaluated in Timezone, e.g. "0 7 * * 1" for Mondays at 7:00
+	Spec     string       `json:"spec" gorm:"not null"`
+	Timezone string       `json:"timezone" gorm:"not null"`
+	Format   string       `json:"format" gorm:"not null"` // csv, json, xlsx or zip
+	Params   ExportParams `json:"params" gorm:"type:text"`
+	Options  string       `json:"-"` // JSON encoded export options parsed from Params
+	// Incremental runs only export submissions received since the last successful run
+	Incremental bool `json:"incremental"`
+	// Email is a comma separated list of addresses that receive a download link after each run
+	Email     string     `json:"email,omitempty"`
+	Active    bool       `json:"active"`
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	// LastRunAt is the end of the period covered by the last successful run
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	// Revision is increased whenever the schedule is changed, so runs queued
+	// for an earlier revision are skipped
+	Revision  int       `json:"-" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *ExportSchedule) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New().String()
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -50,6 +51,24 @@ const (
 	FieldTypeImage     FieldType = "image"
 )
 
+// IsChoice reports whether the field type answers with one or more predefined options
+func (t FieldType) IsChoice() bool {
+	switch t {
+	case FieldTypeSelect, FieldTypeRadio, FieldTypeCheckbox, FieldTypeDropdown:
+		return true
+	}
+	return false
+}
+
+// IsLayout reports whether the field type is a layout element that collects no answer
+func (t FieldType) IsLayout() bool {
+	switch t {
+	case FieldTypeSection, FieldTypePagebreak, FieldTypeDivider, FieldTypeHeading, FieldTypeParagraph, FieldTypeImage:
+		return true
+	}
+	return false
+}
+
 type FormField struct {
 	ID          string                 `json:"id"`
 	Type        FieldType              `json:"type"`
@@ -98,6 +117,16 @@ func (f *FormFields) Scan(value interface{}) error {
 	return json.Unmarshal(bytes, f)
 }
 
+// Find returns the field with the given ID, or nil
+func (f FormFields) Find(id string) *FormField {
+	for i := range f {
+		if f[i].ID == id {
+			return &f[i]
+		}
+	}
+	return nil
+}
+
 type FormDesign struct {
 	PrimaryColor        string `json:"primaryColor,omitempty"`
 	BackgroundColor     string `json:"backgroundColor,omitempty"`
@@ -113,17 +142,52 @@ type FormDesign struct {
 	FontFamily          string `json:"fontFamily,omitempty"`
 }
 
+// IPStorageMode controls how the client IP of a submission is persisted
+type IPStorageMode string
+
+const (
+	IPStorageFull      IPStorageMode = "full"
+	IPStorageNone      IPStorageMode = "none"
+	IPStorageTruncated IPStorageMode = "truncated" // IPv4 /24, IPv6 /48
+	IPStorageHashed    IPStorageMode = "hashed"    // Salted SHA-256
+)
+
+// IsValid reports whether the mode is one of the known storage modes
+func (m IPStorageMode) IsValid() bool {
+	switch m {
+	case IPStorageFull, IPStorageNone, IPStorageTruncated, IPStorageHashed:
+		return true
+	}
+	return false
+}
+
+// FormPrivacy overrides the instance-wide metadata collection defaults for a form.
+// Unset values inherit the instance settings.
+type FormPrivacy struct {
+	// Anonymous forms never store identifying metadata, regardless of other settings
+	Anonymous        bool          `json:"anonymous"`
+	IPStorage        IPStorageMode `json:"ip_storage,omitempty"`
+	CollectUserAgent *bool         `json:"collect_user_agent,omitempty"`
+	CollectReferrer  *bool         `json:"collect_referrer,omitempty"`
+}
+
 type FormSettings struct {
-	SubmitButtonText    string      `json:"submit_button_text"`
-	SuccessMessage      string      `json:"success_message"`
-	AllowMultiple       bool        `json:"allow_multiple"`
-	RequireLogin        bool        `json:"require_login"`
-	NotifyOnSubmission  bool        `json:"notify_on_submission"`
-	NotificationEmail   string      `json:"notification_email,omitempty"`
-	MaxSubmissions      int         `json:"max_submissions,omitempty"`
-	StartDate           string      `json:"start_date,omitempty"`
-	EndDate             string      `json:"end_date,omitempty"`
-	Design              *FormDesign `json:"design,omitempty"`
+	SubmitButtonText   string `json:"submit_button_text"`
+	SuccessMessage     string `json:"success_message"`
+	AllowMultiple      bool   `json:"allow_multiple"`
+	RequireLogin       bool   `json:"require_login"`
+	NotifyOnSubmission bool   `json:"notify_on_submission"`
+	NotificationEmail  string `json:"notification_email,omitempty"`
+	// Confirmation receipt sent to the address entered in an email field
+	SendConfirmation       bool         `json:"send_confirmation"`
+	ConfirmationEmailField string       `json:"confirmation_email_field,omitempty"`
+	ConfirmationSubject    string       `json:"confirmation_subject,omitempty"` // Supports {{field_id}} placeholders
+	ConfirmationBody       string       `json:"confirmation_body,omitempty"`    // Supports {{field_id}} placeholders
+	MaxSubmissions         int          `json:"max_submissions,omitempty"`
+	StartDate              string       `json:"start_date,omitempty"`
+	EndDate                string       `json:"end_date,omitempty"`
+	Design                 *FormDesign  `json:"design,omitempty"`
+	Privacy                *FormPrivacy `json:"privacy,omitempty"`
 }
 
 func (s FormSettings) Value() (driver.Value, error) {
@@ -150,9 +214,24 @@ type Form struct {
 	// Password protection
 	PasswordProtected bool   `json:"password_protected" gorm:"default:false"`
 	PasswordHash      string `json:"-" gorm:"size:255"` // Never expose hash in JSON
-	CreatedAt         time.Time    `json:"created_at"`
-	UpdatedAt         time.Time    `json:"updated_at"`
-	Submissions       []Submission `json:"submissions,omitempty" gorm:"foreignKey:FormID"`
+	// Instance-wide template, marked by an admin
+	IsTemplate          bool   `json:"is_template" gorm:"index"`
+	TemplateCategory    string `json:"template_category,omitempty"`
+	TemplateDescription string `json:"template_description,omitempty"`
+	// CurrentVersion is the latest FormVersion, 0 until the form is first published
+	CurrentVersion int `json:"current_version" gorm:"default:0"`
+	// Revision is incremented on each update, for optimistic concurrency control
+	Revision    int          `json:"revision" gorm:"not null;default:1"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Submissions []Submission `json:"submissions,omitempty" gorm:"foreignKey:FormID"`
+	// Role of the current user, set in API responses
+	Role FormRole `json:"role,omitempty" gorm:"-"`
+}
+
+// ETag returns the entity tag of the form's current revision
+func (f *Form) ETag() string {
+	return `"` + strconv.Itoa(f.Revision) + `"`
 }
 
 func (f *Form) BeforeCreate(tx *gorm.DB) error {
@@ -165,6 +244,9 @@ func (f *Form) BeforeCreate(tx *gorm.DB) error {
 	if f.Status == "" {
 		f.Status = FormStatusDraft
 	}
+	if f.Revision == 0 {
+		f.Revision = 1
+	}
 	if f.Settings.SubmitButtonText == "" {
 		f.Settings.SubmitButtonText = "Absenden"
 	}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusDead      JobStatus = "dead" // All attempts exhausted, needs manual retry
+)
+
+// IsValid reports whether the status is a known job status
+func (s JobStatus) IsValid() bool {
+	switch s {
+	case JobStatusPending, JobStatusRunning, JobStatusSucceeded, JobStatusDead:
+		return true
+	}
+	return false
+}
+
+// Job is a unit of background work persisted in the database
+type Job struct {
+	ID           string     `json:"id" gorm:"primaryKey"`
+	Type         string     `json:"type" gorm:"index;not null"`
+	Payload      string     `json:"payload"`                              // JSON encoded arguments for the handler
+	SubmissionID string     `json:"submission_id,omitempty" gorm:"index"` // Submission whose data the payload contains
+	Status       JobStatus  `json:"status" gorm:"index;not null"`
+	Attempts     int        `json:"attempts"`
+	MaxAttempts  int        `json:"max_attempts"`
+	RunAt        time.Time  `json:"run_at" gorm:"index"`
+	LastError    string     `json:"last_error,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	j.ID = uuid.New().String()
+	return nil
+}
@@ -1,7 +1,9 @@
 package models
 
 import (
+	"crypto/rand"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
 	"time"
 )
@@ -52,8 +54,13 @@ type Settings struct {
 	// Language and Theme
 	Language string `json:"language" gorm:"default:en"`
 	Theme    string `json:"theme" gorm:"default:system"` // "light", "dark", or "system"
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	// Privacy defaults for submission metadata (forms may override)
+	IPStorage        IPStorageMode `json:"ip_storage" gorm:"default:full"`
+	CollectUserAgent bool          `json:"collect_user_agent" gorm:"default:true"`
+	CollectReferrer  bool          `json:"collect_referrer" gorm:"default:true"`
+	IPHashSalt       string        `json:"-"` // Never expose salt in JSON
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        time.Time     `json:"updated_at"`
 }
 
 func GetDefaultSettings() *Settings {
@@ -70,5 +77,18 @@ func GetDefaultSettings() *Settings {
 		LoginBackgroundURL: "",
 		Language:           "en",
 		Theme:              "system",
+		IPStorage:          IPStorageFull,
+		CollectUserAgent:   true,
+		CollectReferrer:    true,
+		IPHashSalt:         NewIPHashSalt(),
 	}
 }
+
+// NewIPHashSalt generates a random salt for hashing submission IPs
+func NewIPHashSalt() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
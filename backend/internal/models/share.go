@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FormRole is the access a user has to a form
+type FormRole string
+
+const (
+	FormRoleOwner  FormRole = "owner"  // Everything, including sharing and deleting the form
+	FormRoleEditor FormRole = "editor" // Edit fields and settings, manage submissions and integrations
+	FormRoleViewer FormRole = "viewer" // Read submissions, analytics and exports
+)
+
+var formRoleRanks = map[FormRole]int{FormRoleViewer: 1, FormRoleEditor: 2, FormRoleOwner: 3}
+
+// IsValid reports whether the role is one of the known roles
+func (r FormRole) IsValid() bool {
+	_, ok := formRoleRanks[r]
+	return ok
+}
+
+// Allows reports whether the role grants at least the access of the required role
+func (r FormRole) Allows(required FormRole) bool {
+	return r.IsValid() && formRoleRanks[r] >= formRoleRanks[required]
+}
+
+// FormShare grants a user access to a form created by another user
+type FormShare struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	FormID    string    `json:"form_id" gorm:"uniqueIndex:idx_form_shares_form_user;not null"`
+	UserID    string    `json:"user_id" gorm:"uniqueIndex:idx_form_shares_form_user;index;not null"`
+	Role      FormRole  `json:"role" gorm:"not null"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *FormShare) BeforeCreate(tx *gorm.DB) error {
+	s.ID = uuid.New().String()
+	return nil
+}
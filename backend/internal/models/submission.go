@@ -50,11 +50,15 @@ func (s *SubmissionMetadata) Scan(value interface{}) error {
 }
 
 type Submission struct {
-	ID        string             `json:"id" gorm:"primaryKey"`
-	FormID    string             `json:"form_id" gorm:"index;not null"`
-	Data      SubmissionData     `json:"data" gorm:"type:json"`
-	Metadata  SubmissionMetadata `json:"metadata" gorm:"type:json"`
-	CreatedAt time.Time          `json:"created_at"`
+	ID       string             `json:"id" gorm:"primaryKey"`
+	FormID   string             `json:"form_id" gorm:"index;not null"`
+	Data     SubmissionData     `json:"data" gorm:"type:json"`
+	Metadata SubmissionMetadata `json:"metadata" gorm:"type:json"`
+	// CompletionSeconds is the time from the first interaction until submit, if known
+	CompletionSeconds *int `json:"completion_seconds,omitempty"`
+	// FormVersion is the version of the form's fields the submission answered, 0 if unknown
+	FormVersion int       `json:"form_version" gorm:"default:0"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 func (s *Submission) BeforeCreate(tx *gorm.DB) error {
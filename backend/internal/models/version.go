@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FormVersion is an immutable snapshot of a form's fields, created when the
+// form is published and on each field change while it is not a draft.
+// Submissions store the version they were made against.
+type FormVersion struct {
+	ID        string     `json:"id" gorm:"primaryKey"`
+	FormID    string     `json:"form_id" gorm:"uniqueIndex:idx_form_versions_form_version;not null"`
+	Version   int        `json:"version" gorm:"uniqueIndex:idx_form_versions_form_version;not null"`
+	Title     string     `json:"title"`
+	Fields    FormFields `json:"fields" gorm:"type:json"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (v *FormVersion) BeforeCreate(tx *gorm.DB) error {
+	v.ID = uuid.New().String()
+	return nil
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookEvent is a submission event webhooks subscribe to. Submissions are
+// never edited once received, so there is no updated event.
+type WebhookEvent string
+
+const (
+	WebhookEventSubmissionCreated WebhookEvent = "submission.created"
+	WebhookEventSubmissionDeleted WebhookEvent = "submission.deleted"
+)
+
+// IsValid reports whether the event is a known webhook event
+func (e WebhookEvent) IsValid() bool {
+	switch e {
+	case WebhookEventSubmissionCreated, WebhookEventSubmissionDeleted:
+		return true
+	}
+	return false
+}
+
+type WebhookEvents []WebhookEvent
+
+func (e WebhookEvents) Value() (driver.Value, error) {
+	if e == nil {
+		return "[]", nil
+	}
+	return json.Marshal(e)
+}
+
+func (e *WebhookEvents) Scan(value interface{}) error {
+	bytes, ok := value.([]byte)
+	if !ok {
+		str, ok := value.(string)
+		if !ok {
+			return errors.New("type assertion to []byte failed")
+		}
+		bytes = []byte(str)
+	}
+	return json.Unmarshal(bytes, e)
+}
+
+// Has reports whether the event is subscribed
+func (e WebhookEvents) Has(event WebhookEvent) bool {
+	for _, ev := range e {
+		if ev == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook is a per-form subscription to submission events
+type Webhook struct {
+	ID        string        `json:"id" gorm:"primaryKey"`
+	FormID    string        `json:"form_id" gorm:"index;not null"`
+	URL       string        `json:"url" gorm:"not null"`
+	Secret    string        `json:"secret"` // HMAC key for the signature header
+	Events    WebhookEvents `json:"events" gorm:"type:json"`
+	Active    bool          `json:"active"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+func (w *Webhook) BeforeCreate(tx *gorm.DB) error {
+	w.ID = uuid.New().String()
+	return nil
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed" // All attempts exhausted
+)
+
+// WebhookDelivery is a single event sent to a webhook, including its retry state
+type WebhookDelivery struct {
+	ID             string                `json:"id" gorm:"primaryKey"`
+	WebhookID      string                `json:"webhook_id" gorm:"index;not null"`
+	SubmissionID   string                `json:"submission_id" gorm:"index"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        string                `json:"payload"` // Exact JSON body, reused on redelivery
+	Status         WebhookDeliveryStatus `json:"status" gorm:"index"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus int                   `json:"response_status,omitempty"`
+	ResponseBody   string                `json:"response_body,omitempty"` // Truncated
+	Error          string                `json:"error,omitempty"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty" gorm:"index"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	d.ID = uuid.New().String()
+	return nil
+}
@@ -0,0 +1,106 @@
+package privacy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+
+	"formera/internal/models"
+)
+
+// Policy is the effective metadata collection policy for a form,
+// resolved from the instance defaults and the form's overrides
+type Policy struct {
+	Anonymous        bool                 `json:"anonymous"`
+	IPStorage        models.IPStorageMode `json:"ip_storage"`
+	CollectUserAgent bool                 `json:"collect_user_agent"`
+	CollectReferrer  bool                 `json:"collect_referrer"`
+}
+
+// Resolve combines instance-wide settings with a form's privacy overrides
+func Resolve(instance *models.Settings, form *models.FormSettings) Policy {
+	policy := Policy{
+		IPStorage:        instance.IPStorage,
+		CollectUserAgent: instance.CollectUserAgent,
+		CollectReferrer:  instance.CollectReferrer,
+	}
+	if !policy.IPStorage.IsValid() {
+		policy.IPStorage = models.IPStorageFull
+	}
+
+	if p := form.Privacy; p != nil {
+		if p.IPStorage.IsValid() {
+			policy.IPStorage = p.IPStorage
+		}
+		if p.CollectUserAgent != nil {
+			policy.CollectUserAgent = *p.CollectUserAgent
+		}
+		if p.CollectReferrer != nil {
+			policy.CollectReferrer = *p.CollectReferrer
+		}
+		policy.Anonymous = p.Anonymous
+	}
+
+	// Anonymous forms must never hold identifying metadata
+	if policy.Anonymous {
+		policy.IPStorage = models.IPStorageNone
+		policy.CollectUserAgent = false
+		policy.CollectReferrer = false
+	}
+
+	return policy
+}
+
+// Apply strips or transforms metadata according to the policy.
+// The salt is only used when IPs are stored hashed.
+func (p Policy) Apply(meta *models.SubmissionMetadata, salt string) {
+	meta.IP = AnonymizeIP(meta.IP, p.IPStorage, salt)
+	if !p.CollectUserAgent {
+		meta.UserAgent = ""
+	}
+	if !p.CollectReferrer {
+		meta.Referrer = ""
+	}
+	// Custom tracking parameters are free-form and may carry identifiers
+	if p.Anonymous {
+		meta.Tracking = nil
+	}
+}
+
+// AnonymizeIP transforms an IP address according to the storage mode
+func AnonymizeIP(ip string, mode models.IPStorageMode, salt string) string {
+	if ip == "" {
+		return ""
+	}
+	switch mode {
+	case models.IPStorageNone:
+		return ""
+	case models.IPStorageTruncated:
+		return TruncateIP(ip)
+	case models.IPStorageHashed:
+		return HashIP(ip, salt)
+	default:
+		return ip
+	}
+}
+
+// TruncateIP zeroes the host part of an address, keeping the /24 network
+// for IPv4 and the /48 network for IPv6. Unparseable input yields "".
+func TruncateIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// HashIP returns a hex-encoded HMAC-SHA256 of the IP keyed with the salt
+func HashIP(ip, salt string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,90 @@
+package privacy
+
+import (
+	"testing"
+
+	"formera/internal/models"
+)
+
+func TestTruncateIP(t *testing.T) {
+	tests := []struct {
+		name     string
+		ip       string
+		expected string
+	}{
+		{"ipv4", "203.0.113.42", "203.0.113.0"},
+		{"ipv6", "2001:db8:abcd:12:34::1", "2001:db8:abcd::"},
+		{"ipv4-mapped ipv6", "::ffff:198.51.100.7", "198.51.100.0"},
+		{"invalid", "not-an-ip", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateIP(tt.ip); got != tt.expected {
+				t.Errorf("TruncateIP(%q) = %q, want %q", tt.ip, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHashIP(t *testing.T) {
+	a := HashIP("203.0.113.42", "salt-a")
+	b := HashIP("203.0.113.42", "salt-b")
+
+	if a == "203.0.113.42" || len(a) != 64 {
+		t.Errorf("expected hex SHA-256 digest, got %q", a)
+	}
+	if a != HashIP("203.0.113.42", "salt-a") {
+		t.Error("hash should be deterministic for the same salt")
+	}
+	if a == b {
+		t.Error("hash should depend on the salt")
+	}
+}
+
+func TestResolve_FormOverridesInstance(t *testing.T) {
+	instance := &models.Settings{IPStorage: models.IPStorageFull, CollectUserAgent: true, CollectReferrer: true}
+	no := false
+	form := &models.FormSettings{Privacy: &models.FormPrivacy{
+		IPStorage:       models.IPStorageTruncated,
+		CollectReferrer: &no,
+	}}
+
+	policy := Resolve(instance, form)
+
+	if policy.IPStorage != models.IPStorageTruncated {
+		t.Errorf("expected truncated IP storage, got %s", policy.IPStorage)
+	}
+	if !policy.CollectUserAgent {
+		t.Error("user agent collection should be inherited from instance")
+	}
+	if policy.CollectReferrer {
+		t.Error("referrer collection should be disabled by form override")
+	}
+}
+
+func TestPolicy_Apply_Anonymous(t *testing.T) {
+	instance := &models.Settings{IPStorage: models.IPStorageFull, CollectUserAgent: true, CollectReferrer: true}
+	yes := true
+	form := &models.FormSettings{Privacy: &models.FormPrivacy{
+		Anonymous:        true,
+		IPStorage:        models.IPStorageHashed,
+		CollectUserAgent: &yes,
+	}}
+
+	meta := models.SubmissionMetadata{
+		IP:        "203.0.113.42",
+		UserAgent: "Mozilla/5.0",
+		Referrer:  "https://example.com/",
+		UTMSource: "newsletter",
+		Tracking:  map[string]string{"member_id": "42"},
+	}
+	Resolve(instance, form).Apply(&meta, "salt")
+
+	if meta.IP != "" || meta.UserAgent != "" || meta.Referrer != "" || meta.Tracking != nil {
+		t.Errorf("anonymous form kept identifying metadata: %+v", meta)
+	}
+	if meta.UTMSource != "newsletter" {
+		t.Errorf("campaign attribution should be kept, got %q", meta.UTMSource)
+	}
+}
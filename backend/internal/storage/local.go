@@ -40,6 +40,20 @@ func NewLocalStorage(basePath, baseURL string) (*LocalStorage, error) {
 
 // Upload stores a file on the local filesystem
 func (s *LocalStorage) Upload(filename string, contentType string, size int64, reader io.Reader) (*UploadResult, error) {
+	// Determine subdirectory based on content type
+	subdir := "files"
+	if AllowedImageTypes[contentType] {
+		subdir = "images"
+	}
+	return s.upload(subdir, filename, contentType, size, reader)
+}
+
+// UploadExport stores a generated export in ExportsDir
+func (s *LocalStorage) UploadExport(filename string, contentType string, size int64, reader io.Reader) (*UploadResult, error) {
+	return s.upload(ExportsDir, filename, contentType, size, reader)
+}
+
+func (s *LocalStorage) upload(subdir, filename string, contentType string, size int64, reader io.Reader) (*UploadResult, error) {
 	// Generate unique file ID
 	fileID, err := generateFileID()
 	if err != nil {
@@ -53,12 +67,6 @@ func (s *LocalStorage) Upload(filename string, contentType string, size int64, r
 		ext = GetExtensionFromMimeType(contentType)
 	}
 
-	// Determine subdirectory based on content type
-	subdir := "files"
-	if AllowedImageTypes[contentType] {
-		subdir = "images"
-	}
-
 	// Create date-based subdirectory for better organization
 	dateDir := time.Now().Format("2006/01")
 	fullDir := filepath.Join(s.basePath, subdir, dateDir)
@@ -197,7 +205,7 @@ func (s *LocalStorage) GetURL(fileID string) (string, error) {
 // Delete removes a file from local storage
 func (s *LocalStorage) Delete(fileID string) error {
 	// Search for the file
-	for _, subdir := range []string{"images", "files"} {
+	for _, subdir := range []string{"images", "files", ExportsDir} {
 		pattern := filepath.Join(s.basePath, subdir, "*", "*", fileID+"*")
 		matches, err := filepath.Glob(pattern)
 		if err != nil {
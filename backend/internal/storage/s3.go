@@ -76,6 +76,20 @@ func NewS3Storage(cfg S3Config) (*S3Storage, error) {
 
 // Upload stores a file in S3
 func (s *S3Storage) Upload(filename string, contentType string, size int64, reader io.Reader) (*UploadResult, error) {
+	// Determine subdirectory based on content type
+	subdir := "files"
+	if AllowedImageTypes[contentType] {
+		subdir = "images"
+	}
+	return s.upload(subdir, filename, contentType, size, reader)
+}
+
+// UploadExport stores a generated export in ExportsDir
+func (s *S3Storage) UploadExport(filename string, contentType string, size int64, reader io.Reader) (*UploadResult, error) {
+	return s.upload(ExportsDir, filename, contentType, size, reader)
+}
+
+func (s *S3Storage) upload(subdir, filename string, contentType string, size int64, reader io.Reader) (*UploadResult, error) {
 	ctx := context.TODO()
 
 	// Generate unique file ID
@@ -91,12 +105,6 @@ func (s *S3Storage) Upload(filename string, contentType string, size int64, read
 		ext = GetExtensionFromMimeType(contentType)
 	}
 
-	// Determine subdirectory based on content type
-	subdir := "files"
-	if AllowedImageTypes[contentType] {
-		subdir = "images"
-	}
-
 	// Create the relative path (without prefix - that's S3-specific)
 	dateDir := time.Now().Format("2006/01")
 	storedFilename := fileID + ext
@@ -209,7 +217,7 @@ func (s *S3Storage) Delete(fileID string) error {
 	ctx := context.TODO()
 
 	// Find and delete the file
-	for _, subdir := range []string{"images", "files"} {
+	for _, subdir := range []string{"images", "files", ExportsDir} {
 		prefix := fmt.Sprintf("%s%s/", s.prefix, subdir)
 
 		paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
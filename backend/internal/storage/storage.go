@@ -23,6 +23,10 @@ const (
 	StorageTypeS3    StorageType = "s3"
 )
 
+// ExportsDir holds generated exports. It is not publicly served and not
+// accepted by SanitizePath, so exports are only available through the export API.
+const ExportsDir = "exports"
+
 // UploadResult contains information about an uploaded file
 type UploadResult struct {
 	ID       string `json:"id"`
@@ -45,6 +49,9 @@ type Storage interface {
 	// Upload stores a file and returns the result
 	Upload(filename string, contentType string, size int64, reader io.Reader) (*UploadResult, error)
 
+	// UploadExport stores a generated export in ExportsDir
+	UploadExport(filename string, contentType string, size int64, reader io.Reader) (*UploadResult, error)
+
 	// GetURL returns the URL for accessing a file by ID (searches for file)
 	GetURL(fileID string) (string, error)
 
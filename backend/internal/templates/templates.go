@@ -0,0 +1,153 @@
+// Package templates provides the form templates users can start new forms from
+package templates
+
+import (
+	"sort"
+	"strings"
+
+	"formera/internal/models"
+)
+
+// Categories of the built-in templates. Admins may use other categories.
+const (
+	CategoryContact  = "contact"
+	CategoryEvents   = "events"
+	CategoryFeedback = "feedback"
+)
+
+// builtInPrefix marks the IDs of built-in templates
+const builtInPrefix = "builtin-"
+
+// Template is a form definition to start new forms from. Built-in templates
+// ship with the instance, the others are forms marked as templates by admins
+// and share their ID.
+type Template struct {
+	ID          string              `json:"id"`
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Category    string              `json:"category"`
+	BuiltIn     bool                `json:"built_in"`
+	Fields      models.FormFields   `json:"fields"`
+	Settings    models.FormSettings `json:"settings"`
+}
+
+// FromForm returns the template of a form marked as template. Settings that
+// belong to the form's owner are left out.
+func FromForm(form *models.Form) Template {
+	settings := form.Settings
+	settings.NotificationEmail = ""
+	return Template{
+		ID:          form.ID,
+		Title:       form.Title,
+		Description: form.TemplateDescription,
+		Category:    form.TemplateCategory,
+		Fields:      form.Fields,
+		Settings:    settings,
+	}
+}
+
+// NewForm returns an unsaved draft form of a user with the template's
+// fields and settings
+func (t *Template) NewForm(userID, title string) *models.Form {
+	if title == "" {
+		title = t.Title
+	}
+	fields := make(models.FormFields, len(t.Fields))
+	copy(fields, t.Fields)
+	return &models.Form{
+		UserID:   userID,
+		Title:    title,
+		Fields:   fields,
+		Settings: t.Settings,
+		Status:   models.FormStatusDraft,
+	}
+}
+
+// IsBuiltIn reports whether an ID belongs to a built-in template
+func IsBuiltIn(id string) bool {
+	return strings.HasPrefix(id, builtInPrefix)
+}
+
+// BuiltIn returns the templates that ship with the instance, ordered by category
+func BuiltIn() []Template {
+	list := []Template{contact(), eventRegistration(), feedback()}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Category < list[j].Category })
+	return list
+}
+
+// FindBuiltIn returns the built-in template with the given ID
+func FindBuiltIn(id string) (*Template, bool) {
+	for _, t := range BuiltIn() {
+		if t.ID == id {
+			return &t, true
+		}
+	}
+	return nil, false
+}
+
+func contact() Template {
+	return Template{
+		ID:          builtInPrefix + "contact",
+		Title:       "Contact",
+		Description: "Let visitors send you a message with their name and email address.",
+		Category:    CategoryContact,
+		BuiltIn:     true,
+		Fields: models.FormFields{
+			{ID: "name", Type: models.FieldTypeText, Label: "Name", Required: true, Order: 0},
+			{ID: "email", Type: models.FieldTypeEmail, Label: "Email", Required: true, Order: 1},
+			{ID: "subject", Type: models.FieldTypeText, Label: "Subject", Order: 2},
+			{ID: "message", Type: models.FieldTypeTextarea, Label: "Message", Required: true, Order: 3},
+		},
+		Settings: models.FormSettings{
+			SubmitButtonText: "Send message",
+			SuccessMessage:   "Thank you for your message. We will get back to you soon.",
+		},
+	}
+}
+
+func eventRegistration() Template {
+	return Template{
+		ID:          builtInPrefix + "event-registration",
+		Title:       "Event registration",
+		Description: "Collect registrations with contact details, attendance days and dietary requirements.",
+		Category:    CategoryEvents,
+		BuiltIn:     true,
+		Fields: models.FormFields{
+			{ID: "name", Type: models.FieldTypeText, Label: "Name", Required: true, Order: 0},
+			{ID: "email", Type: models.FieldTypeEmail, Label: "Email", Required: true, Order: 1},
+			{ID: "organization", Type: models.FieldTypeText, Label: "Organization", Order: 2},
+			{ID: "days", Type: models.FieldTypeCheckbox, Label: "Which days will you attend?", Required: true, Options: []string{"Day 1", "Day 2"}, Order: 3},
+			{ID: "diet", Type: models.FieldTypeRadio, Label: "Dietary requirements", Options: []string{"None", "Vegetarian", "Vegan"}, Order: 4},
+			{ID: "notes", Type: models.FieldTypeTextarea, Label: "Anything else we should know?", Order: 5},
+		},
+		Settings: models.FormSettings{
+			SubmitButtonText:       "Register",
+			SuccessMessage:         "You are registered. A confirmation has been sent to your email address.",
+			SendConfirmation:       true,
+			ConfirmationEmailField: "email",
+			ConfirmationSubject:    "Your registration",
+			ConfirmationBody:       "Hello {{name}},\n\nthank you for registering. We look forward to seeing you.",
+		},
+	}
+}
+
+func feedback() Template {
+	return Template{
+		ID:          builtInPrefix + "feedback",
+		Title:       "Feedback",
+		Description: "Ask for a rating, what worked well and what could be improved.",
+		Category:    CategoryFeedback,
+		BuiltIn:     true,
+		Fields: models.FormFields{
+			{ID: "rating", Type: models.FieldTypeRating, Label: "How satisfied are you overall?", Required: true, MinValue: 1, MaxValue: 5, Order: 0},
+			{ID: "recommend", Type: models.FieldTypeScale, Label: "How likely are you to recommend us?", MinValue: 0, MaxValue: 10, MinLabel: "Not likely", MaxLabel: "Very likely", Order: 1},
+			{ID: "liked", Type: models.FieldTypeTextarea, Label: "What did you like?", Order: 2},
+			{ID: "improve", Type: models.FieldTypeTextarea, Label: "What could we improve?", Order: 3},
+		},
+		Settings: models.FormSettings{
+			SubmitButtonText: "Send feedback",
+			SuccessMessage:   "Thank you for your feedback!",
+			Privacy:          &models.FormPrivacy{Anonymous: true},
+		},
+	}
+}
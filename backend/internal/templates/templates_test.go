@@ -0,0 +1,60 @@
+package templates
+
+import (
+	"testing"
+
+	"formera/internal/models"
+)
+
+func TestBuiltIn(t *testing.T) {
+	list := BuiltIn()
+	if len(list) != 3 {
+		t.Fatalf("expected 3 built-in templates, got %d", len(list))
+	}
+	for i, tmpl := range list {
+		if !IsBuiltIn(tmpl.ID) || !tmpl.BuiltIn || len(tmpl.Fields) == 0 {
+			t.Errorf("invalid built-in template %+v", tmpl)
+		}
+		if i > 0 && list[i-1].Category > tmpl.Category {
+			t.Errorf("expected templates ordered by category")
+		}
+		if found, ok := FindBuiltIn(tmpl.ID); !ok || found.Title != tmpl.Title {
+			t.Errorf("FindBuiltIn(%q) failed", tmpl.ID)
+		}
+	}
+	if _, ok := FindBuiltIn("builtin-unknown"); ok {
+		t.Error("expected unknown template not to be found")
+	}
+}
+
+func TestFromForm(t *testing.T) {
+	form := &models.Form{
+		ID:                  "form-1",
+		Title:               "Survey",
+		IsTemplate:          true,
+		TemplateCategory:    "research",
+		TemplateDescription: "A short survey",
+		Fields:              models.FormFields{{ID: "q1", Type: models.FieldTypeText, Label: "Question"}},
+		Settings:            models.FormSettings{NotificationEmail: "owner@example.com", SuccessMessage: "Thanks"},
+	}
+
+	tmpl := FromForm(form)
+	if tmpl.ID != "form-1" || tmpl.BuiltIn || tmpl.Category != "research" || tmpl.Description != "A short survey" {
+		t.Errorf("unexpected template %+v", tmpl)
+	}
+	if tmpl.Settings.NotificationEmail != "" || tmpl.Settings.SuccessMessage != "Thanks" {
+		t.Errorf("expected the notification email to be left out, got %+v", tmpl.Settings)
+	}
+
+	created := tmpl.NewForm("user-1", "")
+	if created.UserID != "user-1" || created.Title != "Survey" || created.Status != models.FormStatusDraft || created.IsTemplate {
+		t.Errorf("unexpected form %+v", created)
+	}
+	created.Fields[0].Label = "Changed"
+	if form.Fields[0].Label != "Question" {
+		t.Error("expected the template fields to be copied")
+	}
+	if named := tmpl.NewForm("user-1", "My survey"); named.Title != "My survey" {
+		t.Errorf("expected the given title, got %q", named.Title)
+	}
+}
@@ -0,0 +1,171 @@
+package testutil
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// SMTPMessage is a message received by the SMTP sink
+type SMTPMessage struct {
+	From string
+	To   []string
+	Data string
+}
+
+// SMTPSink is a minimal in-process SMTP server that records received messages.
+// It speaks plain SMTP without STARTTLS or AUTH.
+type SMTPSink struct {
+	listener net.Listener
+	mu       sync.Mutex
+	messages []SMTPMessage
+	failNext int
+	received chan struct{}
+}
+
+// StartSMTPSink starts an SMTP sink on a random local port, closed when the test ends
+func StartSMTPSink(t *testing.T) *SMTPSink {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start smtp sink: %v", err)
+	}
+
+	sink := &SMTPSink{listener: listener, received: make(chan struct{}, 100)}
+	go sink.serve()
+	t.Cleanup(func() { listener.Close() })
+
+	return sink
+}
+
+// Host returns the sink's host
+func (s *SMTPSink) Host() string {
+	return s.listener.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port returns the sink's port
+func (s *SMTPSink) Port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+// FailNext makes the next n transactions fail with a temporary error
+func (s *SMTPSink) FailNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failNext = n
+}
+
+// Messages returns all messages received so far
+func (s *SMTPSink) Messages() []SMTPMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SMTPMessage(nil), s.messages...)
+}
+
+// WaitForMessages blocks until n messages were received or the timeout expires
+func (s *SMTPSink) WaitForMessages(t *testing.T, n int, timeout time.Duration) []SMTPMessage {
+	t.Helper()
+
+	deadline := time.After(timeout)
+	for {
+		if msgs := s.Messages(); len(msgs) >= n {
+			return msgs
+		}
+		select {
+		case <-s.received:
+		case <-deadline:
+			t.Fatalf("timed out waiting for %d messages, got %d", n, len(s.Messages()))
+			return nil
+		}
+	}
+}
+
+func (s *SMTPSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *SMTPSink) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	var msg SMTPMessage
+	reply("220 localhost ESMTP sink")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250-localhost")
+			reply("250 8BITMIME")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.mu.Lock()
+			fail := s.failNext > 0
+			if fail {
+				s.failNext--
+			}
+			s.mu.Unlock()
+			if fail {
+				reply("451 Temporary failure")
+				continue
+			}
+			msg = SMTPMessage{From: extractAddress(line)}
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.To = append(msg.To, extractAddress(line))
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.Data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			select {
+			case s.received <- struct{}{}:
+			default:
+			}
+			reply("250 OK")
+		case cmd == "RSET", cmd == "NOOP":
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func extractAddress(line string) string {
+	start := strings.Index(line, "<")
+	end := strings.LastIndex(line, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
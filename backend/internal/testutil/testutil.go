@@ -12,7 +12,7 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-func SetupTestDB(t *testing.T) *gorm.DB {
+func SetupTestDB(t testing.TB) *gorm.DB {
 	t.Helper()
 
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
@@ -22,7 +22,7 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("failed to connect to test database: %v", err)
 	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Form{}, &models.Submission{}, &models.Settings{}, &storage.FileRecord{})
+	err = db.AutoMigrate(&models.User{}, &models.Form{}, &models.Submission{}, &models.Settings{}, &models.AuditLog{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.Job{}, &models.FormAnalytics{}, &models.AttributionView{}, &models.Export{}, &models.ExportPreset{}, &models.ExportSchedule{}, &models.FormVersion{}, &models.FormShare{}, &storage.FileRecord{})
 	if err != nil {
 		t.Fatalf("failed to migrate test database: %v", err)
 	}
@@ -33,7 +33,7 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 	return db
 }
 
-func CreateTestUser(t *testing.T, db *gorm.DB, email, password string, role models.UserRole) *models.User {
+func CreateTestUser(t testing.TB, db *gorm.DB, email, password string, role models.UserRole) *models.User {
 	t.Helper()
 
 	user := &models.User{
@@ -0,0 +1,78 @@
+package versions
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"formera/internal/models"
+)
+
+// FieldChange is a field present in both versions with different properties
+type FieldChange struct {
+	ID         string           `json:"id"`
+	Properties []string         `json:"properties"` // JSON names of the changed properties
+	Before     models.FormField `json:"before"`
+	After      models.FormField `json:"after"`
+}
+
+// Diff lists the field changes between two versions. Fields are matched by ID.
+type Diff struct {
+	From    int                `json:"from"`
+	To      int                `json:"to"`
+	Added   []models.FormField `json:"added"`
+	Removed []models.FormField `json:"removed"`
+	Changed []FieldChange      `json:"changed"`
+}
+
+// Compare returns the differences from one version to another
+func Compare(from, to *models.FormVersion) *Diff {
+	d := &Diff{
+		From:    from.Version,
+		To:      to.Version,
+		Added:   []models.FormField{},
+		Removed: []models.FormField{},
+		Changed: []FieldChange{},
+	}
+	for _, before := range from.Fields {
+		after := to.Fields.Find(before.ID)
+		if after == nil {
+			d.Removed = append(d.Removed, before)
+			continue
+		}
+		if props := changedProperties(before, *after); len(props) > 0 {
+			d.Changed = append(d.Changed, FieldChange{ID: before.ID, Properties: props, Before: before, After: *after})
+		}
+	}
+	for _, after := range to.Fields {
+		if from.Fields.Find(after.ID) == nil {
+			d.Added = append(d.Added, after)
+		}
+	}
+	return d
+}
+
+// changedProperties compares two fields by their JSON properties
+func changedProperties(a, b models.FormField) []string {
+	ma, mb := fieldMap(a), fieldMap(b)
+	var props []string
+	for key, va := range ma {
+		if !reflect.DeepEqual(va, mb[key]) {
+			props = append(props, key)
+		}
+	}
+	for key := range mb {
+		if _, ok := ma[key]; !ok {
+			props = append(props, key)
+		}
+	}
+	sort.Strings(props)
+	return props
+}
+
+func fieldMap(f models.FormField) map[string]interface{} {
+	encoded, _ := json.Marshal(f)
+	m := make(map[string]interface{})
+	json.Unmarshal(encoded, &m)
+	return m
+}
@@ -0,0 +1,136 @@
+// Package versions keeps immutable snapshots of form fields, so submissions
+// are shown and exported with the fields they answered
+package versions
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"formera/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Snapshot creates a new version of the form if its fields differ from the
+// latest version and sets the form's current version. Submissions made
+// before the first version are pinned to it. Returns whether a version was
+// created.
+func Snapshot(tx *gorm.DB, form *models.Form) (bool, error) {
+	var latest models.FormVersion
+	if err := tx.Where("form_id = ?", form.ID).Order("version DESC").Limit(1).Find(&latest).Error; err != nil {
+		return false, err
+	}
+	if latest.ID != "" && reflect.DeepEqual(normalize(latest.Fields), normalize(form.Fields)) {
+		return false, setCurrent(tx, form, latest.Version)
+	}
+
+	version := &models.FormVersion{
+		FormID:  form.ID,
+		Version: latest.Version + 1,
+		Title:   form.Title,
+		Fields:  form.Fields,
+	}
+	if err := tx.Create(version).Error; err != nil {
+		return false, err
+	}
+	if version.Version == 1 {
+		if err := tx.Model(&models.Submission{}).
+			Where("form_id = ? AND form_version = 0", form.ID).
+			UpdateColumn("form_version", 1).Error; err != nil {
+			return false, err
+		}
+	}
+	return true, setCurrent(tx, form, version.Version)
+}
+
+func setCurrent(tx *gorm.DB, form *models.Form, version int) error {
+	if form.CurrentVersion == version {
+		return nil
+	}
+	form.CurrentVersion = version
+	// UpdateColumn keeps the form's updated_at
+	return tx.Model(&models.Form{}).Where("id = ?", form.ID).UpdateColumn("current_version", version).Error
+}
+
+// normalize returns the fields as generic JSON values, so fields loaded from
+// the database compare equal to the same fields from a request
+func normalize(fields models.FormFields) interface{} {
+	if len(fields) == 0 {
+		return nil
+	}
+	encoded, _ := json.Marshal(fields)
+	var v interface{}
+	json.Unmarshal(encoded, &v)
+	return v
+}
+
+// List returns the versions of a form, newest first
+func List(db *gorm.DB, formID string) ([]models.FormVersion, error) {
+	var list []models.FormVersion
+	err := db.Where("form_id = ?", formID).Order("version DESC").Find(&list).Error
+	return list, err
+}
+
+// WithHistory appends the answer fields of older versions that are no longer
+// part of the form, with the label of the newest version containing them, so
+// exports keep the answers to removed or renamed fields.
+func WithHistory(db *gorm.DB, form *models.Form) error {
+	list, err := List(db, form.ID)
+	if err != nil {
+		return err
+	}
+
+	fields := make(models.FormFields, len(form.Fields))
+	copy(fields, form.Fields)
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		seen[f.ID] = true
+	}
+	for _, v := range list {
+		for _, f := range v.Fields {
+			if seen[f.ID] || f.Type.IsLayout() {
+				continue
+			}
+			seen[f.ID] = true
+			f.Order = len(fields)
+			fields = append(fields, f)
+		}
+	}
+	form.Fields = fields
+	return nil
+}
+
+// Resolver looks up the fields submissions were made against, loading each
+// version once
+type Resolver struct {
+	db     *gorm.DB
+	form   *models.Form
+	fields map[int]models.FormFields
+}
+
+// NewResolver creates a resolver for the submissions of a form
+func NewResolver(db *gorm.DB, form *models.Form) *Resolver {
+	return &Resolver{db: db, form: form, fields: make(map[int]models.FormFields)}
+}
+
+// Fields returns the fields of a version. Unknown versions resolve to the
+// form's current fields.
+func (r *Resolver) Fields(version int) (models.FormFields, error) {
+	if version == 0 {
+		return r.form.Fields, nil
+	}
+	if fields, ok := r.fields[version]; ok {
+		return fields, nil
+	}
+
+	var v models.FormVersion
+	if err := r.db.Where("form_id = ? AND version = ?", r.form.ID, version).Limit(1).Find(&v).Error; err != nil {
+		return nil, err
+	}
+	fields := v.Fields
+	if v.ID == "" {
+		fields = r.form.Fields
+	}
+	r.fields[version] = fields
+	return fields, nil
+}
@@ -0,0 +1,95 @@
+package versions
+
+import (
+	"reflect"
+	"testing"
+
+	"formera/internal/models"
+	"formera/internal/testutil"
+)
+
+func TestSnapshot(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	form := &models.Form{
+		UserID: "user-1",
+		Title:  "Survey",
+		Status: models.FormStatusPublished,
+		Fields: models.FormFields{{ID: "name", Type: models.FieldTypeText, Label: "Name"}},
+	}
+	db.Create(form)
+	legacy := &models.Submission{FormID: form.ID, Data: models.SubmissionData{"name": "Ada"}}
+	db.Create(legacy)
+
+	created, err := Snapshot(db, form)
+	if err != nil || !created || form.CurrentVersion != 1 {
+		t.Fatalf("expected version 1, got %d (created %v, err %v)", form.CurrentVersion, created, err)
+	}
+	db.First(legacy, "id = ?", legacy.ID)
+	if legacy.FormVersion != 1 {
+		t.Errorf("expected the earlier submission to be pinned to version 1, got %d", legacy.FormVersion)
+	}
+
+	// Unchanged fields, e.g. reloaded from the database
+	var reloaded models.Form
+	db.First(&reloaded, "id = ?", form.ID)
+	if created, _ := Snapshot(db, &reloaded); created || reloaded.CurrentVersion != 1 {
+		t.Errorf("expected no new version for unchanged fields, got %d", reloaded.CurrentVersion)
+	}
+
+	form.Fields = models.FormFields{{ID: "full_name", Type: models.FieldTypeText, Label: "Full name"}}
+	db.Save(form)
+	if created, err := Snapshot(db, form); err != nil || !created || form.CurrentVersion != 2 {
+		t.Fatalf("expected version 2, got %d (created %v, err %v)", form.CurrentVersion, created, err)
+	}
+	db.First(&reloaded, "id = ?", form.ID)
+	if reloaded.CurrentVersion != 2 {
+		t.Errorf("expected the current version to be stored, got %d", reloaded.CurrentVersion)
+	}
+
+	// Removed fields stay in exports
+	if err := WithHistory(db, &reloaded); err != nil {
+		t.Fatalf("history failed: %v", err)
+	}
+	var ids []string
+	for _, f := range reloaded.Fields {
+		ids = append(ids, f.ID)
+	}
+	if want := []string{"full_name", "name"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("expected fields %v, got %v", want, ids)
+	}
+
+	r := NewResolver(db, form)
+	for version, want := range map[int]string{0: "Full name", 1: "Name", 2: "Full name", 9: "Full name"} {
+		fields, err := r.Fields(version)
+		if err != nil || len(fields) != 1 || fields[0].Label != want {
+			t.Errorf("version %d: expected label %q, got %+v (err %v)", version, want, fields, err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	from := &models.FormVersion{Version: 1, Fields: models.FormFields{
+		{ID: "name", Type: models.FieldTypeText, Label: "Name"},
+		{ID: "color", Type: models.FieldTypeRadio, Label: "Color", Options: []string{"Red", "Blue"}},
+		{ID: "age", Type: models.FieldTypeNumber, Label: "Age"},
+	}}
+	to := &models.FormVersion{Version: 2, Fields: models.FormFields{
+		{ID: "name", Type: models.FieldTypeText, Label: "Name"},
+		{ID: "color", Type: models.FieldTypeRadio, Label: "Favorite color", Required: true, Options: []string{"Red", "Blue"}},
+		{ID: "email", Type: models.FieldTypeEmail, Label: "Email"},
+	}}
+
+	d := Compare(from, to)
+	if d.From != 1 || d.To != 2 {
+		t.Errorf("unexpected versions %d to %d", d.From, d.To)
+	}
+	if len(d.Added) != 1 || d.Added[0].ID != "email" {
+		t.Errorf("expected email to be added, got %+v", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].ID != "age" {
+		t.Errorf("expected age to be removed, got %+v", d.Removed)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].ID != "color" || !reflect.DeepEqual(d.Changed[0].Properties, []string{"label", "required"}) {
+		t.Errorf("expected label and required of color to change, got %+v", d.Changed)
+	}
+}
@@ -0,0 +1,320 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"formera/internal/jobs"
+	"formera/internal/logger"
+	"formera/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxResponseBody limits how much of a receiver's response is kept in the delivery log
+const maxResponseBody = 1024
+
+const (
+	JobType        = "webhook.deliver" // A single delivery attempt
+	CleanupJobType = "webhook.cleanup" // Deletes old deliveries, rescheduled daily
+)
+
+// cleanupInterval between runs of the delivery log cleanup
+const cleanupInterval = 24 * time.Hour
+
+// Config contains configuration for webhook delivery
+type Config struct {
+	// MaxAttempts before a delivery is marked as failed
+	MaxAttempts int
+	// Timeout for a single HTTP request
+	Timeout time.Duration
+	// Retention of finished deliveries, whose payloads contain the answers
+	Retention time.Duration
+	// AllowPrivateNetworks permits receivers on loopback, private and
+	// link-local addresses, e.g. services on the same host
+	AllowPrivateNetworks bool
+}
+
+// DefaultConfig returns sensible defaults
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts: 6,
+		Timeout:     10 * time.Second,
+		Retention:   30 * 24 * time.Hour,
+	}
+}
+
+// Dispatcher records webhook deliveries in the database and sends them through
+// the job queue, which retries failed attempts with exponential backoff.
+type Dispatcher struct {
+	db     *gorm.DB
+	queue  *jobs.Queue
+	client *http.Client
+	config Config
+}
+
+// deliveryPayload is the job payload of a delivery attempt
+type deliveryPayload struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(db *gorm.DB, config Config) *Dispatcher {
+	defaults := DefaultConfig()
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaults.Retention
+	}
+
+	return &Dispatcher{
+		db:     db,
+		client: newClient(config),
+		config: config,
+	}
+}
+
+// Register adds the delivery and cleanup job handlers to the queue and
+// schedules the cleanup. Must be called before the queue is started;
+// deliveries recorded without a queue stay pending.
+func (d *Dispatcher) Register(q *jobs.Queue) {
+	d.queue = q
+	q.Register(JobType, d.deliver)
+	q.Register(CleanupJobType, d.cleanup)
+
+	var scheduled int64
+	d.db.Model(&models.Job{}).
+		Where("type = ? AND status IN ?", CleanupJobType, []models.JobStatus{models.JobStatusPending, models.JobStatusRunning}).
+		Count(&scheduled)
+	if scheduled == 0 {
+		if _, err := q.Enqueue(CleanupJobType, struct{}{}); err != nil {
+			logger.Error().Err(err).Msg("Failed to schedule webhook delivery cleanup")
+		}
+	}
+}
+
+// cleanup is the job handler deleting finished deliveries older than the
+// retention period and scheduling its next run
+func (d *Dispatcher) cleanup(ctx context.Context, job *models.Job) error {
+	result := d.db.Where("status <> ? AND created_at < ?", models.WebhookDeliveryPending, time.Now().Add(-d.config.Retention)).
+		Delete(&models.WebhookDelivery{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		logger.Info().Int64("count", result.RowsAffected).Msg("Deleted old webhook deliveries")
+	}
+	_, err := d.queue.Enqueue(CleanupJobType, struct{}{}, jobs.RunAt(time.Now().Add(cleanupInterval)))
+	return err
+}
+
+// PurgeSubmissions deletes all deliveries of the given submissions, so their
+// answers are not kept after an erasure request
+func PurgeSubmissions(db *gorm.DB, submissionIDs []string) error {
+	return db.Where("submission_id IN ?", submissionIDs).Delete(&models.WebhookDelivery{}).Error
+}
+
+// Dispatch records a delivery of each submission for every active webhook of
+// the form subscribed to the event. Delivery happens asynchronously; the caller
+// is never blocked by receivers.
+func (d *Dispatcher) Dispatch(event models.WebhookEvent, form *models.Form, submissions ...*models.Submission) {
+	if d == nil || len(submissions) == 0 {
+		return
+	}
+
+	var hooks []models.Webhook
+	if err := d.db.Where("form_id = ? AND active = ?", form.ID, true).Find(&hooks).Error; err != nil {
+		logger.Error().Err(err).Str("form_id", form.ID).Msg("Failed to load webhooks")
+		return
+	}
+
+	var bodies []string
+	var deliveries []*models.WebhookDelivery
+	for _, hook := range hooks {
+		if !hook.Events.Has(event) {
+			continue
+		}
+		if bodies == nil {
+			bodies = make([]string, len(submissions))
+			for i, submission := range submissions {
+				body, err := json.Marshal(NewPayload(event, form, submission))
+				if err != nil {
+					logger.Error().Err(err).Str("form_id", form.ID).Msg("Failed to encode webhook payload")
+					return
+				}
+				bodies[i] = string(body)
+			}
+		}
+		for i, body := range bodies {
+			deliveries = append(deliveries, newDelivery(hook.ID, submissions[i].ID, event, body))
+		}
+	}
+
+	if err := d.enqueue(deliveries...); err != nil {
+		logger.Error().Err(err).Str("form_id", form.ID).Msg("Failed to record webhook deliveries")
+	}
+}
+
+// Redeliver sends the payload of an earlier delivery again as a new delivery
+func (d *Dispatcher) Redeliver(original *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	delivery := newDelivery(original.WebhookID, original.SubmissionID, original.Event, original.Payload)
+	if err := d.enqueue(delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func newDelivery(webhookID, submissionID string, event models.WebhookEvent, payload string) *models.WebhookDelivery {
+	now := time.Now()
+	return &models.WebhookDelivery{
+		WebhookID:     webhookID,
+		SubmissionID:  submissionID,
+		Event:         event,
+		Payload:       payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}
+}
+
+// enqueue stores the deliveries and queues a job for each
+func (d *Dispatcher) enqueue(deliveries ...*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := d.db.CreateInBatches(deliveries, 100).Error; err != nil {
+		return err
+	}
+	if d.queue == nil {
+		return nil
+	}
+	payloads := make([]interface{}, len(deliveries))
+	for i, delivery := range deliveries {
+		payloads[i] = deliveryPayload{DeliveryID: delivery.ID}
+	}
+	return d.queue.EnqueueBatch(JobType, payloads, jobs.MaxAttempts(d.config.MaxAttempts))
+}
+
+// RemoveIdle deletes the webhooks of deleted forms including their delivery
+// logs. Webhooks with pending deliveries, such as the deleted events of the
+// forms' submissions, are removed after their last attempt instead.
+func RemoveIdle(db *gorm.DB, formIDs []string) error {
+	pending := db.Model(&models.WebhookDelivery{}).Select("webhook_id").Where("status = ?", models.WebhookDeliveryPending)
+	hookIDs := db.Model(&models.Webhook{}).Select("id").Where("form_id IN ? AND id NOT IN (?)", formIDs, pending)
+	if err := db.Where("webhook_id IN (?)", hookIDs).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	return db.Where("form_id IN ? AND id NOT IN (?)", formIDs, pending).Delete(&models.Webhook{}).Error
+}
+
+// removeIfOrphaned deletes a webhook whose form was deleted once none of its
+// deliveries is pending anymore
+func (d *Dispatcher) removeIfOrphaned(hook *models.Webhook) {
+	var forms int64
+	if err := d.db.Model(&models.Form{}).Where("id = ?", hook.FormID).Count(&forms).Error; err != nil || forms > 0 {
+		return
+	}
+	if err := RemoveIdle(d.db, []string{hook.FormID}); err != nil {
+		logger.Error().Err(err).Str("webhook_id", hook.ID).Msg("Failed to remove webhook of deleted form")
+	}
+}
+
+// deliver is the job handler sending a delivery once and recording the
+// outcome. Failed attempts are returned as error, so the queue retries them.
+func (d *Dispatcher) deliver(ctx context.Context, job *models.Job) error {
+	var payload deliveryPayload
+	if err := jobs.Decode(job, &payload); err != nil {
+		return err
+	}
+	var delivery models.WebhookDelivery
+	if err := d.db.Limit(1).Find(&delivery, "id = ?", payload.DeliveryID).Error; err != nil {
+		return err
+	}
+	// Deleted with its webhook, or already delivered by an earlier run
+	if delivery.ID == "" || delivery.Status == models.WebhookDeliverySucceeded {
+		return nil
+	}
+
+	var hook models.Webhook
+	if err := d.db.First(&hook, "id = ?", delivery.WebhookID).Error; err != nil || !hook.Active {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = "webhook deleted or disabled"
+		delivery.NextAttemptAt = nil
+		if err := d.db.Save(&delivery).Error; err != nil {
+			return err
+		}
+		if hook.ID != "" {
+			d.removeIfOrphaned(&hook)
+		}
+		return nil
+	}
+
+	status, body, err := d.send(ctx, &hook, &delivery)
+	if ctx.Err() != nil {
+		// Interrupted by shutdown; the queue runs the job again on the next start
+		return ctx.Err()
+	}
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.Error = ""
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case job.Attempts >= job.MaxAttempts:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(d.queue.Backoff(job.Attempts))
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.Error = err.Error()
+		delivery.NextAttemptAt = &next
+	}
+
+	if saveErr := d.db.Save(&delivery).Error; saveErr != nil {
+		logger.Error().Err(saveErr).Str("delivery_id", delivery.ID).Msg("Failed to update webhook delivery")
+	}
+	if delivery.Status != models.WebhookDeliveryPending {
+		d.removeIfOrphaned(&hook)
+	}
+	return err
+}
+
+// send performs the signed HTTP request. A non-2xx response is returned as error.
+func (d *Dispatcher) send(ctx context.Context, hook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Formera-Webhooks/1.0")
+	req.Header.Set("X-Formera-Event", string(delivery.Event))
+	req.Header.Set("X-Formera-Delivery", delivery.ID)
+	req.Header.Set("X-Formera-Signature", Sign(hook.Secret, time.Now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(respBody), fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(respBody), nil
+}
@@ -0,0 +1,279 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"formera/internal/jobs"
+	"formera/internal/models"
+	"formera/internal/testutil"
+)
+
+func setupWebhook(t *testing.T, url string) (*Dispatcher, *jobs.Queue, *models.Form, *models.Webhook) {
+	t.Helper()
+	db := testutil.SetupTestDB(t)
+	user := testutil.CreateTestUser(t, db, "owner@example.com", "password123", models.RoleUser)
+
+	form := &models.Form{
+		UserID: user.ID,
+		Title:  "Contact",
+		Fields: models.FormFields{
+			{ID: "h1", Type: models.FieldTypeHeading, Label: "About you"},
+			{ID: "name", Type: models.FieldTypeText, Label: "Name"},
+		},
+	}
+	db.Create(form)
+
+	hook := &models.Webhook{
+		FormID: form.ID,
+		URL:    url,
+		Secret: "s3cret",
+		Events: models.WebhookEvents{models.WebhookEventSubmissionCreated},
+		Active: true,
+	}
+	db.Create(hook)
+
+	q := jobs.New(db, jobs.Config{RetryDelay: time.Millisecond, MaxRetryDelay: time.Millisecond})
+	d := NewDispatcher(db, Config{MaxAttempts: 3, AllowPrivateNetworks: true})
+	d.Register(q)
+	q.RunDue() // Initial cleanup
+	return d, q, form, hook
+}
+
+func TestDispatcher_DeliversSignedPayload(t *testing.T) {
+	var (
+		gotBody      []byte
+		gotSignature string
+		gotEvent     string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get("X-Formera-Signature")
+		gotEvent = r.Header.Get("X-Formera-Event")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d, q, form, hook := setupWebhook(t, server.URL)
+	submission := &models.Submission{ID: "sub-1", FormID: form.ID, Data: models.SubmissionData{"name": "Jane"}}
+
+	d.Dispatch(models.WebhookEventSubmissionCreated, form, submission)
+	d.Dispatch(models.WebhookEventSubmissionDeleted, form, submission) // Not subscribed
+
+	if n := q.RunDue(); n != 1 {
+		t.Fatalf("expected 1 delivery, got %d", n)
+	}
+
+	if gotEvent != string(models.WebhookEventSubmissionCreated) {
+		t.Errorf("unexpected event header %q", gotEvent)
+	}
+
+	// Signature must verify against the received body
+	parts := strings.Split(gotSignature, ",")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "t=") {
+		t.Fatalf("malformed signature %q", gotSignature)
+	}
+	ts, _ := strconv.ParseInt(strings.TrimPrefix(parts[0], "t="), 10, 64)
+	if expected := Sign(hook.Secret, time.Unix(ts, 0), gotBody); expected != gotSignature {
+		t.Errorf("signature mismatch: got %q, expected %q", gotSignature, expected)
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(gotBody, &payload); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if payload.Answers["name"].Label != "Name" || payload.Answers["name"].Value != "Jane" {
+		t.Errorf("expected labelled answer, got %+v", payload.Answers["name"])
+	}
+	if _, ok := payload.Answers["h1"]; ok {
+		t.Error("layout fields should not be part of the payload")
+	}
+
+	var delivery models.WebhookDelivery
+	d.db.First(&delivery, "webhook_id = ?", hook.ID)
+	if delivery.Status != models.WebhookDeliverySucceeded || delivery.Attempts != 1 || delivery.ResponseStatus != http.StatusNoContent {
+		t.Errorf("unexpected delivery state: %+v", delivery)
+	}
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	d, q, form, hook := setupWebhook(t, server.URL)
+	d.Dispatch(models.WebhookEventSubmissionCreated, form, &models.Submission{ID: "sub-1"})
+
+	q.RunDue()
+
+	var delivery models.WebhookDelivery
+	d.db.First(&delivery, "webhook_id = ?", hook.ID)
+	if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 1 || delivery.NextAttemptAt == nil {
+		t.Fatalf("expected delivery to be rescheduled, got %+v", delivery)
+	}
+
+	for i := 0; i < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+		q.RunDue()
+	}
+
+	d.db.First(&delivery, "id = ?", delivery.ID)
+	if delivery.Status != models.WebhookDeliveryFailed || delivery.Attempts != 3 {
+		t.Errorf("expected delivery to fail after 3 attempts, got %+v", delivery)
+	}
+	if atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("expected 3 requests, got %d", calls)
+	}
+
+	redelivery, err := d.Redeliver(&delivery)
+	if err != nil {
+		t.Fatalf("Redeliver returned error: %v", err)
+	}
+	if redelivery.ID == delivery.ID || redelivery.Payload != delivery.Payload {
+		t.Error("redelivery should be a new delivery with the same payload")
+	}
+	q.RunDue()
+	if atomic.LoadInt32(&calls) != 4 {
+		t.Errorf("expected the redelivery to be sent, got %d requests", calls)
+	}
+
+	var dead int64
+	d.db.Model(&models.Job{}).Where("type = ? AND status = ?", JobType, models.JobStatusDead).Count(&dead)
+	if dead != 1 {
+		t.Errorf("expected the exhausted delivery job to be dead, got %d", dead)
+	}
+}
+
+func TestDispatcher_RejectsPrivateAddresses(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	d, q, form, hook := setupWebhook(t, server.URL)
+	d.client = newClient(Config{Timeout: time.Second})
+	d.Dispatch(models.WebhookEventSubmissionCreated, form, &models.Submission{ID: "sub-1"})
+	q.RunDue()
+
+	var delivery models.WebhookDelivery
+	d.db.First(&delivery, "webhook_id = ?", hook.ID)
+	if atomic.LoadInt32(&calls) != 0 {
+		t.Error("expected no request to reach the loopback receiver")
+	}
+	if delivery.Status != models.WebhookDeliveryPending || !strings.Contains(delivery.Error, ErrPrivateAddress.Error()) {
+		t.Errorf("expected the delivery to fail with a private address error, got %+v", delivery)
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"0.0.0.0":          false,
+		"100.64.0.1":       false,
+		"::ffff:127.0.0.1": false,
+		"64:ff9b::a00:1":   false,
+	}
+	for addr, want := range tests {
+		if got := isPublic(net.ParseIP(addr)); got != want {
+			t.Errorf("isPublic(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestDispatcher_RemovesWebhooksOfDeletedForms(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d, q, form, hook := setupWebhook(t, server.URL)
+	d.db.Model(hook).Update("events", models.WebhookEvents{models.WebhookEventSubmissionDeleted})
+	d.db.Delete(form)
+
+	d.Dispatch(models.WebhookEventSubmissionDeleted, form, &models.Submission{ID: "sub-1"}, &models.Submission{ID: "sub-2"})
+	if err := RemoveIdle(d.db, []string{form.ID}); err != nil {
+		t.Fatalf("RemoveIdle returned error: %v", err)
+	}
+
+	var count int64
+	d.db.Model(&models.Webhook{}).Where("id = ?", hook.ID).Count(&count)
+	if count != 1 {
+		t.Fatal("expected the webhook to be kept while deliveries are pending")
+	}
+
+	if n := q.RunDue(); n != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", n)
+	}
+	d.db.Model(&models.Webhook{}).Where("id = ?", hook.ID).Count(&count)
+	if count != 0 {
+		t.Error("expected the webhook to be removed after its last delivery")
+	}
+	d.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", hook.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("expected the delivery log to be removed with the webhook, got %d", count)
+	}
+}
+
+func TestDispatcher_CleanupAndPurge(t *testing.T) {
+	d, q, form, hook := setupWebhook(t, "https://example.com/hook")
+	d.Dispatch(models.WebhookEventSubmissionCreated, form, &models.Submission{ID: "sub-1"}, &models.Submission{ID: "sub-2"})
+
+	var deliveries []models.WebhookDelivery
+	d.db.Where("webhook_id = ?", hook.ID).Order("submission_id").Find(&deliveries)
+	if len(deliveries) != 2 || deliveries[0].SubmissionID != "sub-1" || deliveries[1].SubmissionID != "sub-2" {
+		t.Fatalf("expected a delivery per submission, got %+v", deliveries)
+	}
+
+	// Finished deliveries past the retention period are deleted, pending ones kept
+	old := time.Now().Add(-d.config.Retention - time.Hour)
+	d.db.Model(&models.WebhookDelivery{}).Where("id = ?", deliveries[0].ID).
+		Updates(map[string]interface{}{"status": models.WebhookDeliverySucceeded, "created_at": old})
+	d.db.Model(&models.WebhookDelivery{}).Where("id = ?", deliveries[1].ID).Update("created_at", old)
+	if err := d.cleanup(context.Background(), &models.Job{}); err != nil {
+		t.Fatalf("cleanup returned error: %v", err)
+	}
+
+	var remaining []models.WebhookDelivery
+	d.db.Where("webhook_id = ?", hook.ID).Find(&remaining)
+	if len(remaining) != 1 || remaining[0].ID != deliveries[1].ID {
+		t.Errorf("expected only the pending delivery to remain, got %+v", remaining)
+	}
+	var next models.Job
+	d.db.Where("type = ? AND status = ?", CleanupJobType, models.JobStatusPending).First(&next)
+	if next.RunAt.Before(time.Now().Add(cleanupInterval - time.Minute)) {
+		t.Errorf("expected the next cleanup to be scheduled, got %+v", next)
+	}
+
+	if err := PurgeSubmissions(d.db, []string{"sub-2"}); err != nil {
+		t.Fatalf("PurgeSubmissions returned error: %v", err)
+	}
+	var count int64
+	d.db.Model(&models.WebhookDelivery{}).Count(&count)
+	if count != 0 {
+		t.Errorf("expected the deliveries of the purged submission to be deleted, got %d", count)
+	}
+	// The queued attempt finds nothing to send
+	q.RunDue()
+}
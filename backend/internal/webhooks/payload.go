@@ -0,0 +1,86 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"formera/internal/models"
+)
+
+// Payload is the JSON body sent to webhook endpoints
+type Payload struct {
+	Event      models.WebhookEvent `json:"event"`
+	OccurredAt time.Time           `json:"occurred_at"`
+	Form       PayloadForm         `json:"form"`
+	Submission PayloadSubmission   `json:"submission"`
+	// Answers keyed by field ID, each carrying the field label. Not part of
+	// deleted events, which only identify the submission.
+	Answers map[string]PayloadAnswer `json:"answers,omitempty"`
+}
+
+type PayloadForm struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+}
+
+type PayloadSubmission struct {
+	ID        string                     `json:"id"`
+	CreatedAt time.Time                  `json:"created_at"`
+	Metadata  *models.SubmissionMetadata `json:"metadata,omitempty"`
+}
+
+type PayloadAnswer struct {
+	Label string           `json:"label"`
+	Type  models.FieldType `json:"type,omitempty"`
+	Value interface{}      `json:"value"`
+}
+
+// NewPayload builds the webhook payload for a submission event
+func NewPayload(event models.WebhookEvent, form *models.Form, submission *models.Submission) Payload {
+	payload := Payload{
+		Event:      event,
+		OccurredAt: time.Now().UTC(),
+		Form:       PayloadForm{ID: form.ID, Title: form.Title, Slug: form.Slug},
+		Submission: PayloadSubmission{
+			ID:        submission.ID,
+			CreatedAt: submission.CreatedAt,
+		},
+	}
+	// Deleted submissions may have been erased on request, so their data is not sent again
+	if event == models.WebhookEventSubmissionDeleted {
+		return payload
+	}
+
+	answers := make(map[string]PayloadAnswer, len(submission.Data))
+	for _, field := range form.Fields {
+		if field.Type.IsLayout() {
+			continue
+		}
+		answers[field.ID] = PayloadAnswer{Label: field.Label, Type: field.Type, Value: submission.Data[field.ID]}
+	}
+	// Keep answers to fields that were removed from the form since
+	for id, val := range submission.Data {
+		if _, ok := answers[id]; !ok {
+			answers[id] = PayloadAnswer{Label: id, Value: val}
+		}
+	}
+	payload.Submission.Metadata = &submission.Metadata
+	payload.Answers = answers
+	return payload
+}
+
+// Sign returns the signature header value for a payload: "t=<unix>,v1=<hex>" where
+// v1 is the HMAC-SHA256 of "<unix>.<body>" keyed with the webhook secret.
+// Including the timestamp lets receivers reject replayed requests.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -12,6 +12,17 @@ export const getFileUrl = (pathOrUrl: string | undefined | null): string => {
 	return `${apiUrl}/uploads/${cleanPath}`;
 };
 
+// Error response of the API with its status code and body
+export class ApiError extends Error {
+	constructor(
+		message: string,
+		public status: number,
+		public data: Record<string, unknown>,
+	) {
+		super(message);
+	}
+}
+
 export const useApi = () => {
 	const config = useRuntimeConfig();
 	const apiUrl = config.public.apiUrl as string;
@@ -44,7 +55,7 @@ export const useApi = () => {
 
 		if (!response.ok) {
 			const error = await response.json().catch(() => ({ error: "Request failed" }));
-			throw new Error(error.error || "Request failed");
+			throw new ApiError(error.error || "Request failed", response.status, error);
 		}
 
 		return response.json();
@@ -72,16 +83,22 @@ export const useApi = () => {
 			const query = searchParams.toString();
 			return request(`/forms${query ? `?${query}` : ""}`);
 		},
+		dashboard: (): Promise<DashboardSummary> => request("/dashboard"),
 		get: (id: string): Promise<Form> => request(`/forms/${id}`),
-		getPublic: (id: string): Promise<Form> => request(`/public/forms/${id}`),
+		getPublic: (id: string, tracking?: Record<string, string>): Promise<Form> => {
+			const query = tracking ? new URLSearchParams(tracking).toString() : "";
+			return request(`/public/forms/${id}${query ? `?${query}` : ""}`);
+		},
 		create: (form: Partial<Form>): Promise<Form> =>
 			request("/forms", {
 				method: "POST",
 				body: JSON.stringify(form),
 			}),
-		update: (id: string, form: UpdateFormRequest): Promise<Form> =>
+		// With a revision, rejects with status 409 and the current form if it was changed in the meantime
+		update: (id: string, form: UpdateFormRequest, revision?: number): Promise<Form> =>
 			request(`/forms/${id}`, {
 				method: "PUT",
+				headers: revision ? { "If-Match": `"${revision}"` } : {},
 				body: JSON.stringify(form),
 			}),
 		delete: (id: string): Promise<void> =>
@@ -92,6 +109,35 @@ export const useApi = () => {
 			request(`/forms/${id}/duplicate`, {
 				method: "POST",
 			}),
+		exportBundle: (id: string, format: "json" | "zip" = "json"): string => {
+			const searchParams = new URLSearchParams({ format, token: getToken() ?? "" });
+			return `${apiBase}/forms/${id}/bundle?${searchParams.toString()}`;
+		},
+		importBundle: async (file: File): Promise<Form> => {
+			const token = getToken();
+			const formData = new FormData();
+			formData.append("file", file);
+
+			const response = await fetch(`${apiBase}/forms/import`, {
+				method: "POST",
+				headers: {
+					...(token ? { Authorization: `Bearer ${token}` } : {}),
+				},
+				body: formData,
+			});
+			if (!response.ok) {
+				const error = await response.json().catch(() => ({ error: "Import failed" }));
+				throw new Error(error.error || `Import failed (${response.status})`);
+			}
+			return response.json();
+		},
+		versions: (id: string): Promise<FormVersionSummary[]> => request(`/forms/${id}/versions`),
+		version: (id: string, version: number): Promise<FormVersion> => request(`/forms/${id}/versions/${version}`),
+		diffVersions: (id: string, from: number, to?: number): Promise<FormVersionDiff> => {
+			const params = new URLSearchParams({ from: from.toString() });
+			if (to) params.append("to", to.toString());
+			return request(`/forms/${id}/versions/diff?${params.toString()}`);
+		},
 		checkSlugAvailability: (slug: string, excludeId?: string): Promise<{ available: boolean; slug: string }> => {
 			const params = new URLSearchParams({ slug });
 			if (excludeId) params.append("exclude_id", excludeId);
@@ -104,11 +150,59 @@ export const useApi = () => {
 			}),
 	};
 
+	const templatesApi = {
+		list: (category?: string): Promise<FormTemplate[]> =>
+			request(`/templates${category ? `?category=${encodeURIComponent(category)}` : ""}`),
+		get: (id: string): Promise<FormTemplate> => request(`/templates/${id}`),
+		use: (id: string, title?: string): Promise<Form> =>
+			request(`/templates/${id}/use`, {
+				method: "POST",
+				body: JSON.stringify({ title }),
+			}),
+		// Admin only
+		mark: (formId: string, category: string, description?: string): Promise<FormTemplate> =>
+			request(`/forms/${formId}/template`, {
+				method: "PUT",
+				body: JSON.stringify({ category, description }),
+			}),
+		unmark: (formId: string): Promise<void> =>
+			request(`/forms/${formId}/template`, {
+				method: "DELETE",
+			}),
+	};
+
+	const sharesApi = {
+		list: (formId: string): Promise<FormSharesResponse> => request(`/forms/${formId}/shares`),
+		create: (formId: string, email: string, role: FormRole): Promise<FormShare> =>
+			request(`/forms/${formId}/shares`, {
+				method: "POST",
+				body: JSON.stringify({ email, role }),
+			}),
+		update: (formId: string, shareId: string, role: FormRole): Promise<FormShare> =>
+			request(`/forms/${formId}/shares/${shareId}`, {
+				method: "PUT",
+				body: JSON.stringify({ role }),
+			}),
+		delete: (formId: string, shareId: string): Promise<void> =>
+			request(`/forms/${formId}/shares/${shareId}`, {
+				method: "DELETE",
+			}),
+	};
+
 	const submissionsApi = {
-		submit: (formId: string, formData: Record<string, unknown>, metadata?: Record<string, string>): Promise<{ message: string; submission: Submission }> =>
+		submit: (
+			formId: string,
+			formData: Record<string, unknown>,
+			metadata?: Record<string, string>,
+			startedAt?: Date,
+		): Promise<{ message: string; submission: Submission }> =>
 			request(`/public/forms/${formId}/submit`, {
 				method: "POST",
-				body: JSON.stringify({ data: formData, metadata }),
+				body: JSON.stringify({ data: formData, metadata, started_at: startedAt?.toISOString() }),
+			}),
+		start: (formId: string): Promise<void> =>
+			request(`/public/forms/${formId}/start`, {
+				method: "POST",
 			}),
 		list: (formId: string, params?: PaginationParams): Promise<SubmissionsResponse> => {
 			const searchParams = new URLSearchParams();
@@ -118,18 +212,119 @@ export const useApi = () => {
 			return request(`/forms/${formId}/submissions${query ? `?${query}` : ""}`);
 		},
 		get: (formId: string, submissionId: string): Promise<Submission> => request(`/forms/${formId}/submissions/${submissionId}`),
+		// Resolves with the per-row errors if rows are invalid, in which case nothing was imported
+		import: async (formId: string, file: File, options?: SubmissionImportOptions): Promise<SubmissionImportResult> => {
+			const token = getToken();
+			const formData = new FormData();
+			formData.append("file", file);
+			if (options?.mapping) formData.append("mapping", JSON.stringify(options.mapping));
+
+			const searchParams = new URLSearchParams();
+			if (options?.format) searchParams.set("format", options.format);
+			if (options?.delimiter) searchParams.set("delimiter", options.delimiter);
+			if (options?.separator) searchParams.set("separator", options.separator);
+			if (options?.dryRun) searchParams.set("dry_run", "true");
+			const query = searchParams.toString();
+
+			const response = await fetch(`${apiBase}/forms/${formId}/submissions/import${query ? `?${query}` : ""}`, {
+				method: "POST",
+				headers: {
+					...(token ? { Authorization: `Bearer ${token}` } : {}),
+				},
+				body: formData,
+			});
+			if (!response.ok && response.status !== 422) {
+				const error = await response.json().catch(() => ({ error: "Import failed" }));
+				throw new Error(error.error || `Import failed (${response.status})`);
+			}
+			return response.json();
+		},
 		delete: (formId: string, submissionId: string): Promise<void> =>
 			request(`/forms/${formId}/submissions/${submissionId}`, {
 				method: "DELETE",
 			}),
 		stats: (formId: string): Promise<FormStats> => request(`/forms/${formId}/stats`),
-		exportCSV: (formId: string): string => {
-			const token = getToken();
-			return `${apiBase}/forms/${formId}/export/csv?token=${token}`;
+		exportCSV: (formId: string, options?: Record<string, string>): string => {
+			const searchParams = new URLSearchParams({ ...options, token: getToken() ?? "" });
+			return `${apiBase}/forms/${formId}/export/csv?${searchParams.toString()}`;
 		},
-		exportJSON: (formId: string): string => {
+		exportXLSX: (formId: string, options?: Record<string, string>): string => {
+			const searchParams = new URLSearchParams({ ...options, token: getToken() ?? "" });
+			return `${apiBase}/forms/${formId}/export/xlsx?${searchParams.toString()}`;
+		},
+		exportPDF: (formId: string, submissionIds?: string[], options?: Record<string, string>): string => {
+			const searchParams = new URLSearchParams({ ...options, token: getToken() ?? "" });
+			if (submissionIds?.length) searchParams.set("ids", submissionIds.join(","));
+			return `${apiBase}/forms/${formId}/export/pdf?${searchParams.toString()}`;
+		},
+		submissionPDF: (formId: string, submissionId: string): string => {
+			const searchParams = new URLSearchParams({ token: getToken() ?? "" });
+			return `${apiBase}/forms/${formId}/submissions/${submissionId}/pdf?${searchParams.toString()}`;
+		},
+		exportZip: (formId: string, options?: Record<string, string>): string => {
+			const searchParams = new URLSearchParams({ ...options, token: getToken() ?? "" });
+			return `${apiBase}/forms/${formId}/export/zip?${searchParams.toString()}`;
+		},
+		startExport: (formId: string, options?: Record<string, string>): Promise<FormExport> => {
+			const searchParams = new URLSearchParams({ ...options, async: "true" });
+			return request(`/forms/${formId}/export/zip?${searchParams.toString()}`);
+		},
+		listExports: (formId: string): Promise<FormExport[]> => request(`/forms/${formId}/exports`),
+		getExport: (formId: string, exportId: string): Promise<FormExport> => request(`/forms/${formId}/exports/${exportId}`),
+		// Fetches the file of a finished export, which is only served with the Authorization header
+		downloadExport: async (formId: string, exportId: string): Promise<Blob> => {
 			const token = getToken();
-			return `${apiBase}/forms/${formId}/export/json?token=${token}`;
+			const response = await fetch(`${apiBase}/forms/${formId}/exports/${exportId}/download`, {
+				headers: {
+					...(token ? { Authorization: `Bearer ${token}` } : {}),
+				},
+			});
+			if (!response.ok) {
+				const error = await response.json().catch(() => ({ error: "Download failed" }));
+				throw new ApiError(error.error || "Download failed", response.status, error);
+			}
+			return response.blob();
+		},
+		exportJSON: (formId: string, options?: Record<string, string>): string => {
+			const searchParams = new URLSearchParams({ ...options, token: getToken() ?? "" });
+			return `${apiBase}/forms/${formId}/export/json?${searchParams.toString()}`;
+		},
+		listExportPresets: (formId: string): Promise<ExportPreset[]> => request(`/forms/${formId}/export-presets`),
+		createExportPreset: (formId: string, preset: ExportPresetInput): Promise<ExportPreset> =>
+			request(`/forms/${formId}/export-presets`, {
+				method: "POST",
+				body: JSON.stringify(preset),
+			}),
+		updateExportPreset: (formId: string, presetId: string, preset: ExportPresetInput): Promise<ExportPreset> =>
+			request(`/forms/${formId}/export-presets/${presetId}`, {
+				method: "PUT",
+				body: JSON.stringify(preset),
+			}),
+		deleteExportPreset: (formId: string, presetId: string): Promise<void> =>
+			request(`/forms/${formId}/export-presets/${presetId}`, {
+				method: "DELETE",
+			}),
+		listExportSchedules: (formId: string): Promise<ExportSchedule[]> => request(`/forms/${formId}/export-schedules`),
+		createExportSchedule: (formId: string, schedule: ExportScheduleInput): Promise<ExportSchedule> =>
+			request(`/forms/${formId}/export-schedules`, {
+				method: "POST",
+				body: JSON.stringify(schedule),
+			}),
+		updateExportSchedule: (formId: string, scheduleId: string, schedule: ExportScheduleInput): Promise<ExportSchedule> =>
+			request(`/forms/${formId}/export-schedules/${scheduleId}`, {
+				method: "PUT",
+				body: JSON.stringify(schedule),
+			}),
+		deleteExportSchedule: (formId: string, scheduleId: string): Promise<void> =>
+			request(`/forms/${formId}/export-schedules/${scheduleId}`, {
+				method: "DELETE",
+			}),
+		listExportScheduleRuns: (formId: string, scheduleId: string, params?: PaginationParams): Promise<PaginatedResponse<FormExport[]>> => {
+			const searchParams = new URLSearchParams();
+			if (params?.page) searchParams.append("page", params.page.toString());
+			if (params?.pageSize) searchParams.append("page_size", params.pageSize.toString());
+			const query = searchParams.toString();
+			return request(`/forms/${formId}/export-schedules/${scheduleId}/runs${query ? `?${query}` : ""}`);
 		},
 	};
 
@@ -240,6 +435,8 @@ export const useApi = () => {
 	return {
 		authApi,
 		formsApi,
+		templatesApi,
+		sharesApi,
 		submissionsApi,
 		setupApi,
 		settingsApi,
@@ -81,7 +81,8 @@ export const useFormsStore = defineStore("forms", () => {
 
 	const updateForm = async (id: string, formData: UpdateFormRequest) => {
 		try {
-			const updated = await formsApi.update(id, formData);
+			const revision = currentForm.value?.id === id ? currentForm.value.revision : undefined;
+			const updated = await formsApi.update(id, formData, revision);
 			const index = forms.value.findIndex((f) => f.id === id);
 			if (index > -1) {
 				forms.value[index] = updated;
@@ -93,6 +94,14 @@ export const useFormsStore = defineStore("forms", () => {
 
 			return updated;
 		} catch (error) {
+			if (error instanceof ApiError && error.status === 409 && error.data.form) {
+				// Saved by someone else in the meantime; continue from their version
+				if (currentForm.value?.id === id) {
+					currentForm.value = error.data.form as Form;
+				}
+				toastStore.error(t("common.error"), t("store.forms.staleError"));
+				throw error;
+			}
 			toastStore.error(t("common.error"), t("store.forms.saveError"));
 			throw error;
 		}
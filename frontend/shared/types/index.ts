@@ -152,10 +152,47 @@ export interface Form {
 	settings: FormSettings;
 	status: FormStatus;
 	password_protected: boolean;
+	is_template: boolean;
+	template_category?: string;
+	template_description?: string;
+	current_version: number; // 0 until first published
+	revision: number; // Incremented on each update, sent as If-Match
+	role?: FormRole; // Role of the current user
 	created_at: string;
 	updated_at: string;
 }
 
+// owner: full access; editor: fields, settings and submissions; viewer: read only
+export type FormRole = "owner" | "editor" | "viewer";
+
+export interface FormShare {
+	id: string;
+	form_id: string;
+	user_id: string;
+	role: FormRole;
+	created_by: string;
+	email: string;
+	name: string;
+	created_at: string;
+	updated_at: string;
+}
+
+export interface FormSharesResponse {
+	owner: FormShare; // Creator of the form
+	shares: FormShare[];
+}
+
+// Template to start new forms from; built-in or a form marked by an admin
+export interface FormTemplate {
+	id: string;
+	title: string;
+	description: string;
+	category: string;
+	built_in: boolean;
+	fields: FormField[];
+	settings: FormSettings;
+}
+
 // Request type for updating forms with password
 export interface UpdateFormRequest extends Partial<Form> {
 	password?: string; // Only sent when setting a new password
@@ -188,9 +225,44 @@ export interface SubmissionMetadata {
 export interface Submission {
 	id: string;
 	form_id: string;
+	form_version: number; // 0 if unknown
 	data: Record<string, unknown>;
 	metadata: SubmissionMetadata;
 	created_at: string;
+	fields?: FormField[]; // Fields of the answered version, only when fetched by ID
+}
+
+// Immutable snapshot of a form's fields
+export interface FormVersion {
+	id: string;
+	form_id: string;
+	version: number;
+	title: string;
+	fields: FormField[];
+	created_at: string;
+}
+
+export interface FormVersionSummary {
+	version: number;
+	title: string;
+	fields: number;
+	submissions: number;
+	created_at: string;
+}
+
+export interface FormFieldChange {
+	id: string;
+	properties: string[];
+	before: FormField;
+	after: FormField;
+}
+
+export interface FormVersionDiff {
+	from: number;
+	to: number;
+	added: FormField[];
+	removed: FormField[];
+	changed: FormFieldChange[];
 }
 
 export interface AuthResponse {
@@ -215,6 +287,8 @@ export interface PaginationParams {
 
 export interface SubmissionsResponse {
 	form: Form;
+	// Fields of older form versions answered by the listed submissions, by version
+	versions: Record<string, FormField[]>;
 	submissions: PaginatedResponse<Submission[]>;
 }
 
@@ -223,6 +297,121 @@ export interface FormStats {
 	field_stats: Record<string, Record<string, number>>;
 }
 
+export interface DashboardForm {
+	id: string;
+	title: string;
+	status: FormStatus;
+	total_submissions: number;
+	submissions_last_7_days: number;
+	submissions_last_30_days: number;
+	last_submission_at: string | null;
+	max_submissions?: number;
+	remaining_submissions?: number;
+	capacity_percent?: number;
+}
+
+export interface DashboardSummary {
+	totals: {
+		forms: number;
+		published_forms: number;
+		submissions: number;
+		submissions_last_7_days: number;
+		submissions_last_30_days: number;
+	};
+	forms: DashboardForm[];
+	near_capacity: DashboardForm[];
+}
+
+export type ExportStatus = "pending" | "running" | "succeeded" | "failed" | "expired";
+
+export interface FormExport {
+	id: string;
+	form_id: string;
+	user_id: string;
+	schedule_id?: string;
+	format: string;
+	status: ExportStatus;
+	submissions: number;
+	size: number;
+	filename?: string;
+	error?: string;
+	created_at: string;
+	finished_at?: string;
+	expires_at?: string;
+	since?: string;
+	until?: string;
+	download_url?: string;
+}
+
+export type ExportFormat = "csv" | "json" | "xlsx" | "zip" | "pdf";
+
+// Parameters are the query parameters of the export endpoint, e.g. { columns: "name,email", last: "7d" }
+export interface ExportPresetInput {
+	name: string;
+	format: ExportFormat;
+	params: Record<string, string>;
+}
+
+export interface ExportPreset extends ExportPresetInput {
+	id: string;
+	form_id: string;
+	user_id: string;
+	created_at: string;
+	updated_at: string;
+}
+
+// Mapping assigns columns or JSON keys to field IDs or "submitted_at"; guessed from the column names if omitted
+export interface SubmissionImportOptions {
+	format?: "csv" | "json";
+	mapping?: Record<string, string>;
+	delimiter?: "comma" | "semicolon" | "tab" | "pipe";
+	separator?: string;
+	dryRun?: boolean;
+}
+
+export interface SubmissionImportError {
+	row: number;
+	column?: string;
+	error: string;
+}
+
+export interface SubmissionImportResult {
+	rows: number;
+	imported: number;
+	invalid: number;
+	errors: SubmissionImportError[];
+	mapping: Record<string, string>;
+	ignored?: string[];
+	dry_run: boolean;
+	error?: string;
+}
+
+export type ScheduleFormat = "csv" | "json" | "xlsx" | "zip";
+
+// Spec is a five-field cron expression, e.g. "0 7 * * mon"; email is a comma separated list of recipients
+export interface ExportScheduleInput {
+	name: string;
+	spec: string;
+	timezone?: string;
+	format: ScheduleFormat;
+	params: Record<string, string>;
+	incremental: boolean;
+	email?: string;
+	active?: boolean;
+}
+
+export interface ExportSchedule extends ExportScheduleInput {
+	id: string;
+	form_id: string;
+	user_id: string;
+	timezone: string;
+	active: boolean;
+	next_run_at?: string;
+	last_run_at?: string;
+	created_at: string;
+	updated_at: string;
+}
+
 export interface FooterLink {
 	label: string;
 	url: string;